@@ -0,0 +1,62 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import "sync/atomic"
+
+// accessible is true if accessibility mode has been enabled with
+// SetAccessible or --accessible.
+var accessible atomic.Bool
+
+// Accessible returns true if accessibility mode is enabled, through
+// --accessible, the ACCESSIBLE environment variable, or SetAccessible. In
+// accessibility mode, clix output (version information, help, logging,
+// warnings and statistics) is uncolored (unless forced with
+// CLI.SetColorMode) and uses plain ASCII, for screen readers. Applications
+// should also check this to disable spinners, progress animations, and
+// similar output.
+func Accessible() bool {
+	return accessible.Load() || envTrue("ACCESSIBLE")
+}
+
+// SetAccessible enables or disables accessibility mode. See Accessible. This
+// is process-wide, and affects all CLIs. When enabled by a CLI (e.g. with
+// --accessible), it's disabled again by CLI.Reset.
+func SetAccessible(enabled bool) {
+	accessible.Store(enabled)
+}
+
+// enableAccessible enables accessibility mode, on behalf of the CLI (e.g. with
+// --accessible), so it's disabled again by Reset.
+func (cli *CLI[T]) enableAccessible() {
+	cli.mu.Lock()
+	cli.accessible = true
+	cli.mu.Unlock()
+
+	SetAccessible(true)
+}
+
+// accessibleArg returns true if --accessible was provided in args (before any
+// "--" terminator). This allows accessibility mode to apply to output
+// generated before flags are parsed (e.g. help).
+func accessibleArg(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--accessible":
+			return true
+		}
+	}
+	return false
+}
+
+// bullet returns the bullet used for lists in clix output.
+func bullet() string {
+	if Accessible() {
+		return "-"
+	}
+	return "•"
+}
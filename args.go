@@ -0,0 +1,96 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrMissingArg is returned by BindArgs when fewer positional arguments were
+// provided than were requested.
+var ErrMissingArg = errors.New("missing positional argument")
+
+// Arg returns the positional argument at index i, or an empty string if it
+// wasn't provided. Positional arguments are only available after Parse(), and
+// include everything provided after "--".
+func (cli *CLI[T]) Arg(i int) string {
+	if i < 0 || i >= len(cli.Args) {
+		return ""
+	}
+
+	return cli.Args[i]
+}
+
+// NArg returns the number of positional arguments remaining after parsing.
+func (cli *CLI[T]) NArg() int {
+	return len(cli.Args)
+}
+
+// BindArgs binds positional arguments, in order, to the provided pointers.
+// Supported types are strings, bools, ints, uints, floats, time.Duration, and
+// anything implementing encoding.TextUnmarshaler. If the last pointer is a
+// *[]string, it receives all remaining arguments. ErrMissingArg is returned
+// (wrapped) if there are fewer arguments than pointers, and an error is
+// returned if there are more arguments than pointers.
+//
+// Example:
+//
+//	var (
+//		src, dst string
+//		count    int
+//	)
+//
+//	if err := cli.BindArgs(&src, &dst, &count); err != nil {
+//		logger.WithError(err).Fatal("invalid arguments")
+//	}
+func (cli *CLI[T]) BindArgs(dst ...any) error {
+	for i, d := range dst {
+		if rest, ok := d.(*[]string); ok && i == len(dst)-1 {
+			if i < len(cli.Args) {
+				*rest = append((*rest)[:0], cli.Args[i:]...)
+			}
+			return nil
+		}
+
+		if i >= len(cli.Args) {
+			return fmt.Errorf("%w at position %d", ErrMissingArg, i+1)
+		}
+
+		if err := bindArg(d, cli.Args[i]); err != nil {
+			return fmt.Errorf("invalid positional argument %d (%q): %w", i+1, cli.Args[i], err)
+		}
+	}
+
+	if len(cli.Args) > len(dst) {
+		return fmt.Errorf("too many positional arguments (expected %d, got %d)", len(dst), len(cli.Args))
+	}
+
+	return nil
+}
+
+func bindArg(dst any, s string) error {
+	switch d := dst.(type) {
+	case encoding.TextUnmarshaler:
+		return d.UnmarshalText([]byte(s))
+	case *time.Duration:
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("expected non-nil pointer, got %T", dst)
+	}
+
+	return setScalar(v.Elem(), s)
+}
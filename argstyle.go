@@ -0,0 +1,147 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// isBoolOption returns true if the provided option is a boolean flag (or a
+// slice of them).
+func isBoolOption(option *flags.Option) bool {
+	t := reflect.TypeOf(option.Value())
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Bool
+}
+
+// normalizeArgs applies the argument style options to the provided arguments
+// (excluding the program name), before they're parsed. go-flags natively
+// allows flags after positional arguments (unless OptDisableInterspersed is
+// set, see newParser), both "--flag value" and "--flag=value", and combined
+// short flags (e.g. "-abc"), so this handles what it doesn't:
+//
+//   - OptEnableFlagNegation: "--no-<flag>" is rewritten to "--<flag>=false",
+//     for boolean flags, unless a "no-<flag>" flag exists (e.g. --no-cache).
+//   - OptRequireEquals: values of long flags must be provided as
+//     "--flag=value".
+//   - OptDisableCombinedShort: combined short flags, and short flags with
+//     attached values (e.g. "-abc" or "-ovalue") are rejected.
+//
+// Arguments after "--" are left as-is, as are values of flags.
+func (cli *CLI[T]) normalizeArgs(p *flags.Parser, args []string) ([]string, error) {
+	negation := cli.IsSet(OptEnableFlagNegation)
+	equals := cli.IsSet(OptRequireEquals)
+	combined := cli.IsSet(OptDisableCombinedShort)
+
+	if !negation && !equals && !combined {
+		return args, nil
+	}
+
+	// Flags are looked up across all commands, as sub-commands are resolved
+	// while parsing.
+	long := make(map[string]*flags.Option)
+	short := make(map[rune]*flags.Option)
+
+	walkOptions(p.Command, func(option *flags.Option) {
+		if name := option.LongNameWithNamespace(); name != "" {
+			long[name] = option
+		}
+
+		if option.ShortName != 0 {
+			short[option.ShortName] = option
+		}
+	})
+
+	out := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			out = append(out, args[i:]...)
+			break
+		}
+
+		if name, ok := strings.CutPrefix(arg, "--"); ok {
+			if _, _, hasValue := strings.Cut(name, "="); hasValue {
+				out = append(out, arg)
+				continue
+			}
+
+			if target, isNegated := strings.CutPrefix(name, "no-"); negation && isNegated && long[name] == nil {
+				if option := long[target]; option != nil && isBoolOption(option) {
+					out = append(out, "--"+target+"=false")
+					continue
+				}
+			}
+
+			option := long[name]
+			if option == nil || !takesValue(option) {
+				out = append(out, arg)
+				continue
+			}
+
+			if equals {
+				return nil, &flags.Error{
+					Type:    flags.ErrExpectedArgument,
+					Message: fmt.Sprintf("expected argument for flag `--%s' in the form --%s=VALUE", name, name),
+				}
+			}
+
+			// The value is passed through as-is.
+			out = append(out, arg)
+			if i+1 < len(args) {
+				i++
+				out = append(out, args[i])
+			}
+			continue
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			out = append(out, arg)
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(arg[1:])
+
+		option := short[r]
+		if option == nil {
+			out = append(out, arg)
+			continue
+		}
+
+		if combined && len(arg) > 1+size {
+			if takesValue(option) {
+				return nil, &flags.Error{
+					Type:    flags.ErrExpectedArgument,
+					Message: fmt.Sprintf("expected argument for flag `-%c' as a separate argument (-%c %s)", r, r, arg[1+size:]),
+				}
+			}
+
+			return nil, &flags.Error{
+				Type:    flags.ErrUnknownFlag,
+				Message: fmt.Sprintf("combined short flags are not supported (use -%s instead of %s)", strings.Join(strings.Split(arg[1:], ""), " -"), arg),
+			}
+		}
+
+		out = append(out, arg)
+
+		// The value is passed through as-is.
+		if len(arg) == 1+size && takesValue(option) && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AuthorizeFunc decides whether the command with the provided path (see
+// CommandPath) may be executed, with the provided command flags (the command
+// struct, e.g. *DeployCommand). Returning an error denies execution. The
+// top-level flags are available through FlagsFrom.
+type AuthorizeFunc func(ctx context.Context, commandPath string, flags any) error
+
+// Authorize registers policy checks, which are invoked (in order of
+// registration) before any command is executed, e.g. to enforce role-based
+// access to dangerous commands in shared environments, by consulting a policy
+// engine or a local role file. Checks run after all middleware (see
+// UseMiddleware), so they can use values middleware adds to the context
+// (e.g. the authenticated user). Denials are returned as an
+// *AuthorizationError. Must be called before Parse().
+//
+// Example:
+//
+//	cli.Authorize(func(ctx context.Context, path string, flags any) error {
+//		if path == "cluster delete" && !roles.Has(currentUser(), "admin") {
+//			return errors.New("requires the admin role")
+//		}
+//		return nil
+//	})
+func (cli *CLI[T]) Authorize(fn ...AuthorizeFunc) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.authorizers = append(cli.authorizers, fn...)
+}
+
+// authorize invokes all registered policy checks for the provided command.
+func (cli *CLI[T]) authorize(ctx context.Context, command any) error {
+	cli.mu.Lock()
+	authorizers := cli.authorizers
+	path := cli.commandPath
+	cli.mu.Unlock()
+
+	for _, fn := range authorizers {
+		if err := fn(ctx, path, command); err != nil {
+			return &AuthorizationError{Command: path, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// ErrUnauthorized is returned (wrapped in an *AuthorizationError) when a
+// policy check denies execution of a command. See CLI.Authorize.
+var ErrUnauthorized = errors.New("clix: command not authorized")
+
+// AuthorizationError is returned when a policy check denies execution of a
+// command (see CLI.Authorize). Matches ErrUnauthorized with errors.Is.
+type AuthorizationError struct {
+	// Command is the path of the denied command.
+	Command string
+
+	// Err is the error returned by the policy check.
+	Err error
+}
+
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("not authorized to run %q: %v", e.Command, e.Err)
+}
+
+func (e *AuthorizationError) Unwrap() error { return e.Err }
+
+func (e *AuthorizationError) Is(target error) bool { return target == ErrUnauthorized }
+
+// ExitCode returns 77 (EX_NOPERM from sysexits.h), so denials can be
+// distinguished from other failures (see ExitCode).
+func (e *AuthorizationError) ExitCode() int { return 77 }
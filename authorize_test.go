@@ -0,0 +1,74 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lrstanley/clix"
+)
+
+type deployCommand struct {
+	executed bool
+}
+
+func (c *deployCommand) Execute(_ []string) error {
+	c.executed = true
+	return nil
+}
+
+func TestAuthorizeExitCode(t *testing.T) {
+	dir := t.TempDir()
+	for _, key := range []string{"HOME", "XDG_CONFIG_HOME", "XDG_STATE_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		t.Setenv(key, filepath.Join(dir, key))
+	}
+
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	origArgs, origStderr := os.Args, os.Stderr
+	os.Args, os.Stderr = []string{origArgs[0], "deploy"}, stderr
+	defer func() { os.Args, os.Stderr = origArgs, origStderr }()
+
+	code := -1
+	cmd := &deployCommand{}
+
+	cli := &clix.CLI[struct{}]{}
+	if err = cli.Apply(clix.WithExitFunc(func(c int) { code = c })); err != nil {
+		t.Fatal(err)
+	}
+
+	cli.AddCommand("deploy", cmd, "deploy the application")
+	cli.Authorize(func(_ context.Context, path string, _ any) error {
+		if path == "deploy" {
+			return errors.New("requires the admin role")
+		}
+		return nil
+	})
+
+	err = cli.ParseWithInit(nil)
+
+	var authErr *clix.AuthorizationError
+	if !errors.As(err, &authErr) || !errors.Is(err, clix.ErrUnauthorized) {
+		t.Fatalf("expected an authorization error, got %v", err)
+	}
+
+	if cmd.executed {
+		t.Fatal("expected the denied command to not be executed")
+	}
+
+	// The process must exit with the code of the error, not just report it
+	// in the returned error.
+	if code != 77 {
+		t.Fatalf("unexpected exit code %d, want 77", code)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxCacheSize is the default maximum size of the cache directory, in
+// bytes. See WithCache.
+const DefaultMaxCacheSize = 100 << 20 // 100MiB.
+
+// WithCache enables the --no-cache flag, and a "cache clear" command, for
+// applications using the cache (see CLI.Cache). maxSize is the maximum size of
+// the cache in bytes, where the least recently used entries are evicted once
+// exceeded. A maxSize of 0 uses DefaultMaxCacheSize, and a negative maxSize
+// disables eviction.
+func WithCache(maxSize int64) Option {
+	return func(s *settings) error {
+		s.cache = true
+		s.maxCacheSize = maxSize
+		return nil
+	}
+}
+
+// Cache is a file-based cache, with per-entry TTLs and size-bound eviction
+// (least recently used first). Entries are stored as individual files, so it
+// is safe for use across goroutines and concurrent invocations of the
+// application.
+type Cache struct {
+	dir      string
+	maxSize  int64
+	disabled bool
+}
+
+// Cache returns the application's cache, stored in the cache directory (see
+// CacheDir). If --no-cache is provided, the cache is disabled (Get always
+// misses, and Put is a no-op). Must be called after Parse().
+func (cli *CLI[T]) Cache() (*Cache, error) {
+	dir, err := cli.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCache(dir, limit(cli.settings.maxCacheSize, DefaultMaxCacheSize))
+	c.disabled = cli.NoCache
+
+	return c, nil
+}
+
+// NewCache returns a cache stored in the provided directory, limited to
+// maxSize bytes (0 or less disables eviction).
+func NewCache(dir string, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+// Dir returns the directory the cache is stored in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Disabled returns true if the cache is disabled (e.g. with --no-cache).
+func (c *Cache) Disabled() bool {
+	return c.disabled
+}
+
+// path returns the path of the entry for the provided key.
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".cache")
+}
+
+// Get returns the cached data for the provided key, if it exists and hasn't
+// expired.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	if c.disabled {
+		return nil, false
+	}
+
+	fn := c.path(key)
+
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, false
+	}
+
+	// Entries are prefixed with their expiry (unix nanoseconds, 0 for no
+	// expiry) on the first line.
+	header, data, ok := bytes.Cut(b, []byte{'\n'})
+	if !ok {
+		_ = os.Remove(fn)
+		return nil, false
+	}
+
+	expires, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil || (expires > 0 && time.Now().UnixNano() > expires) {
+		_ = os.Remove(fn)
+		return nil, false
+	}
+
+	// Track usage for eviction.
+	now := time.Now()
+	_ = os.Chtimes(fn, now, now)
+
+	return data, true
+}
+
+// Put stores data for the provided key, expiring after ttl (0 for no expiry).
+// Least recently used entries are evicted if the cache exceeds its maximum
+// size.
+func (c *Cache) Put(key string, data []byte, ttl time.Duration) error {
+	if c.disabled {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	var expires int64
+	if ttl > 0 {
+		expires = time.Now().Add(ttl).UnixNano()
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	_, _ = w.WriteString(strconv.FormatInt(expires, 10) + "\n")
+	_, _ = w.Write(data)
+
+	if err = w.Flush(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	return c.evict()
+}
+
+// Delete removes the entry for the provided key, if it exists.
+func (c *Cache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Clear removes all entries, returning the number of removed entries and the
+// bytes freed.
+func (c *Cache) Clear() (removed int, freed int64, err error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, e := range entries {
+		if err = os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, freed, err
+		}
+
+		removed++
+		freed += e.Size()
+	}
+
+	return removed, freed, nil
+}
+
+// Size returns the total size of all entries, in bytes.
+func (c *Cache) Size() (int64, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, e := range entries {
+		size += e.Size()
+	}
+
+	return size, nil
+}
+
+// entries returns all cache entries.
+func (c *Cache) entries() ([]fs.FileInfo, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]fs.FileInfo, 0, len(dirEntries))
+
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".cache") {
+			continue
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			continue // Removed concurrently.
+		}
+
+		entries = append(entries, info)
+	}
+
+	return entries, nil
+}
+
+// evict removes the least recently used entries, until the cache is within
+// its maximum size.
+func (c *Cache) evict() error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, e := range entries {
+		size += e.Size()
+	}
+
+	if size <= c.maxSize {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	for _, e := range entries {
+		if size <= c.maxSize {
+			break
+		}
+
+		if err = os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		size -= e.Size()
+	}
+
+	return nil
+}
+
+// cacheCommand is the "cache" command, registered with WithCache.
+type cacheCommand struct {
+	Clear cacheClearCommand `command:"clear" description:"remove all cached data"`
+}
+
+// cacheClearCommand is the "cache clear" command.
+type cacheClearCommand struct {
+	clear func() error
+}
+
+// Execute implements flags.Commander.
+func (c *cacheClearCommand) Execute(_ []string) error {
+	return c.clear()
+}
+
+// clearCache clears the application's cache, printing a summary to stdout.
+func (cli *CLI[T]) clearCache() error {
+	dir, err := cli.CacheDir()
+	if err != nil {
+		return err
+	}
+
+	removed, freed, err := NewCache(dir, 0).Clear()
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "removed %d cache entries (%s)\n", removed, formatBytes(uint64(freed)))
+	return nil
+}
@@ -0,0 +1,277 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultOutputCaptureSize is the default amount of output kept per stream by
+// WithOutputCapture, in bytes.
+const DefaultOutputCaptureSize = 64 << 10 // 64KiB.
+
+const (
+	// captureIdle is how long a stream must be idle before a snapshot is taken,
+	// so output which was just written (but not yet read from the pipe) is
+	// included.
+	captureIdle = 10 * time.Millisecond
+
+	// captureTimeout is the maximum time a snapshot waits for streams to be
+	// idle.
+	captureTimeout = 250 * time.Millisecond
+)
+
+// CapturedOutput is the tail of the process output, captured when
+// WithOutputCapture is used. Values of secret flags are redacted (see
+// RedactText).
+type CapturedOutput struct {
+	Stdout          string `json:"stdout,omitempty"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	Stderr          string `json:"stderr,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+}
+
+// WithOutputCapture tees stdout and stderr into bounded buffers, keeping the
+// last size bytes of each (0 uses DefaultOutputCaptureSize), which are
+// included in the result envelope (see --result-json) and exit events (see
+// WithExitHook, e.g. for crash reports), and are available through
+// CLI.CapturedOutput. Values of secret flags are redacted.
+//
+// Output is still streamed as-is, as os.Stdout and os.Stderr are replaced with
+// pipes when Parse() is invoked, which are copied to the original streams.
+// clix's terminal and color detection (see Console and ColorEnabled) continue
+// to use the original streams, however other terminal checks (e.g.
+// term.IsTerminal(int(os.Stdout.Fd()))) will report pipes, so the original
+// streams should be used for those (see CLI.OriginalStreams). Output is
+// flushed to the original streams by CLI.Finish (invoked automatically after
+// sub-commands), and before clix exits the process. Applications which write
+// output after Finish should call CLI.RunExitHooks before exiting.
+func WithOutputCapture(size int) Option {
+	return func(s *settings) error {
+		if size < 0 {
+			return errors.New("WithOutputCapture: size must not be negative")
+		}
+
+		if size == 0 {
+			size = DefaultOutputCaptureSize
+		}
+
+		s.outputCapture = size
+		return nil
+	}
+}
+
+// ringBuffer keeps the last size bytes written to it.
+type ringBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	size      int
+	truncated bool
+	lastWrite time.Time
+}
+
+// Write implements io.Writer.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWrite = time.Now()
+	r.buf = append(r.buf, p...)
+
+	if n := len(r.buf); n > r.size {
+		r.buf = r.buf[:copy(r.buf, r.buf[n-r.size:])]
+		r.truncated = true
+	}
+
+	return len(p), nil
+}
+
+// snapshot returns the buffered data, and whether older data was discarded.
+func (r *ringBuffer) snapshot() (data string, truncated bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return string(r.buf), r.truncated
+}
+
+// idleSince returns true if nothing was written since the provided time.
+func (r *ringBuffer) idleSince(t time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastWrite.Before(t)
+}
+
+// capturedStream is a stream (stdout or stderr) replaced with a pipe, which is
+// copied to the original stream and a ringBuffer.
+type capturedStream struct {
+	orig *os.File
+	pipe *os.File
+	ring *ringBuffer
+}
+
+// captureStream replaces the provided stream with a pipe, which is copied to
+// the original stream and a ringBuffer of the provided size.
+func captureStream(stream **os.File, size int) (*capturedStream, error) {
+	orig := *stream
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Terminal and color detection use the original stream.
+	info := Console(orig)
+
+	consoleMu.Lock()
+	consoleCache[pw.Fd()] = info
+	consoleMu.Unlock()
+
+	colorMu.Lock()
+	if mode, ok := colorStreams[orig.Fd()]; ok {
+		colorStreams[pw.Fd()] = mode
+	}
+	colorMu.Unlock()
+
+	s := &capturedStream{orig: orig, pipe: pw, ring: &ringBuffer{size: size}}
+
+	go func() {
+		buf := make([]byte, 32*1024)
+
+		for {
+			n, rerr := pr.Read(buf)
+			if n > 0 {
+				// Output is kept, even if the original stream is closed.
+				_, _ = orig.Write(buf[:n])
+				_, _ = s.ring.Write(buf[:n])
+			}
+
+			if rerr != nil {
+				return
+			}
+		}
+	}()
+
+	*stream = pw
+	return s, nil
+}
+
+// outputCapture is the captured stdout and stderr, see WithOutputCapture.
+type outputCapture struct {
+	stdout *capturedStream
+	stderr *capturedStream
+}
+
+// wait waits (at most captureTimeout) until both streams are idle, so output
+// which was just written has been copied to the original streams and buffers.
+func (c *outputCapture) wait() {
+	start := time.Now()
+
+	for time.Since(start) < captureTimeout {
+		time.Sleep(captureIdle)
+
+		since := time.Now().Add(-captureIdle)
+		if c.stdout.ring.idleSince(since) && c.stderr.ring.idleSince(since) {
+			return
+		}
+	}
+}
+
+// stop waits until captured output has been copied, and restores the original
+// streams (unless they were replaced since), closing the pipes.
+func (c *outputCapture) stop() {
+	c.wait()
+
+	if os.Stdout == c.stdout.pipe {
+		os.Stdout = c.stdout.orig
+	}
+
+	if os.Stderr == c.stderr.pipe {
+		os.Stderr = c.stderr.orig
+	}
+
+	_ = c.stdout.pipe.Close()
+	_ = c.stderr.pipe.Close()
+}
+
+// flushOutputCapture waits until captured output has been copied to the
+// original streams (see WithOutputCapture), before the process exits.
+func (cli *CLI[T]) flushOutputCapture() {
+	cli.mu.Lock()
+	c := cli.capture
+	cli.mu.Unlock()
+
+	if c != nil {
+		c.wait()
+	}
+}
+
+// startOutputCapture replaces os.Stdout and os.Stderr with captured streams,
+// if enabled and not already started.
+func (cli *CLI[T]) startOutputCapture() error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	size := cli.settings.outputCapture
+	if size == 0 || cli.capture != nil {
+		return nil
+	}
+
+	stdout, err := captureStream(&os.Stdout, size)
+	if err != nil {
+		return err
+	}
+
+	stderr, err := captureStream(&os.Stderr, size)
+	if err != nil {
+		return err
+	}
+
+	cli.capture = &outputCapture{stdout: stdout, stderr: stderr}
+	return nil
+}
+
+// OriginalStreams returns the original stdout and stderr, when output is
+// captured (see WithOutputCapture), otherwise os.Stdout and os.Stderr.
+func (cli *CLI[T]) OriginalStreams() (stdout, stderr *os.File) {
+	cli.mu.Lock()
+	c := cli.capture
+	cli.mu.Unlock()
+
+	if c == nil {
+		return os.Stdout, os.Stderr
+	}
+
+	return c.stdout.orig, c.stderr.orig
+}
+
+// CapturedOutput returns the tail of the output written so far (see
+// WithOutputCapture), with values of secret flags redacted, or nil if output
+// isn't captured. Output which was just written may still be in flight, so
+// this waits (briefly) until both streams are idle.
+func (cli *CLI[T]) CapturedOutput() *CapturedOutput {
+	cli.mu.Lock()
+	c := cli.capture
+	cli.mu.Unlock()
+
+	if c == nil {
+		return nil
+	}
+
+	c.wait()
+
+	out := &CapturedOutput{}
+
+	out.Stdout, out.StdoutTruncated = c.stdout.ring.snapshot()
+	out.Stderr, out.StderrTruncated = c.stderr.ring.snapshot()
+
+	out.Stdout = cli.RedactText(out.Stdout)
+	out.Stderr = cli.RedactText(out.Stderr)
+
+	return out
+}
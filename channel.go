@@ -0,0 +1,219 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UpdateChannel is a release channel, which determines which versions are
+// considered when checking for updates. See WithUpdateChannels.
+type UpdateChannel string
+
+const (
+	// ChannelStable only includes releases without a pre-release (e.g.
+	// "v1.2.3").
+	ChannelStable UpdateChannel = "stable"
+
+	// ChannelBeta includes stable releases, and alpha, beta and release
+	// candidate pre-releases (e.g. "v1.2.3-beta.1" or "v1.2.3-rc.2").
+	ChannelBeta UpdateChannel = "beta"
+
+	// ChannelNightly includes all versions, including nightly and development
+	// builds (e.g. "v1.2.4-nightly.20240101").
+	ChannelNightly UpdateChannel = "nightly"
+)
+
+// updateChannelKey is the state key the channel preference is persisted with.
+const updateChannelKey = "update-channel"
+
+// Includes returns true if the provided semantic version belongs to the
+// channel. Channels other than the built-in ones include stable releases, and
+// pre-releases starting with the channel name (e.g. channel "canary" includes
+// "v1.2.3-canary.4"). Versions which aren't semantic versions are never
+// included.
+func (c UpdateChannel) Includes(version string) bool {
+	v, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+
+	if v.pre == "" || c == ChannelNightly {
+		return true
+	}
+
+	pre := strings.ToLower(v.pre)
+
+	switch c {
+	case ChannelStable:
+		return false
+	case ChannelBeta:
+		return strings.HasPrefix(pre, "alpha") || strings.HasPrefix(pre, "beta") || strings.HasPrefix(pre, "rc")
+	default:
+		return strings.HasPrefix(pre, strings.ToLower(string(c)))
+	}
+}
+
+// Latest returns the latest of the provided versions which belong to the
+// channel (see Includes), using semantic version ordering (where pre-releases
+// precede their release), and false if none do. This is useful for selecting
+// the version to update to, from the versions listed by a release endpoint.
+func (c UpdateChannel) Latest(versions []string) (latest string, ok bool) {
+	for _, v := range versions {
+		if !c.Includes(v) {
+			continue
+		}
+
+		if cmp, _ := compareVersions(v, latest); !ok || cmp > 0 {
+			latest, ok = v, true
+		}
+	}
+
+	return latest, ok
+}
+
+// Newer returns the latest version of the channel (see Latest), and true, if
+// it's newer than the current version.
+func (c UpdateChannel) Newer(current string, versions []string) (latest string, ok bool) {
+	latest, ok = c.Latest(versions)
+	if !ok {
+		return "", false
+	}
+
+	cmp, valid := compareVersions(latest, current)
+	if !valid || cmp <= 0 {
+		return "", false
+	}
+
+	return latest, true
+}
+
+// updateChannelConfig is the configuration provided to WithUpdateChannels.
+type updateChannelConfig struct {
+	defaultChannel UpdateChannel
+	endpoints      map[UpdateChannel]string
+}
+
+// WithUpdateChannels enables the --update-channel flag, which selects the
+// release channel used by the application's update checker (see
+// CLI.UpdateChannel). The provided channel is persisted in the state store
+// (see CLI.State), so it's used by subsequent invocations until changed.
+// endpoints maps each supported channel to its release endpoint (see
+// CLI.UpdateEndpoint), and defaultChannel (which must be one of them) is used
+// when no preference has been provided.
+//
+// Example:
+//
+//	cli.Apply(clix.WithUpdateChannels(clix.ChannelStable, map[clix.UpdateChannel]string{
+//		clix.ChannelStable:  "https://example.com/releases/stable.json",
+//		clix.ChannelBeta:    "https://example.com/releases/beta.json",
+//		clix.ChannelNightly: "https://example.com/releases/nightly.json",
+//	}))
+func WithUpdateChannels(defaultChannel UpdateChannel, endpoints map[UpdateChannel]string) Option {
+	return func(s *settings) error {
+		if len(endpoints) == 0 {
+			return errors.New("WithUpdateChannels: no channels provided")
+		}
+
+		for c := range endpoints {
+			if c == "" || strings.ContainsAny(string(c), " \t\n.") {
+				return fmt.Errorf("WithUpdateChannels: invalid channel name %q", c)
+			}
+		}
+
+		if _, ok := endpoints[defaultChannel]; !ok {
+			return fmt.Errorf("WithUpdateChannels: default channel %q has no endpoint", defaultChannel)
+		}
+
+		s.updateChannels = &updateChannelConfig{
+			defaultChannel: defaultChannel,
+			endpoints:      endpoints,
+		}
+		return nil
+	}
+}
+
+// channelNames returns the sorted names of the configured channels.
+func (c *updateChannelConfig) channelNames() []string {
+	names := make([]string, 0, len(c.endpoints))
+	for ch := range c.endpoints {
+		names = append(names, string(ch))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// persistUpdateChannel validates --update-channel, and persists it as the
+// channel preference. Errors wrap ErrUpdateCheck.
+func (cli *CLI[T]) persistUpdateChannel() error {
+	cfg := cli.settings.updateChannels
+	if cfg == nil || cli.Channel == "" {
+		return nil
+	}
+
+	channel := UpdateChannel(strings.ToLower(cli.Channel))
+	if _, ok := cfg.endpoints[channel]; !ok {
+		return fmt.Errorf(
+			"%w: invalid --update-channel %q: must be one of: %s",
+			ErrUpdateCheck, cli.Channel, strings.Join(cfg.channelNames(), ", "),
+		)
+	}
+
+	state, err := cli.State()
+	if err != nil {
+		return fmt.Errorf("%w: failed to persist update channel: %w", ErrUpdateCheck, err)
+	}
+
+	if err = state.Set(updateChannelKey, channel); err != nil {
+		return fmt.Errorf("%w: failed to persist update channel: %w", ErrUpdateCheck, err)
+	}
+
+	return nil
+}
+
+// UpdateChannel returns the release channel to use when checking for updates:
+// the channel provided with --update-channel, otherwise the channel persisted
+// by a previous invocation, otherwise the default channel (see
+// WithUpdateChannels). Persisted channels which are no longer supported are
+// ignored. Returns ChannelStable if update channels aren't enabled. Must be
+// called after Parse().
+func (cli *CLI[T]) UpdateChannel() UpdateChannel {
+	cfg := cli.settings.updateChannels
+	if cfg == nil {
+		return ChannelStable
+	}
+
+	if channel := UpdateChannel(strings.ToLower(cli.Channel)); channel != "" {
+		if _, ok := cfg.endpoints[channel]; ok {
+			return channel
+		}
+	}
+
+	if state, err := cli.State(); err == nil {
+		var channel UpdateChannel
+		if ok, _ := state.Get(updateChannelKey, &channel); ok {
+			if _, ok = cfg.endpoints[channel]; ok {
+				return channel
+			}
+		}
+	}
+
+	return cfg.defaultChannel
+}
+
+// UpdateEndpoint returns the release endpoint of the active update channel
+// (see UpdateChannel), or an empty string if update channels aren't enabled.
+// Must be called after Parse().
+func (cli *CLI[T]) UpdateEndpoint() string {
+	cfg := cli.settings.updateChannels
+	if cfg == nil {
+		return ""
+	}
+
+	return cfg.endpoints[cli.UpdateChannel()]
+}
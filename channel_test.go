@@ -0,0 +1,38 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+func TestUpdateChannelInvalid(t *testing.T) {
+	cli := &clix.CLI[struct{}]{}
+	err := cli.Apply(clix.WithUpdateChannels(clix.ChannelStable, map[clix.UpdateChannel]string{
+		clix.ChannelStable: "https://example.com/stable.json",
+		clix.ChannelBeta:   "https://example.com/beta.json",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := clixtest.Run(t, cli, []string{"--update-channel", "nightly"}, nil)
+	if !errors.Is(res.Err, clix.ErrUpdateCheck) {
+		t.Fatalf("expected ErrUpdateCheck, got %v", res.Err)
+	}
+
+	res = clixtest.Run(t, cli, []string{"--update-channel", "beta"}, nil)
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+
+	if got := cli.UpdateChannel(); got != clix.ChannelBeta {
+		t.Fatalf("unexpected channel %q, want %q", got, clix.ChannelBeta)
+	}
+}
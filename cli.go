@@ -5,27 +5,59 @@
 package clix
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apex/log"
-	"github.com/gookit/color"
 	flags "github.com/jessevdk/go-flags"
-	_ "github.com/joho/godotenv/autoload"
 )
 
 // Options allows overriding default logic.
 type Options int
 
 const (
-	OptDisableLogging       Options = 1 << iota // Disable logging initialization.
-	OptDisableVersion                           // Disable version printing (must handle manually).
-	OptDisableDeps                              // Disable dependency printing in version output.
-	OptDisableBuildSettings                     // Disable build info printing in version output.
-	OptDisableGlobalLogger                      // Disable setting the global logger for apex/log.
-	OptSubcommandsOptional                      // Subcommands are optional.
+	OptDisableLogging        Options = 1 << iota // Disable logging initialization.
+	OptDisableVersion                            // Disable version printing (must handle manually).
+	OptDisableDeps                               // Disable dependency printing in version output.
+	OptDisableBuildSettings                      // Disable build info printing in version output.
+	OptDisableGlobalLogger                       // Disable setting the global logger for apex/log.
+	OptSubcommandsOptional                       // Subcommands are optional.
+	OptNoExit                                    // Return sentinel errors instead of calling os.Exit (see ParseWithInit).
+	OptEnableHistory                             // Record each invocation in the local command history (see HistoryPath).
+	OptDisableAutoLinks                          // Disable deriving links from the module path, when none are provided.
+	OptDisableDotEnv                             // Disable loading environment variables from a .env file.
+	OptDisableEnv                                // Disable resolving flag values from environment variables.
+	OptDisableHelpFlag                           // Disable the built-in -h/--help flag (go-flags HelpFlag).
+	OptDisableMarkdown                           // Disable the built-in --generate-markdown flag.
+	OptEnableVersionTracking                     // Record the last-run version in the state directory (see UpgradedFrom).
+	OptEnableNetwork                             // Show the built-in network flags (see NetworkConfig).
+	OptEnableInteractive                         // Show the built-in --interactive flag, which prompts for a sub-command and flag values.
+	OptEnableUsageReport                         // Record parse failures locally, and add a usage-report command (see UsageReport).
+	OptEnableWaitFor                             // Show the built-in --wait-for flags, which wait for dependencies before running (see CLI.WaitFor).
+	OptEnableFlagNegation                        // Accept --no-<flag> (and --<flag>=true|false) for boolean flags.
+	OptDisableInterspersed                       // Stop parsing flags at the first positional argument (POSIX style), passing the rest as arguments.
+	OptRequireEquals                             // Require values of long flags to be provided as --flag=value, rather than --flag value.
+	OptDisableCombinedShort                      // Reject combined short flags (e.g. -abc), and short flags with attached values (e.g. -ovalue).
+)
+
+// ErrAlreadyParsed is returned when a CLI is parsed more than once, without
+// calling Reset() in between.
+var ErrAlreadyParsed = errors.New("clix: cli has already been parsed (use Reset() to parse again)")
+
+// Sentinel errors returned by ParseWithInit when OptNoExit is set, in place of
+// exiting the process.
+var (
+	ErrHelp     = errors.New("clix: help requested")
+	ErrVersion  = errors.New("clix: version information requested")
+	ErrMarkdown = errors.New("clix: markdown documentation requested")
+	ErrGenerate = errors.New("clix: generated output requested")
+	ErrBuiltin  = errors.New("clix: built-in command executed")
 )
 
 // CLI is the main construct for clix. Do not manually set any fields until
@@ -47,6 +79,7 @@ const (
 // Additional notes:
 // * Use cli.Logger as a apex/log log.Interface (as shown above).
 // * Use cli.Args to get the remaining arguments provided to the program.
+// * A CLI can only be parsed once, unless Reset() is called (e.g. in tests).
 type CLI[T any] struct {
 	// Flags are the user-provided flags.
 	Flags *T
@@ -61,39 +94,241 @@ type CLI[T any] struct {
 	// a custom version of this if you already have version information.
 	VersionInfo *VersionInfo[T] `json:"version_info"`
 
+	// VersionOptions allows configuring how version information is collected
+	// and rendered. Must be set before calling Parse().
+	VersionOptions VersionOptions `json:"-"`
+
+	// PackageOptions allows configuring generated package manifests (see
+	// --generate-package).
+	PackageOptions PackageOptions `json:"-"`
+
+	// DeployOptions allows configuring generated deployment artifacts (see
+	// --generate-deploy).
+	DeployOptions DeployOptions `json:"-"`
+
+	// Description is the long description of the application, in markdown
+	// (e.g. embedded with go:embed). It's rendered for the terminal in help
+	// output, and used verbatim in generated documentation (e.g. markdown).
+	// If empty, help output includes version information instead.
+	Description string `json:"-"`
+
+	// DocsMeta is additional documentation metadata (exit codes, environment
+	// variables, files, and references), rendered by all documentation formats
+	// (e.g. markdown and man pages).
+	DocsMeta DocsMeta `json:"-"`
+
 	// Links are the links to the project's website, support, issues, security,
 	// etc. This will be used in help and version output if provided.
-	// Links are in the format of "name=url".
+	// Links are in the format of "name=url". If not provided, and the module
+	// is hosted on GitHub, links are derived from the module path (see
+	// OptDisableAutoLinks).
 	Links []Link
 
-	// Args are the remaining arguments after parsing.
+	// Args are the remaining (positional) arguments after parsing, including
+	// everything after "--". See also Arg(), NArg(), and BindArgs().
 	Args []string
 
 	// Version can be used to print the version information to console. Use
-	// NO_COLOR or FORCE_COLOR to change coloring.
+	// NO_COLOR or FORCE_COLOR to change coloring. --version also uses -v,
+	// unless it's used by the application's flags (see WithFlag).
 	Version struct {
-		Enabled     bool `short:"v" long:"version" description:"prints version information and exits"`
+		Enabled     bool `long:"version" description:"prints version information and exits"`
 		EnabledJSON bool `long:"version-json" description:"prints version information in JSON format and exits"`
+		EnabledOCI  bool `long:"version-oci-labels" hidden:"true" description:"prints version information as OCI image labels and exits"`
+
+		// VerifyProvenance verifies the binary against a SLSA provenance
+		// document. See VersionInfo.VerifyProvenance.
+		VerifyProvenance string `long:"verify-provenance" value-name:"FILE" description:"verifies the binary against a SLSA/in-toto provenance document and exits"`
 	}
 
-	// Debug can be used to enable/disable debugging as a global flag. Also
-	// sets the log level to debug.
-	Debug bool `short:"D" long:"debug" env:"DEBUG" description:"enables debug mode"`
+	// Debug is true when debugging is enabled for all categories (i.e. --debug
+	// without categories, which also sets the log level to debug). It's set
+	// after parsing, from DebugCategories.
+	Debug bool `no-flag:"true"`
+
+	// DebugCategories can be used to enable debugging as a global flag, either
+	// for all categories (--debug), or for specific categories (e.g.
+	// --debug=http,sql). Use DebugEnabled() to query debug state, and
+	// ComponentLogger() for per-category loggers, rather than using this
+	// directly. --debug also uses -D, unless it's used by the application's
+	// flags (see WithFlag).
+	DebugCategories []string `long:"debug" env:"DEBUG" env-delim:"," optional:"true" optional-value:"all" value-name:"CATEGORY,..." description:"enables debug mode, for all or the provided categories"`
 
 	// GenerateMarkdown can be used to generate markdown documentation for
 	// the cli. clix will intercept and output the documentation to stdout.
 	GenerateMarkdown bool `long:"generate-markdown" hidden:"true" description:"generate markdown documentation and write to stdout" json:"-"`
 
+	// GenerateEnvReference can be used to generate a deployment-oriented
+	// configuration reference of all environment variables (see
+	// CLI.EnvReference). clix will intercept and output the reference to
+	// stdout.
+	GenerateEnvReference bool `long:"generate-env-reference" hidden:"true" description:"generate a configuration reference of environment variables (with kubernetes sourcing hints) and write to stdout" json:"-"`
+
+	// GenerateMan can be used to generate a man page for the cli (see
+	// CLI.ManPage). clix will intercept and output the man page to stdout.
+	GenerateMan bool `long:"generate-man" hidden:"true" description:"generate a man page and write to stdout" json:"-"`
+
+	// GenerateShell can be used to generate shell integration snippets for the
+	// cli (see the Shell* constants). clix will intercept and output the
+	// snippets to stdout.
+	GenerateShell string `long:"generate-shell" hidden:"true" choice:"aliases" choice:"fish-aliases" choice:"env" choice:"fish-env" choice:"direnv" choice:"bash-completion" choice:"zsh-completion" choice:"fish-completion" description:"generate shell integration snippets and write to stdout" json:"-"`
+
+	// GeneratePackage can be used to generate a Homebrew formula or Scoop
+	// manifest skeleton for the cli (see CLI.HomebrewFormula,
+	// CLI.ScoopManifest, and PackageOptions). clix will intercept and output
+	// the package manifest to stdout.
+	GeneratePackage string `long:"generate-package" hidden:"true" choice:"homebrew" choice:"scoop" description:"generate a homebrew formula or scoop manifest and write to stdout" json:"-"`
+
+	// GenerateDeploy can be used to generate a hardened systemd unit, or a
+	// Dockerfile snippet for the cli (see CLI.SystemdUnit, CLI.Dockerfile, and
+	// DeployOptions). clix will intercept and output the result to stdout.
+	GenerateDeploy string `long:"generate-deploy" hidden:"true" choice:"systemd" choice:"dockerfile" description:"generate a systemd unit or dockerfile snippet and write to stdout" json:"-"`
+
+	// FeatureOverrides are the feature flag overrides provided via --feature
+	// or FEATURES. Use Feature() to query feature state, rather than using this
+	// directly. The flag is hidden unless features have been declared.
+	FeatureOverrides []string `long:"feature" env:"FEATURES" env-delim:"," value-name:"NAME[=on|off]" description:"enable or disable a feature flag (repeatable)" json:"-"`
+
+	// WarningsJSON is an optional path to write warnings collected with Warn
+	// to (in JSON format), when they're flushed. See FlushWarnings.
+	WarningsJSON string `long:"warnings-json" env:"WARNINGS_JSON" value-name:"PATH" description:"write collected warnings as JSON to the provided path on exit" json:"-"`
+
+	// ResultJSON is an optional target to write the result envelope to (in
+	// JSON format), at the end of the run. See Finish and SetResult.
+	ResultJSON string `long:"result-json" env:"RESULT_JSON" optional:"true" optional-value:"-" value-name:"-|stderr|fd:N|PATH" description:"write a JSON result envelope (exit code, duration, version, etc) to stdout, or the provided target, on exit" json:"-"`
+
+	// Stats can be used to print execution timing and resource usage
+	// statistics to stderr, at the end of the run. See Finish.
+	Stats string `long:"stats" env:"STATS" optional:"true" optional-value:"text" choice:"text" choice:"json" description:"print execution timing and resource usage statistics to stderr on exit" json:"-"`
+
+	// GenerateKubernetes can be used to generate Kubernetes ConfigMap and
+	// Secret manifests from the resolved configuration (see
+	// CLI.KubernetesManifests). clix will intercept and output the manifests to
+	// stdout.
+	GenerateKubernetes bool `long:"generate-kubernetes" hidden:"true" description:"generate kubernetes configmap/secret manifests from the resolved configuration and write to stdout" json:"-"`
+
+	// ExportEnvFormat can be used to print the resolved configuration as environment
+	// variable assignments (see CLI.ExportEnv). clix will intercept and output
+	// the assignments to stdout. Secrets are masked unless Reveal is set.
+	ExportEnvFormat string `long:"export-env" choice:"dotenv" choice:"shell" description:"print the resolved configuration as environment variables and exit" json:"-"`
+	Reveal          bool   `long:"reveal" description:"reveal secret values when using --export-env or config get" json:"-"`
+
+	// Accessible enables accessibility mode, which disables color and uses
+	// plain ASCII in clix output. See Accessible().
+	Accessible bool `long:"accessible" env:"ACCESSIBLE" description:"accessible output for screen readers (no color or animation, plain ASCII)" json:"-"`
+
+	// NoColor, NoPager, NoInput and Yes can be used to disable color, disable
+	// paging (see CLI.Pager), fail instead of prompting, and confirm
+	// destructive actions without prompting (see CLI.Confirm). The flags are
+	// hidden (and ignored) unless WithGuidelines is used.
+	NoColor bool `long:"no-color" description:"disable color output (also see NO_COLOR)" json:"-"`
+	NoPager bool `long:"no-pager" description:"don't page output" json:"-"`
+	NoInput bool `long:"no-input" description:"never prompt for input, failing instead" json:"-"`
+	Yes     bool `long:"yes" description:"confirm destructive actions without prompting" json:"-"`
+
+	// GuidelinesReport can be used to print a checklist of the Command Line
+	// Interface Guidelines the application follows, and those it violates.
+	// Ignored unless WithGuidelines is used. See CLI.CheckGuidelines.
+	GuidelinesReport bool `long:"guidelines-report" hidden:"true" description:"print a checklist of the command line interface guidelines the application follows and exit" json:"-"`
+
+	// HelpSearch can be used to fuzzy-search the names and descriptions of
+	// all flags and sub-commands, printing matches with their full paths. See
+	// CLI.SearchHelp.
+	HelpSearch string `long:"help-search" value-name:"QUERY" description:"search all flags and sub-commands (names and help text) and exit" json:"-"`
+
+	// HelpJSON can be used to print the documentation model (see
+	// CLI.DocModel) as JSON, so wrappers and other tools can introspect the
+	// CLI without parsing help output.
+	HelpJSON bool `long:"help-json" description:"print all flags and sub-commands (with groups, defaults, etc) as JSON and exit" json:"-"`
+
+	// Interactive can be used to interactively select a sub-command, and
+	// provide its flag values and arguments, which is then executed as if it
+	// was provided directly. The flag is hidden (and ignored) unless
+	// OptEnableInteractive is set.
+	Interactive bool `long:"interactive" description:"interactively select a sub-command and provide flag values, then run it" json:"-"`
+
+	// WaitForTargets are dependencies to wait for before running (e.g.
+	// "tcp://db:5432" or "http://auth:8080/healthz"), retried with backoff
+	// until WaitForTimeout. The flags are hidden (and ignored) unless
+	// OptEnableWaitFor is set. See ParseWaitTarget and CLI.WaitFor.
+	WaitForTargets []string      `long:"wait-for" env:"WAIT_FOR" env-delim:"," value-name:"TARGET" description:"wait for a dependency (tcp://host:port, http(s)://url, unix:///path or file:///path) before running (can be repeated)" json:"-"`
+	WaitForTimeout time.Duration `long:"wait-for-timeout" env:"WAIT_FOR_TIMEOUT" default:"1m" description:"maximum time to wait for dependencies provided with --wait-for (0 waits forever)" json:"-"`
+
+	// Record can be used to record the invocation (arguments, environment,
+	// stdin and timing) to a file, which can be replayed with Replay (e.g. to
+	// reproduce user-reported bugs). See Recording.
+	Record string `long:"record" hidden:"true" value-name:"FILE" description:"record this invocation (args, environment, stdin) to a replayable file" json:"-"`
+
+	// Replay can be used to replay an invocation recorded with Record.
+	Replay string `long:"replay" hidden:"true" value-name:"FILE" description:"replay an invocation recorded with --record" json:"-"`
+
+	// FrozenTime can be used to fix the time returned by the CLI's clock, and
+	// seed its random number generator, so output is deterministic (e.g. in
+	// golden tests and demos). See CLI.Clock and CLI.Rand.
+	FrozenTime string `long:"frozen-time" env:"FROZEN_TIME" hidden:"true" value-name:"RFC3339|UNIX" description:"freeze the clock at the provided time, and seed randomness from it" json:"-"`
+
+	// DebugCLI can be used to log how each flag was resolved (from a flag, an
+	// environment variable, a .env file, or its default), how each feature
+	// flag was resolved, which .env files were read, and which environment
+	// variables were used, once parsed. See CLI.TraceResolution.
+	DebugCLI bool `long:"debug-cli" hidden:"true" description:"log how each flag and feature was resolved, and which .env files and environment variables were used" json:"-"`
+
+	// NoCache disables the cache (see CLI.Cache). The flag is hidden unless
+	// the cache is enabled with WithCache.
+	NoCache bool `long:"no-cache" env:"NO_CACHE" description:"disable reading and writing cached data" json:"-"`
+
+	// Channel is the release channel to track for updates, which is
+	// persisted for subsequent invocations. The flag is hidden unless update
+	// channels are enabled with WithUpdateChannels. See CLI.UpdateChannel.
+	Channel string `long:"update-channel" env:"UPDATE_CHANNEL" value-name:"CHANNEL" description:"release channel to track for updates (e.g. stable, beta, nightly), remembered for future runs" json:"-"`
+
+	// Network are the proxy, CA bundle and DNS override flags, shared by all
+	// clix-provided clients. The flags are hidden unless OptEnableNetwork is set. See
+	// NetworkConfig and DefaultNetwork.
+	Network NetworkConfig `group:"Network Options" json:"-"`
+
 	// Logger is the generated logger.
 	Logger       *log.Logger  `json:"-"`
 	LoggerConfig LoggerConfig `group:"Logging Options" namespace:"log" env-namespace:"LOG"`
 
-	options Options `json:"-"`
+	mu       sync.Mutex
+	parsed   bool
+	exitErr  error
+	options  Options    `json:"-"`
+	mounts   []*mount   `json:"-"`
+	commands []*command `json:"-"`
+
+	features        map[string]*feature
+	featureProvider FeatureProvider
+	warnings        []Warning
+	result          any
+	started         time.Time
+	settings        settings
+	exitHooksOnce   sync.Once
+	events          *EventBus
+	completers      map[string]CompleteFunc
+	upgradedFrom    string
+	commandPath     string
+	middleware      []Middleware
+	state           *State
+	processes       []ProcessResult
+	recorder        *recorder
+	authorizers     []AuthorizeFunc
+	hints           []errorHint
+	capture         *outputCapture
+	invocationID    string
+	clock           Clock
+	rand            *rand.Rand
+	accessible      bool
 }
 
 // Parse executes the go-flags parser, returns the remaining arguments, as
 // well as initializes a new logger. If cli.Version is set, it will print
 // the version information (unless disabled).
+//
+// All errors (including ErrAlreadyParsed) are printed to stderr, and exit the
+// process. When using OptNoExit, use ParseWithInit instead, to receive the
+// returned error.
 func (cli *CLI[T]) Parse(options ...Options) {
 	_ = cli.ParseWithInit(nil, options...)
 }
@@ -104,40 +339,284 @@ func (cli *CLI[T]) Parse(options ...Options) {
 //
 // Prefer using Parse() unless you're using sub-commands and want to run some
 // initialization logic before the sub-command if invoked.
+//
+// Informational output (help, version, markdown, etc) exits with code 0, and
+// errors (usage errors, ErrAlreadyParsed, invalid commands, etc) are printed to
+// stderr and exit with code 1, or the code provided by the error (see
+// ExitCode), e.g. 77 for an *AuthorizationError. If OptNoExit is set, the process is never
+// exited. Instead, after the relevant output is written, ErrHelp, ErrVersion,
+// ErrMarkdown, or ErrGenerate is returned (for help, version, markdown, and
+// other generated output respectively), ErrBuiltin is returned after a
+// built-in command (e.g. "cache clear") succeeds, and errors are returned
+// as-is.
 func (cli *CLI[T]) ParseWithInit(initFn func() error, options ...Options) error {
+	cli.mu.Lock()
+	if cli.parsed {
+		cli.mu.Unlock()
+		return cli.fail(ErrAlreadyParsed)
+	}
+	cli.parsed = true
+	cli.started = time.Now()
+	cli.invocationID = newInvocationID()
+	cli.mu.Unlock()
+
 	if cli.Flags == nil {
 		cli.Flags = new(T)
 	}
 
 	cli.Set(options...)
+
+	if err := cli.validateSettings(); err != nil {
+		return cli.fail(err)
+	}
+
+	if err := cli.startOutputCapture(); err != nil {
+		return cli.fail(fmt.Errorf("failed to capture output: %w", err))
+	}
+
+	if !cli.IsSet(OptDisableDotEnv) {
+		if err := loadDotEnv(limit(cli.settings.maxConfigFileSize, DefaultMaxConfigFileSize), ".env"); err != nil {
+			return cli.fail(err)
+		}
+
+		if err := cli.decryptDotEnv(); err != nil {
+			return cli.fail(err)
+		}
+	}
+
+	cli.emit(EventConfigLoaded, 0, nil)
+
+	if len(cli.VersionOptions.BuildInfoJSON) > 0 {
+		if _, err := parseEmbeddedBuildInfo[T](cli.VersionOptions.BuildInfoJSON); err != nil {
+			return cli.fail(err)
+		}
+	}
+
 	cli.VersionInfo = cli.GetVersionInfo()
 
-	cli.Parser = cli.newParser()
+	if accessibleArg(os.Args[1:]) {
+		cli.enableAccessible()
+	}
+
+	if cli.settings.guidelines && hasFlagArg(os.Args[1:], "--no-color") {
+		cli.NoColor = true
+		cli.SetColorMode(ColorNever)
+	}
+
+	var err error
+	cli.Parser, err = cli.newParser()
+	if err != nil {
+		return cli.fail(err)
+	}
+
+	// Shell completion requests (see ShellBashCompletion).
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		for _, c := range cli.Completions(os.Args[2:]) {
+			if c.Description != "" {
+				fmt.Printf("%s\t%s\n", c.Item, c.Description)
+			} else {
+				fmt.Println(c.Item)
+			}
+		}
+		return cli.exit(0, ErrGenerate)
+	}
+
+	if err = cli.setupRecording(); err != nil {
+		return cli.fail(err)
+	}
+
+	// Searches are handled before parsing, so they work regardless of required
+	// flags or sub-commands.
+	if query, ok := helpSearchArg(os.Args[1:]); ok {
+		cli.HelpSearch = query
+		cli.SearchHelp(os.Stdout, query)
+		return cli.exit(0, ErrHelp)
+	}
+
+	if cli.settings.guidelines && hasFlagArg(os.Args[1:], "--guidelines-report") {
+		cli.GuidelinesReport = true
+		cli.printGuidelinesReport(os.Stdout)
+		return cli.exit(0, ErrGenerate)
+	}
+
+	if helpJSONArg(os.Args[1:]) {
+		cli.HelpJSON = true
+		if err = cli.DocModel().EncodeJSON(os.Stdout); err != nil {
+			return cli.fail(fmt.Errorf("failed to write help: %w", err))
+		}
+		return cli.exit(0, ErrHelp)
+	}
+
+	if cli.IsSet(OptEnableInteractive) && interactiveArg(os.Args[1:]) {
+		cli.Interactive = true
+
+		args, ierr := cli.interactive(os.Stdin, os.Stderr)
+		if ierr != nil {
+			return cli.fail(ierr)
+		}
+
+		// The composed arguments are parsed (and e.g. recorded in the command
+		// history) as if they were provided directly.
+		os.Args = append([]string{os.Args[0]}, args...)
+	}
+
 	cli.Parser.CommandHandler = func(command flags.Commander, args []string) error {
 		cli.Args = args
+		cli.DebugCategories = normalizeDebug(cli.DebugCategories)
+		cli.Debug = cli.DebugEnabled("")
+		setDefaultNetwork(&cli.Network)
+		setDefaultKeyring(cli.Keyring())
+
+		if err := cli.initClock(); err != nil {
+			return err
+		}
+
+		cli.mu.Lock()
+		cli.commandPath = activeCommandPath(cli.Parser)
+		cli.mu.Unlock()
+
+		cli.emit(EventParsed, 0, nil)
+
+		if cli.Accessible {
+			cli.enableAccessible()
+		}
 
 		// Initialize the logger.
-		if !cli.IsSet(OptDisableLogging) {
-			cli.newLogger()
+		if cli.settings.logger != nil {
+			cli.wrapExitHooks(cli.settings.logger)
+			cli.wrapCommandField(cli.settings.logger)
+
+			cli.mu.Lock()
+			cli.Logger = cli.settings.logger
+			cli.mu.Unlock()
+		} else if !cli.IsSet(OptDisableLogging) {
+			if err := cli.newLogger(); err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+		}
+
+		if cli.Logger != nil {
+			cli.emit(EventLoggerReady, 0, nil)
+		}
+
+		if cli.DebugCLI {
+			cli.TraceResolution()
+		}
+
+		if err := cli.checkStrict(); err != nil {
+			return err
+		}
+
+		if err := cli.checkDeprecation(cli.commandPath); err != nil {
+			return err
 		}
 
 		if (cli.Version.EnabledJSON) && !cli.IsSet(OptDisableVersion) {
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "    ")
-			if err := enc.Encode(cli.VersionInfo); err != nil {
-				panic(err)
+			if err := cli.VersionInfo.EncodeJSON(os.Stdout); err != nil {
+				return fmt.Errorf("failed to write version information: %w", err)
+			}
+			cli.exitErr = cli.exit(0, ErrVersion)
+			return nil
+		}
+
+		if (cli.Version.EnabledOCI) && !cli.IsSet(OptDisableVersion) {
+			writeOCILabels(os.Stdout, cli.VersionInfo.OCILabels(""))
+			cli.exitErr = cli.exit(0, ErrVersion)
+			return nil
+		}
+
+		if cli.Version.VerifyProvenance != "" && !cli.IsSet(OptDisableVersion) {
+			if err := cli.verifyProvenance(os.Stdout, cli.Version.VerifyProvenance); err != nil {
+				return err
 			}
-			os.Exit(1)
+			cli.exitErr = cli.exit(0, ErrVersion)
+			return nil
 		}
 
 		if (cli.Version.Enabled) && !cli.IsSet(OptDisableVersion) {
 			fmt.Println(cli.VersionInfo.String())
-			os.Exit(1)
+			cli.exitErr = cli.exit(0, ErrVersion)
+			return nil
 		}
 
-		if cli.GenerateMarkdown {
+		if cli.GenerateMarkdown && !cli.IsSet(OptDisableMarkdown) {
 			cli.Markdown(os.Stdout)
-			os.Exit(0)
+			cli.exitErr = cli.exit(0, ErrMarkdown)
+			return nil
+		}
+
+		if cli.GenerateEnvReference {
+			if err := cli.EnvReference(os.Stdout); err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.GenerateMan {
+			cli.ManPage(os.Stdout)
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.GenerateShell != "" {
+			if err := cli.ShellIntegration(os.Stdout, cli.GenerateShell); err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.GeneratePackage != "" {
+			var err error
+			if cli.GeneratePackage == "scoop" {
+				err = cli.ScoopManifest(os.Stdout)
+			} else {
+				err = cli.HomebrewFormula(os.Stdout)
+			}
+			if err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.GenerateDeploy != "" {
+			var err error
+			if cli.GenerateDeploy == "dockerfile" {
+				err = cli.Dockerfile(os.Stdout)
+			} else {
+				err = cli.SystemdUnit(os.Stdout)
+			}
+			if err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.GenerateKubernetes {
+			if err := cli.KubernetesManifests(os.Stdout, ""); err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if cli.ExportEnvFormat != "" {
+			if err := cli.ExportEnv(os.Stdout, cli.ExportEnvFormat, cli.Reveal); err != nil {
+				return err
+			}
+			cli.exitErr = cli.exit(0, ErrGenerate)
+			return nil
+		}
+
+		if err := cli.validateFeatures(); err != nil {
+			return err
+		}
+
+		if err := cli.checkFIPSPolicy(); err != nil {
+			return err
 		}
 
 		if !cli.IsSet(OptDisableLogging) {
@@ -148,36 +627,173 @@ func (cli *CLI[T]) ParseWithInit(initFn func() error, options ...Options) error
 				"go_version": cli.VersionInfo.GoVersion,
 				"os":         cli.VersionInfo.OS,
 				"arch":       cli.VersionInfo.Arch,
+				"fips":       cli.VersionInfo.FIPS,
 			}).Debug("logger initialized")
 		}
 
-		if command != nil {
-			err := initFn()
-			if err != nil {
+		if cli.IsSet(OptEnableVersionTracking) {
+			if err := cli.trackVersion(); err != nil {
+				if errors.Is(err, ErrDowngrade) {
+					return err
+				}
+				return fmt.Errorf("failed to track version: %w", err)
+			}
+		}
+
+		if err := cli.persistUpdateChannel(); err != nil {
+			return err
+		}
+
+		// Built-in commands (e.g. "cache clear") exit once done, as the
+		// application may not otherwise use commands.
+		switch command.(type) {
+		case *cacheClearCommand, *doctorCommand, *configGetCommand, *configSetCommand, *configUnsetCommand, *completionInstallCommand, *usageReportCommand:
+			if err := cli.Finish(command.Execute(args)); err != nil {
 				return err
 			}
+			cli.exitErr = cli.exit(0, ErrBuiltin)
+			return nil
+		}
+
+		if err := cli.waitForTargets(); err != nil {
+			return cli.Finish(err)
+		}
+
+		if command != nil {
+			if initFn != nil {
+				err := initFn()
+				if err != nil {
+					return cli.Finish(err)
+				}
+			}
 
-			return command.Execute(args)
+			return cli.Finish(cli.runCommand(cli.Context(context.Background()), command, args))
 		}
 
 		return nil
 	}
 
-	args, err := cli.Parser.Parse()
+	if err = cli.checkEnvLimits(); err != nil {
+		return cli.fail(err)
+	}
+
+	// Errors are printed here rather than by the parser, so type coercion
+	// errors can be replaced with more descriptive ones first.
+	printErrors := cli.Parser.Options&flags.PrintErrors != 0
+	cli.Parser.Options &^= flags.PrintErrors
+
+	// The long description is only styled while parsing (for help output), so
+	// the parser (e.g. used for generated documentation) remains free of
+	// escape codes. Descriptions changed through WithParserOptions are kept.
+	if plain := cli.Parser.LongDescription; plain == cli.LongDescription() {
+		cli.Parser.LongDescription = cli.renderDescription(os.Stdout)
+		defer func() { cli.Parser.LongDescription = plain }()
+	}
+
+	args, err := cli.normalizeArgs(cli.Parser, cli.splitMountArgs(cli.Parser, os.Args[1:]))
+	if err == nil {
+		args, err = cli.Parser.ParseArgs(args)
+	}
 	if err != nil {
 		if FlagErr, ok := err.(*flags.Error); ok && FlagErr.Type == flags.ErrHelp {
-			os.Exit(0)
+			if printErrors {
+				fmt.Fprintln(os.Stdout, err)
+			}
+			return cli.exit(0, ErrHelp)
+		}
+
+		err = cli.describeValueError(wrapParseError(err))
+
+		// Failing to record parse failures shouldn't change the outcome.
+		if cli.IsSet(OptEnableUsageReport) {
+			_ = cli.recordUsageIssue(err)
+		}
+
+		if printErrors {
+			fmt.Fprintln(os.Stderr, err)
+			cli.printHints(os.Stderr, err)
 		}
-		os.Exit(1)
+		return cli.exit(ExitCode(err), err)
 	}
 
 	cli.Args = args
+	return cli.exitErr
+}
+
+// LongDescription returns the long description of the application, without
+// any styling. This is Description (rendered as plain text) if provided,
+// otherwise the version information.
+func (cli *CLI[T]) LongDescription() string {
+	return cli.renderDescription(nil)
+}
+
+// renderDescription renders the long description for output to f, styled if
+// color is enabled for f (see ColorEnabled).
+func (cli *CLI[T]) renderDescription(f *os.File) string {
+	if cli.Description != "" {
+		return renderMarkdown(f, cli.Description)
+	}
+
+	if cli.VersionInfo == nil {
+		return ""
+	}
+
+	return colorize(f, cli.VersionInfo.stringBase())
+}
+
+// fail prints the provided error to stderr, and exits the process (unless
+// OptNoExit is set, in which case err is returned). This is used for errors
+// which aren't printed by the parser, like setup errors.
+func (cli *CLI[T]) fail(err error) error {
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	return cli.exit(1, err)
+}
+
+// exit exits the process with the provided code, unless OptNoExit is set, in
+// which case err is returned.
+func (cli *CLI[T]) exit(code int, err error) error {
+	if !cli.IsSet(OptNoExit) {
+		cli.emit(EventExit, code, err)
+
+		if code != 0 {
+			cli.RunExitHooks(&ExitEvent{Code: code, Err: err})
+		}
+
+		cli.flushOutputCapture()
+
+		if cli.settings.exitFunc != nil {
+			cli.settings.exitFunc(code)
+			return err
+		}
+
+		os.Exit(code)
+	}
+
 	return err
 }
 
 // newParser returns a new flags parser. However, it does not set CLI[T].Parser.
-func (cli *CLI[T]) newParser() (p *flags.Parser) {
-	p = flags.NewParser(cli, flags.PrintErrors|flags.HelpFlag|flags.PassDoubleDash)
+// If an error is returned, the parser is still usable, however it excludes
+// any commands which failed to be added.
+func (cli *CLI[T]) newParser() (p *flags.Parser, err error) {
+	var opts flags.Options = flags.PrintErrors | flags.HelpFlag | flags.PassDoubleDash
+	if cli.IsSet(OptDisableHelpFlag) {
+		opts &^= flags.HelpFlag
+	}
+
+	if cli.IsSet(OptEnableFlagNegation) {
+		opts |= flags.AllowBoolValues
+	}
+
+	if cli.IsSet(OptDisableInterspersed) {
+		opts |= flags.PassAfterNonOption
+	}
+
+	p = flags.NewParser(cli, opts)
+
+	if name := appletName(); name != "" {
+		p.Name = name
+	}
 
 	p.NamespaceDelimiter = "."
 	p.EnvNamespaceDelimiter = "_"
@@ -186,28 +802,241 @@ func (cli *CLI[T]) newParser() (p *flags.Parser) {
 		p.SubcommandsOptional = true
 	}
 
-	p.LongDescription = color.Sprint(cli.VersionInfo.stringBase())
+	p.LongDescription = cli.LongDescription()
+
+	err = cli.addCommands(p)
+
+	if ferr := cli.applyFlagOverrides(p); ferr != nil {
+		err = errors.Join(err, ferr)
+	}
+
+	if derr := cli.applyDeprecations(p); derr != nil {
+		err = errors.Join(err, derr)
+	}
+
+	for _, fn := range cli.settings.parserOptions {
+		fn(p)
+	}
+
+	if cli.IsSet(OptDisableEnv) {
+		walkOptions(p.Command, func(option *flags.Option) {
+			option.EnvDefaultKey = ""
+		})
+	}
+
+	if o := p.FindOptionByLongName("feature"); o != nil {
+		cli.mu.Lock()
+		o.Hidden = len(cli.features) == 0
+		cli.mu.Unlock()
+	}
+
+	if g := p.Group.Find(networkGroup); g != nil {
+		g.Hidden = !cli.IsSet(OptEnableNetwork)
+	}
+
+	if o := p.FindOptionByLongName("interactive"); o != nil {
+		o.Hidden = !cli.IsSet(OptEnableInteractive)
+	}
+
+	for _, name := range []string{"no-color", "no-pager", "no-input", "yes"} {
+		if o := p.FindOptionByLongName(name); o != nil {
+			o.Hidden = !cli.settings.guidelines
+		}
+	}
+
+	if o := p.FindOptionByLongName("no-cache"); o != nil {
+		o.Hidden = !cli.settings.cache
+	}
+
+	for _, name := range []string{"wait-for", "wait-for-timeout"} {
+		if o := p.FindOptionByLongName(name); o != nil {
+			o.Hidden = !cli.IsSet(OptEnableWaitFor)
+		}
+	}
+
+	if o := p.FindOptionByLongName("update-channel"); o != nil {
+		o.Hidden = cli.settings.updateChannels == nil
+	}
+
+	// Applications without commands of their own shouldn't require one, when
+	// built-in commands are added.
+	if (cli.settings.cache || len(cli.settings.doctorChecks) > 0 || cli.settings.configCommand || cli.settings.completionCommand || cli.IsSet(OptEnableUsageReport)) && len(p.Commands()) == 0 {
+		p.SubcommandsOptional = true
+	}
+
+	if cli.settings.cache {
+		cmd := &cacheCommand{Clear: cacheClearCommand{clear: cli.clearCache}}
+		if _, cerr := p.AddCommand("cache", "manage the cache", "manage the cache", cmd); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add command %q: %w", "cache", cerr))
+		}
+	}
+
+	if cli.settings.completionCommand {
+		cmd := &completionCommand{Install: completionInstallCommand{install: cli.installCompletion}}
+		if _, cerr := p.AddCommand("completion", "manage shell completion", "install shell completion for the current user", cmd); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add command %q: %w", "completion", cerr))
+		}
+	}
+
+	if cli.settings.configCommand {
+		if _, cerr := p.AddCommand("config", "manage configuration", "get, set and unset flag values in the "+DotEnvFile+" file", cli.configCommandData()); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add command %q: %w", "config", cerr))
+		}
+	}
+
+	if cli.IsSet(OptEnableUsageReport) {
+		cmd := &usageReportCommand{run: cli.runUsageReport}
+		if _, cerr := p.AddCommand("usage-report", "summarize recorded parse failures", "summarize parse failures (unknown flags, invalid values, etc) recorded locally", cmd); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add command %q: %w", "usage-report", cerr))
+		}
+	}
+
+	if len(cli.settings.doctorChecks) > 0 {
+		cmd := &doctorCommand{run: cli.runDoctor}
+		if _, cerr := p.AddCommand("doctor", "run diagnostic checks", "run diagnostic checks, exiting non-zero if any fail", cmd); cerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to add command %q: %w", "doctor", cerr))
+		}
+	}
 
-	return p
+	return p, err
 }
 
 // IsSet returns true if the given option is set.
 func (cli *CLI[T]) IsSet(options Options) bool {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
 	return cli.options&options != 0
 }
 
 // Set sets the given option.
 func (cli *CLI[T]) Set(options ...Options) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
 	for _, o := range options {
 		cli.options |= o
 	}
 }
 
+// Reset resets the CLI back to its unparsed state (flags, options, parser,
+// logger, version information and remaining arguments), so it can be parsed
+// again. This is primarily useful in tests. Output capture (see
+// WithOutputCapture) is stopped, restoring the original streams, and
+// accessibility mode is disabled if it was enabled by the CLI (e.g. with
+// --accessible). Mounted
+// commands, links, event subscriptions, completers, middleware, hints and
+// version options are retained.
+func (cli *CLI[T]) Reset() {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.Flags = nil
+	cli.Parser = nil
+	cli.VersionInfo = nil
+	cli.Args = nil
+	cli.Version.Enabled = false
+	cli.Version.EnabledJSON = false
+	cli.Version.VerifyProvenance = ""
+	cli.Version.EnabledOCI = false
+	cli.Debug = false
+	cli.DebugCategories = nil
+	cli.GenerateMarkdown = false
+	cli.GenerateMan = false
+	cli.GenerateEnvReference = false
+	cli.GenerateShell = ""
+	cli.GeneratePackage = ""
+	cli.GenerateDeploy = ""
+	cli.ExportEnvFormat = ""
+	cli.GenerateKubernetes = false
+	cli.Reveal = false
+	cli.Accessible = false
+	cli.DebugCLI = false
+	cli.HelpSearch = ""
+	cli.HelpJSON = false
+	cli.NoColor = false
+	cli.NoPager = false
+	cli.NoInput = false
+	cli.Yes = false
+	cli.GuidelinesReport = false
+	cli.Interactive = false
+	cli.WaitForTargets = nil
+	cli.WaitForTimeout = 0
+	cli.Record = ""
+	cli.Replay = ""
+	cli.recorder = nil
+	cli.FrozenTime = ""
+	cli.clock = nil
+	cli.rand = nil
+	cli.Network = NetworkConfig{}
+	cli.NoCache = false
+	cli.Channel = ""
+	cli.FeatureOverrides = nil
+	cli.WarningsJSON = ""
+	cli.warnings = nil
+	cli.ResultJSON = ""
+	cli.Stats = ""
+	cli.result = nil
+	cli.processes = nil
+	cli.upgradedFrom = ""
+	cli.commandPath = ""
+	cli.Logger = nil
+	cli.LoggerConfig = LoggerConfig{
+		Handler: cli.LoggerConfig.Handler,
+		Writer:  cli.LoggerConfig.Writer,
+	}
+	cli.options = 0
+	cli.parsed = false
+	cli.exitErr = nil
+	cli.exitHooksOnce = sync.Once{}
+	cli.state = nil
+
+	// Accessibility mode is process-wide, so only disable it if it was enabled
+	// by this CLI (rather than with SetAccessible).
+	if cli.accessible {
+		SetAccessible(false)
+		cli.accessible = false
+	}
+
+	if cli.capture != nil {
+		cli.capture.stop()
+		cli.capture = nil
+	}
+}
+
+// LinkKind is the category of a link, which allows clix (and applications)
+// to find a specific type of link, regardless of its name.
+type LinkKind string
+
+const (
+	LinkWebsite      LinkKind = "website"
+	LinkSource       LinkKind = "source"
+	LinkIssues       LinkKind = "issues"
+	LinkReleases     LinkKind = "releases"
+	LinkSupport      LinkKind = "support"
+	LinkContributing LinkKind = "contributing"
+	LinkSecurity     LinkKind = "security"
+	LinkDocs         LinkKind = "docs"
+	LinkDiscord      LinkKind = "discord"
+)
+
 // Link allows you to define a link to be included in the version and usage
 // output.
 type Link struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
+	Name string   `json:"name"`
+	URL  string   `json:"url"`
+	Kind LinkKind `json:"kind,omitempty"`
+}
+
+// FindLink returns the first link of the provided kind, if any.
+func FindLink(links []Link, kind LinkKind) (link Link, ok bool) {
+	for _, l := range links {
+		if l.Kind == kind {
+			return l, true
+		}
+	}
+
+	return link, false
 }
 
 // GithubLinks return an opinonated set of links for the project, using
@@ -226,16 +1055,29 @@ func GithubLinks(repo, branch, homepage string) []Link {
 		links = append(links, Link{
 			Name: "homepage",
 			URL:  homepage,
+			Kind: LinkWebsite,
 		})
 	}
 
 	links = append(links, []Link{
-		{Name: "github", URL: fmt.Sprintf("https://%s", repo)},
-		{Name: "issues", URL: fmt.Sprintf("https://%s/issues/new/choose", repo)},
-		{Name: "support", URL: fmt.Sprintf("https://%s/blob/%s/.github/SUPPORT.md", repo, branch)},
-		{Name: "contributing", URL: fmt.Sprintf("https://%s/blob/%s/.github/CONTRIBUTING.md", repo, branch)},
-		{Name: "security", URL: fmt.Sprintf("https://%s/security/policy", repo)},
+		{Name: "github", URL: fmt.Sprintf("https://%s", repo), Kind: LinkSource},
+		{Name: "issues", URL: fmt.Sprintf("https://%s/issues/new/choose", repo), Kind: LinkIssues},
+		{Name: "releases", URL: fmt.Sprintf("https://%s/releases", repo), Kind: LinkReleases},
+		{Name: "support", URL: fmt.Sprintf("https://%s/blob/%s/.github/SUPPORT.md", repo, branch), Kind: LinkSupport},
+		{Name: "contributing", URL: fmt.Sprintf("https://%s/blob/%s/.github/CONTRIBUTING.md", repo, branch), Kind: LinkContributing},
+		{Name: "security", URL: fmt.Sprintf("https://%s/security/policy", repo), Kind: LinkSecurity},
 	}...)
 
 	return links
 }
+
+// moduleLinks returns links derived from the provided module path, if it's
+// hosted on GitHub (e.g. "github.com/user/repo/v2").
+func moduleLinks(module string) []Link {
+	parts := strings.Split(module, "/")
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return nil
+	}
+
+	return GithubLinks(strings.Join(parts[:3], "/"), "", "")
+}
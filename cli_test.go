@@ -0,0 +1,99 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+type resetFlags struct {
+	Name string `long:"name" description:"name"`
+}
+
+func TestResetParse(t *testing.T) {
+	var hooks int
+
+	cli := &clix.CLI[resetFlags]{}
+	err := cli.Apply(
+		clix.WithOutputCapture(1024),
+		clix.WithExitHook(func(_ context.Context, _ *clix.ExitEvent) { hooks++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range []string{"first", "second"} {
+		res := clixtest.Run(t, cli, []string{"--name", name}, &clixtest.RunOptions{
+			Run: func() error {
+				fmt.Println("hello", cli.Flags.Name)
+				return nil
+			},
+		})
+		if res.Err != nil {
+			t.Fatalf("run %d: unexpected error: %v", i, res.Err)
+		}
+
+		if cli.Flags.Name != name {
+			t.Fatalf("run %d: unexpected name %q, want %q", i, cli.Flags.Name, name)
+		}
+
+		// Output capture must be restarted for each run, using the streams of
+		// that run.
+		if want := "hello " + name; !strings.Contains(res.Stdout, want) {
+			t.Fatalf("run %d: expected stdout to contain %q, got %q", i, want, res.Stdout)
+		}
+	}
+
+	if err = cli.ParseWithInit(nil); !errors.Is(err, clix.ErrAlreadyParsed) {
+		t.Fatalf("expected ErrAlreadyParsed without Reset, got %v", err)
+	}
+
+	hooks = 0
+
+	for i := range 2 {
+		clixtest.Run(t, cli, nil, nil)
+
+		cli.RunExitHooks(&clix.ExitEvent{Code: 1})
+		cli.RunExitHooks(&clix.ExitEvent{Code: 1})
+
+		if hooks != i+1 {
+			t.Fatalf("run %d: expected exit hooks to be invoked once per run, got %d invocations", i, hooks)
+		}
+	}
+}
+
+func TestResetAccessible(t *testing.T) {
+	t.Setenv("ACCESSIBLE", "")
+	os.Unsetenv("ACCESSIBLE")
+
+	cli := &clix.CLI[resetFlags]{}
+
+	clixtest.Run(t, cli, []string{"--accessible"}, nil)
+	if !clix.Accessible() {
+		t.Fatal("expected accessibility mode to be enabled with --accessible")
+	}
+
+	clixtest.Run(t, cli, nil, nil)
+	if clix.Accessible() {
+		t.Fatal("expected accessibility mode to be disabled after Reset")
+	}
+
+	// Accessibility mode enabled by the application is kept.
+	clix.SetAccessible(true)
+	t.Cleanup(func() { clix.SetAccessible(false) })
+
+	clixtest.Run(t, cli, nil, nil)
+	if !clix.Accessible() {
+		t.Fatal("expected accessibility mode enabled with SetAccessible to be kept")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+// Package clixtest provides helpers for testing applications built with clix.
+package clixtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix"
+)
+
+// Documentation formats, supported by GoldenDocs. Shell integrations (see the
+// clix.Shell* constants) are also supported.
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+func init() {
+	// Share the -update flag, if already defined by the application's tests
+	// (or another golden-file package).
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "update golden files")
+	}
+}
+
+// updating returns true if golden files should be updated (go test -update).
+func updating() bool {
+	f := flag.Lookup("update")
+	return f != nil && f.Value.String() == "true"
+}
+
+// RenderDocs renders the documentation for the CLI in the provided format
+// (see the Format* constants, and the clix.Shell* constants). Output is
+// deterministic: the name is derived from the binary name, without the
+// ".test" or ".exe" suffixes.
+func RenderDocs[T any](cli *clix.CLI[T], format string) ([]byte, error) {
+	if cli.VersionInfo == nil {
+		cli.VersionInfo = cli.GetVersionInfo()
+	}
+
+	m := cli.DocModel()
+	m.Name = strings.TrimSuffix(strings.TrimSuffix(m.Name, ".exe"), ".test")
+
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatMarkdown:
+		m.Markdown(&buf)
+	case FormatJSON:
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			return nil, err
+		}
+	default:
+		if err := m.Shell(&buf, format); err != nil {
+			return nil, fmt.Errorf("unknown docs format %q", format)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GoldenDocs renders the documentation for the CLI in the provided format (see
+// RenderDocs), and compares it against the golden file at path, failing the
+// test if they differ. Run tests with -update to write the golden files (and
+// any missing parent directories).
+//
+// Example:
+//
+//	func TestDocs(t *testing.T) {
+//		clixtest.GoldenDocs(t, cli, clixtest.FormatMarkdown, "testdata/docs.golden.md")
+//	}
+func GoldenDocs[T any](t testing.TB, cli *clix.CLI[T], format, path string) {
+	t.Helper()
+
+	got, err := RenderDocs(cli, format)
+	if err != nil {
+		t.Fatalf("failed to render %s docs: %v", format, err)
+	}
+
+	Golden(t, got, path)
+}
+
+// Golden compares got against the golden file at path, failing the test if
+// they differ (line endings are normalized). Run tests with -update to write
+// the golden files (and any missing parent directories).
+func Golden(t testing.TB, got []byte, path string) {
+	t.Helper()
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil { //nolint:gosec
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	got = bytes.ReplaceAll(got, []byte("\r\n"), []byte("\n"))
+
+	if bytes.Equal(want, got) {
+		return
+	}
+
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+
+	for i := range max(len(wantLines), len(gotLines)) {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+
+		if w != g {
+			t.Errorf(
+				"output differs from golden file %s (run with -update to update it), first difference at line %d:\n  want: %q\n   got: %q",
+				path, i+1, w, g,
+			)
+			return
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clixtest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix"
+)
+
+// RunOptions are the options for Run.
+type RunOptions struct {
+	// Stdin is the input provided to the CLI as os.Stdin. Defaults to empty
+	// input.
+	Stdin string
+
+	// Env are environment variables to set for the duration of the test.
+	Env map[string]string
+
+	// Options are additional clix options to parse with. OptNoExit is always
+	// set.
+	Options []clix.Options
+
+	// Init is invoked after parsing, before any sub-command is executed (see
+	// CLI.ParseWithInit).
+	Init func() error
+
+	// Run is invoked after parsing, for CLIs which don't use sub-commands
+	// (i.e. the code which would normally follow Parse in main). Its error is
+	// passed through CLI.Finish, as it would be in main.
+	Run func() error
+}
+
+// RunResult is the result of Run.
+type RunResult struct {
+	// Stdout and Stderr are the captured output.
+	Stdout string
+	Stderr string
+
+	// Err is the error returned from parsing, the executed sub-command, or
+	// RunOptions.Run.
+	Err error
+
+	// ExitCode is the exit code the process would have exited with.
+	ExitCode int
+
+	// Dir is the temporary directory used for the user's home, config, state,
+	// cache and data directories.
+	Dir string
+}
+
+// Run parses and runs the CLI in-process with the provided arguments
+// (excluding the program name), capturing stdin/stdout/stderr and the exit
+// code, instead of exiting the process. The CLI is reset (see CLI.Reset)
+// beforehand, so it can be run multiple times.
+//
+// The user's home, config, state, cache and data directories (HOME, XDG_*,
+// and their Windows equivalents) point to a temporary directory, so tests
+// don't read or write the user's actual configuration. Since os.Args, the
+// environment and the standard streams are process-wide, Run must not be
+// used in parallel tests.
+//
+// Example:
+//
+//	func TestServe(t *testing.T) {
+//		res := clixtest.Run(t, cli, []string{"serve", "--dry-run"}, nil)
+//		if res.ExitCode != 0 {
+//			t.Fatalf("unexpected exit code %d: %s", res.ExitCode, res.Stderr)
+//		}
+//	}
+func Run[T any](t testing.TB, cli *clix.CLI[T], args []string, opts *RunOptions) *RunResult {
+	t.Helper()
+
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+
+	res := &RunResult{Dir: t.TempDir()}
+
+	for key, dir := range map[string]string{
+		"HOME":            "home",
+		"USERPROFILE":     "home",
+		"XDG_CONFIG_HOME": "config",
+		"XDG_STATE_HOME":  "state",
+		"XDG_CACHE_HOME":  "cache",
+		"XDG_DATA_HOME":   "data",
+		"AppData":         "config",
+		"LocalAppData":    "local",
+	} {
+		t.Setenv(key, filepath.Join(res.Dir, dir))
+	}
+
+	for key, value := range opts.Env {
+		t.Setenv(key, value)
+	}
+
+	stdin, err := os.CreateTemp(res.Dir, "stdin-*")
+	if err != nil {
+		t.Fatalf("failed to create stdin: %v", err)
+	}
+	defer stdin.Close()
+
+	if _, err = io.WriteString(stdin, opts.Stdin); err != nil {
+		t.Fatalf("failed to write stdin: %v", err)
+	}
+
+	if _, err = stdin.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("failed to seek stdin: %v", err)
+	}
+
+	stdout, stopStdout := capture(t)
+	stderr, stopStderr := capture(t)
+
+	origArgs, origStdin, origStdout, origStderr := os.Args, os.Stdin, os.Stdout, os.Stderr
+	os.Args = append([]string{origArgs[0]}, args...)
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+
+	func() {
+		defer func() {
+			os.Args, os.Stdin, os.Stdout, os.Stderr = origArgs, origStdin, origStdout, origStderr
+		}()
+
+		cli.Reset()
+
+		options := append([]clix.Options{clix.OptNoExit}, opts.Options...)
+		res.Err = cli.ParseWithInit(opts.Init, options...)
+
+		if res.Err == nil && opts.Run != nil {
+			res.Err = cli.Finish(opts.Run())
+		}
+	}()
+
+	res.Stdout = stopStdout()
+	res.Stderr = stopStderr()
+	res.ExitCode = exitCode(res.Err)
+
+	return res
+}
+
+// exitCode returns the exit code the process would have exited with, for the
+// provided error.
+func exitCode(err error) int {
+	for _, sentinel := range []error{clix.ErrHelp, clix.ErrVersion, clix.ErrMarkdown, clix.ErrGenerate, clix.ErrBuiltin} {
+		if errors.Is(err, sentinel) {
+			return 0
+		}
+	}
+
+	return clix.ExitCode(err)
+}
+
+// capture returns a pipe which captures everything written to it, and a
+// function which closes the pipe, and returns the captured output.
+func capture(t testing.TB) (w *os.File, stop func() string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		_, _ = io.Copy(&buf, r)
+		_ = r.Close()
+	}()
+
+	return w, func() string {
+		_ = w.Close()
+		<-done
+		return strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time. Use ClockFrom (or CLI.Clock) rather than
+// time.Now, so output can be made deterministic with --frozen-time (e.g. in
+// golden tests and demos).
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock which uses the system time.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+// Now returns the current system time.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// FixedClock returns a Clock which always returns the provided time.
+func FixedClock(t time.Time) Clock {
+	return fixedClock{t: t}
+}
+
+type fixedClock struct {
+	t time.Time
+}
+
+// Now returns the fixed time.
+func (c fixedClock) Now() time.Time { return c.t }
+
+// lockedSource is a rand.Source which is safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+// Uint64 implements rand.Source.
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.src.Uint64()
+}
+
+// newRand returns a random number generator, which is safe for concurrent use,
+// seeded with the provided seed.
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(&lockedSource{src: rand.NewPCG(seed, seed)})
+}
+
+// defaultRand is the random number generator used when none is configured.
+var defaultRand = newRand(rand.Uint64())
+
+// parseFrozenTime parses the value of --frozen-time, which is either an
+// RFC3339 timestamp, or a unix timestamp (in seconds).
+func parseFrozenTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --frozen-time %q: expected an RFC3339 or unix timestamp", v)
+}
+
+// initClock initializes the clock and random number generator, from
+// --frozen-time.
+func (cli *CLI[T]) initClock() error {
+	if cli.FrozenTime == "" {
+		cli.mu.Lock()
+		cli.clock, cli.rand = SystemClock, defaultRand
+		cli.mu.Unlock()
+		return nil
+	}
+
+	t, err := parseFrozenTime(cli.FrozenTime)
+	if err != nil {
+		return err
+	}
+
+	cli.mu.Lock()
+	cli.clock, cli.rand = FixedClock(t), newRand(uint64(t.UnixNano())) //nolint:gosec
+	cli.mu.Unlock()
+
+	return nil
+}
+
+// Clock returns the clock of the CLI, which is fixed to the time provided with
+// --frozen-time, or the system clock otherwise.
+func (cli *CLI[T]) Clock() Clock {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.clock == nil {
+		return SystemClock
+	}
+
+	return cli.clock
+}
+
+// Rand returns the random number generator of the CLI, which is safe for
+// concurrent use. With --frozen-time, it's seeded from the provided time, so
+// it produces the same sequence on each run.
+func (cli *CLI[T]) Rand() *rand.Rand {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.rand == nil {
+		return defaultRand
+	}
+
+	return cli.rand
+}
+
+// ClockFrom returns the clock carried by the provided context (see
+// CLI.Context), or SystemClock if there is none.
+func ClockFrom(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(contextClock).(Clock); ok {
+		return clock
+	}
+
+	return SystemClock
+}
+
+// RandFrom returns the random number generator carried by the provided context
+// (see CLI.Context), or a randomly seeded one if there is none. It's safe for
+// concurrent use.
+func RandFrom(ctx context.Context) *rand.Rand {
+	if r, ok := ctx.Value(contextRand).(*rand.Rand); ok {
+		return r
+	}
+
+	return defaultRand
+}
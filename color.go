@@ -0,0 +1,215 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ColorMode controls whether clix output (version information, help, logging,
+// warnings and statistics) is colored.
+type ColorMode int
+
+const (
+	// ColorAuto colors output if the stream is a terminal which supports ANSI
+	// escape sequences, unless overridden by the environment: NO_COLOR
+	// disables color, and FORCE_COLOR or CLICOLOR_FORCE (when not "0")
+	// enables it, taking precedence over NO_COLOR. Color is always disabled
+	// in accessibility mode (see Accessible).
+	ColorAuto   ColorMode = iota
+	ColorAlways           // Always color output, ignoring the environment.
+	ColorNever            // Never color output, ignoring the environment.
+)
+
+var (
+	colorMu      sync.RWMutex
+	colorMode    = ColorAuto
+	colorStreams = map[uintptr]ColorMode{}
+)
+
+// SetColorMode sets the color mode for the provided streams (e.g. os.Stdout
+// or os.Stderr), or the default mode for all streams if none are provided.
+// Streams are process-wide, so this affects all CLIs. A per-stream mode takes
+// precedence over the default mode.
+//
+// Example:
+//
+//	// color on stderr (logging), but not on stdout.
+//	cli.SetColorMode(clix.ColorAlways, os.Stderr)
+//	cli.SetColorMode(clix.ColorNever, os.Stdout)
+func (cli *CLI[T]) SetColorMode(mode ColorMode, streams ...*os.File) {
+	colorMu.Lock()
+	defer colorMu.Unlock()
+
+	if len(streams) == 0 {
+		colorMode = mode
+		return
+	}
+
+	for _, f := range streams {
+		if f != nil {
+			colorStreams[f.Fd()] = mode
+		}
+	}
+}
+
+// ColorEnabled returns true if output to the provided stream should be
+// colored, based on the configured ColorMode (see CLI.SetColorMode), the
+// environment, and the stream's capabilities (see Console).
+func ColorEnabled(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+
+	colorMu.RLock()
+	mode, ok := colorStreams[f.Fd()]
+	if !ok {
+		mode = colorMode
+	}
+	colorMu.RUnlock()
+
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if Accessible() {
+		return false
+	}
+
+	if envTrue("FORCE_COLOR") || envTrue("CLICOLOR_FORCE") {
+		return true
+	}
+
+	if getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+
+	info := Console(f)
+	return info.Terminal && info.VirtualTerminal
+}
+
+// envTrue returns true if the provided environment variable is set to a
+// non-empty value other than "0" or "false".
+func envTrue(key string) bool {
+	v := strings.TrimSpace(getenv(key))
+	return v != "" && v != "0" && !strings.EqualFold(v, "false")
+}
+
+// streamFor returns the stream written to by w, or os.Stdout if w isn't a
+// file.
+func streamFor(w io.Writer) *os.File {
+	if f, ok := w.(*os.File); ok {
+		return f
+	}
+	return os.Stdout
+}
+
+// colorTags are the ANSI codes of the color tags supported by colorize (a
+// subset of those supported by gookit/color, which clix output previously
+// used).
+var colorTags = map[string]string{
+	"black":     "0;30",
+	"red":       "0;31",
+	"green":     "0;32",
+	"yellow":    "0;33",
+	"blue":      "0;34",
+	"magenta":   "0;35",
+	"cyan":      "0;36",
+	"white":     "1;37",
+	"gray":      "0;90",
+	"default":   "0;39",
+	"redB":      "1;31",
+	"greenB":    "1;32",
+	"yellowB":   "1;33",
+	"blueB":     "1;34",
+	"magentaB":  "1;35",
+	"cyanB":     "1;36",
+	"bold":      "1",
+	"italic":    "3",
+	"underline": "4",
+}
+
+// colorize renders the color tags in s (see colorTags, e.g. "<cyan>text</>")
+// for output to f, or strips them if color is disabled for f (see
+// ColorEnabled). Tags can't be nested. This is done in a single pass without
+// regular expressions, as it's used on large outputs (e.g. version information
+// with many dependencies).
+func colorize(f *os.File, s string) string {
+	if !strings.Contains(s, "</>") {
+		return s
+	}
+
+	enabled := ColorEnabled(f)
+
+	var b strings.Builder
+	b.Grow(len(s) + strings.Count(s, "</>")*8)
+
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			break
+		}
+
+		tagEnd := i + 1
+		for tagEnd < len(s) && isColorTagByte(s[tagEnd]) {
+			tagEnd++
+		}
+
+		end := -1
+		if tagEnd > i+1 && tagEnd < len(s) && s[tagEnd] == '>' {
+			end = strings.Index(s[tagEnd+1:], "</>")
+		}
+
+		if end < 0 {
+			// Not a tag, so skip past the "<".
+			b.WriteString(s[:i+1])
+			s = s[i+1:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+
+		tag, body := s[i+1:tagEnd], s[tagEnd+1:tagEnd+1+end]
+		s = s[tagEnd+1+end+3:]
+
+		if code := colorTags[tag]; enabled && code != "" && body != "" {
+			b.WriteString("\x1b[")
+			b.WriteString(code)
+			b.WriteString("m")
+			b.WriteString(body)
+			b.WriteString("\x1b[0m")
+			continue
+		}
+
+		b.WriteString(body)
+	}
+
+	b.WriteString(s)
+	return b.String()
+}
+
+// colorText returns s colored with the provided color tag (see colorTags) for
+// output to f, or as-is if color is disabled for f. Unlike colorize, s isn't
+// interpreted, so it's safe for arbitrary (e.g. user-provided) text.
+func colorText(f *os.File, tag, s string) string {
+	code := colorTags[tag]
+	if code == "" || s == "" || !ColorEnabled(f) {
+		return s
+	}
+
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// isColorTagByte returns true if c is valid within a color tag.
+func isColorTagByte(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		c == '_' || c == '=' || c == ',' || c == ';'
+}
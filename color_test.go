@@ -0,0 +1,64 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// benchColorInput returns version-like output with 500 colored dependency
+// lines.
+func benchColorInput() string {
+	var b strings.Builder
+
+	for i := range 500 {
+		fmt.Fprintf(&b, "|  <cyan>github.com/example/dependency-%03d</> :: <green>v1.%d.0</>\n", i, i)
+	}
+
+	return b.String()
+}
+
+func BenchmarkColorize(b *testing.B) {
+	s := benchColorInput()
+
+	for _, enabled := range []bool{true, false} {
+		b.Run(fmt.Sprintf("enabled=%v", enabled), func(b *testing.B) {
+			if enabled {
+				b.Setenv("FORCE_COLOR", "1")
+			} else {
+				b.Setenv("FORCE_COLOR", "0")
+				b.Setenv("NO_COLOR", "1")
+			}
+
+			if got := strings.Contains(colorize(os.Stdout, s), "\x1b["); got != enabled {
+				b.Fatalf("expected color enabled=%v, got %v", enabled, got)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				colorize(os.Stdout, s)
+			}
+		})
+	}
+}
+
+func BenchmarkVersionString(b *testing.B) {
+	b.Setenv("FORCE_COLOR", "1")
+
+	cli := &CLI[struct{}]{}
+	v := cli.GetVersionInfo()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = v.String()
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// command is a command registered at runtime with AddCommand.
+type command struct {
+	name string
+	help string
+	data any
+}
+
+// AddCommand registers a command at runtime (e.g. based on build tags or
+// plugins), in addition to those defined in the flags struct. cmd must be a
+// pointer to a struct using go-flags struct tags, which should implement
+// flags.Commander to be invoked. Registered commands are included in help and
+// generated documentation. Must be called before Parse().
+//
+// Example:
+//
+//	type ServeCommand struct {
+//		Port int `long:"port" default:"8080" description:"port to listen on"`
+//	}
+//
+//	func (c *ServeCommand) Execute(args []string) error { [...] }
+//
+//	[...]
+//	cli.AddCommand("serve", &ServeCommand{}, "start the server")
+func (cli *CLI[T]) AddCommand(name string, cmd any, help string) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.commands = append(cli.commands, &command{name: name, help: help, data: cmd})
+}
+
+// addCommands adds all runtime-registered and mounted commands to the parser,
+// returning all errors encountered (commands which fail to be added are
+// skipped).
+func (cli *CLI[T]) addCommands(p *flags.Parser) error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	var errs []error
+
+	for _, c := range cli.commands {
+		// go-flags panics if the command isn't a pointer to a struct.
+		if v := reflect.ValueOf(c.data); v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			errs = append(errs, fmt.Errorf("failed to add command %q: expected pointer to struct, got %T", c.name, c.data))
+			continue
+		}
+
+		if _, err := p.AddCommand(c.name, c.help, c.help, c.data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add command %q: %w", c.name, err))
+		}
+	}
+
+	for _, m := range cli.mounts {
+		if _, err := p.AddCommand(m.name, m.description, m.description, m); err != nil {
+			errs = append(errs, fmt.Errorf("failed to mount command %q: %w", m.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
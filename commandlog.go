@@ -0,0 +1,106 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"strings"
+
+	"github.com/apex/log"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// CommandPath returns the path of the sub-command being executed (e.g.
+// "server start"), or an empty string if no sub-command was invoked. Must be
+// called after Parse().
+func (cli *CLI[T]) CommandPath() string {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	return cli.commandPath
+}
+
+// CommandLogger returns a logger scoped to the sub-command being executed,
+// which includes a "command" field with the command path (see CommandPath).
+// Note that the "command" field is also added to all entries of the CLI's
+// logger automatically, when a sub-command is invoked. Must be called after
+// Parse().
+func (cli *CLI[T]) CommandLogger() *log.Entry {
+	cli.mu.Lock()
+	logger, path := cli.Logger, cli.commandPath
+	cli.mu.Unlock()
+
+	var l log.Interface = log.Log
+	if logger != nil {
+		l = logger
+	}
+
+	return l.WithField("command", path)
+}
+
+// activeCommandPath returns the path of the active sub-command of the
+// provided parser.
+func activeCommandPath(p *flags.Parser) string {
+	var names []string
+
+	for cmd := p.Command.Active; cmd != nil; cmd = cmd.Active {
+		names = append(names, cmd.Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// commandFieldHandler adds "command" (if a sub-command was invoked) and
+// "invocation_id" fields to all log entries, unless already set.
+type commandFieldHandler struct {
+	next         log.Handler
+	path         string
+	invocationID string
+}
+
+func (h *commandFieldHandler) HandleLog(e *log.Entry) error {
+	_, hasCommand := e.Fields["command"]
+	_, hasID := e.Fields["invocation_id"]
+
+	if (hasCommand || h.path == "") && (hasID || h.invocationID == "") {
+		return h.next.HandleLog(e)
+	}
+
+	fields := make(log.Fields, len(e.Fields)+2)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+
+	if !hasCommand && h.path != "" {
+		fields["command"] = h.path
+	}
+
+	if !hasID && h.invocationID != "" {
+		fields["invocation_id"] = h.invocationID
+	}
+
+	entry := *e
+	entry.Fields = fields
+
+	return h.next.HandleLog(&entry)
+}
+
+// wrapCommandField wraps the logger's handler, so all entries include the
+// command path (see CommandPath), if a sub-command was invoked, and the
+// invocation ID (see InvocationID).
+func (cli *CLI[T]) wrapCommandField(logger *log.Logger) {
+	cli.mu.Lock()
+	path, id := cli.commandPath, cli.invocationID
+	cli.mu.Unlock()
+
+	if (path == "" && id == "") || logger.Handler == nil {
+		return
+	}
+
+	if _, ok := logger.Handler.(*commandFieldHandler); ok {
+		return
+	}
+
+	logger.Handler = &commandFieldHandler{next: logger.Handler, path: path, invocationID: id}
+}
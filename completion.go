@@ -0,0 +1,200 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"reflect"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// completeCommand is the hidden command used by the generated shell completion
+// scripts (see ShellBashCompletion), which writes completions for the provided
+// arguments (the last being the word being completed) to stdout, one per line,
+// as "ITEM" or "ITEM<tab>DESCRIPTION".
+const completeCommand = "__complete"
+
+// CompleteFunc returns completions for the provided (partial) flag value, e.g.
+// by reading from a local cache, or querying an API. Completions which don't
+// start with match are ignored.
+type CompleteFunc func(match string) []flags.Completion
+
+// RegisterCompletion registers a dynamic value completer for the flag with the
+// provided long name (including any namespace, e.g. "db.cluster"), used by the
+// generated shell completion scripts. Flags with choices, or with a value type
+// implementing flags.Completer (e.g. flags.Filename), are completed without a
+// completer. Must be called before Parse().
+//
+// Example:
+//
+//	cli.RegisterCompletion("cluster", func(match string) []flags.Completion {
+//		var out []flags.Completion
+//		for _, name := range cachedClusters() {
+//			out = append(out, flags.Completion{Item: name})
+//		}
+//		return out
+//	})
+func (cli *CLI[T]) RegisterCompletion(long string, fn CompleteFunc) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.completers == nil {
+		cli.completers = make(map[string]CompleteFunc)
+	}
+
+	cli.completers[long] = fn
+}
+
+// Completions returns the completions for the provided arguments (excluding
+// the program name), where the last argument is the (possibly empty) word
+// being completed. Flag names, sub-commands, and flag values are completed.
+// Values of "--flag=value" words are completed without the "--flag=" prefix.
+// Must be called after the parser is created (e.g. after Parse()).
+func (cli *CLI[T]) Completions(args []string) []flags.Completion {
+	if cli.Parser == nil {
+		return nil
+	}
+
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	words, match := args[:len(args)-1], args[len(args)-1]
+	stack := []*flags.Command{cli.Parser.Command}
+	var pending *flags.Option
+
+	for _, word := range words {
+		if pending != nil {
+			pending = nil
+			continue
+		}
+
+		if word == "--" {
+			return nil
+		}
+
+		if strings.HasPrefix(word, "-") && len(word) > 1 {
+			option, attached := findCompletionOption(stack, word)
+			if option != nil && !attached && takesValue(option) {
+				pending = option
+			}
+			continue
+		}
+
+		if sub := stack[len(stack)-1].Find(word); sub != nil {
+			stack = append(stack, sub)
+		}
+	}
+
+	if pending != nil {
+		return cli.completeValue(pending, match)
+	}
+
+	if name, value, ok := strings.Cut(match, "="); ok && strings.HasPrefix(name, "--") {
+		if option, _ := findCompletionOption(stack, name); option != nil {
+			return cli.completeValue(option, value)
+		}
+		return nil
+	}
+
+	var out []flags.Completion
+
+	if strings.HasPrefix(match, "-") {
+		for _, cmd := range stack {
+			walkGroupOptions(cmd.Group, func(option *flags.Option) {
+				if option.Hidden {
+					return
+				}
+
+				item := "--" + option.LongNameWithNamespace()
+				if option.LongName == "" {
+					item = "-" + string(option.ShortName)
+				}
+
+				if strings.HasPrefix(item, match) {
+					out = append(out, flags.Completion{Item: item, Description: option.Description})
+				}
+			})
+		}
+
+		return out
+	}
+
+	for _, sub := range stack[len(stack)-1].Commands() {
+		if !sub.Hidden && strings.HasPrefix(sub.Name, match) {
+			out = append(out, flags.Completion{Item: sub.Name, Description: sub.ShortDescription})
+		}
+	}
+
+	return out
+}
+
+// completeValue returns completions for the value of the provided option,
+// from a registered completer, its choices, or its value type.
+func (cli *CLI[T]) completeValue(option *flags.Option, match string) []flags.Completion {
+	cli.mu.Lock()
+	fn := cli.completers[option.LongNameWithNamespace()]
+	cli.mu.Unlock()
+
+	var candidates []flags.Completion
+
+	switch {
+	case fn != nil:
+		candidates = fn(match)
+	case len(option.Choices) > 0:
+		for _, choice := range option.Choices {
+			candidates = append(candidates, flags.Completion{Item: choice})
+		}
+	default:
+		// Completers (e.g. flags.Filename) typically use pointer receivers.
+		if completer, ok := reflect.New(reflect.TypeOf(option.Value())).Interface().(flags.Completer); ok {
+			candidates = completer.Complete(match)
+		}
+	}
+
+	out := candidates[:0]
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Item, match) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+// findCompletionOption returns the option for the provided flag word (e.g.
+// "--name", "--name=value", "-n", or "-nvalue"), searching from the innermost
+// command outwards, and whether a value is attached to the word.
+func findCompletionOption(stack []*flags.Command, word string) (option *flags.Option, attached bool) {
+	for i := len(stack) - 1; i >= 0 && option == nil; i-- {
+		if name, ok := strings.CutPrefix(word, "--"); ok {
+			name, _, attached = strings.Cut(name, "=")
+			option = stack[i].FindOptionByLongName(name)
+			continue
+		}
+
+		short := []rune(word[1:])
+		attached = len(short) > 1
+		option = stack[i].FindOptionByShortName(short[0])
+	}
+
+	return option, attached
+}
+
+// takesValue returns true if the provided option requires a value as the
+// next argument.
+func takesValue(option *flags.Option) bool {
+	if option.OptionalArgument {
+		return false
+	}
+
+	t := reflect.TypeOf(option.Value())
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t.Kind() != reflect.Bool && t.Kind() != reflect.Func
+}
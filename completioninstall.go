@@ -0,0 +1,202 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithCompletionCommand adds a "completion install" command, which installs
+// the shell completion script (see ShellBashCompletion) for the user's shell
+// (bash, zsh or fish, detected from $SHELL, or provided with --shell):
+//
+//   - fish: written to ~/.config/fish/completions/<name>.fish, which fish
+//     loads automatically.
+//   - bash and zsh: written to ~/.local/share/<name>/completion.<shell>, and
+//     sourced from ~/.bashrc or ~/.zshrc (respecting $XDG_CONFIG_HOME,
+//     $XDG_DATA_HOME and $ZDOTDIR).
+//
+// Startup files are only modified if they don't already source the script,
+// and a backup (with a ".bak" suffix) is written first. --no-rc skips
+// modifying startup files.
+func WithCompletionCommand() Option {
+	return func(s *settings) error {
+		s.completionCommand = true
+		return nil
+	}
+}
+
+// completionCommand is the "completion" command, registered with
+// WithCompletionCommand.
+type completionCommand struct {
+	Install completionInstallCommand `command:"install" description:"install shell completion for the current user"`
+}
+
+// completionInstallCommand is the "completion install" command.
+type completionInstallCommand struct {
+	Shell string `long:"shell" choice:"bash" choice:"zsh" choice:"fish" description:"shell to install completion for (defaults to $SHELL)"`
+	NoRC  bool   `long:"no-rc" description:"don't modify shell startup files (e.g. ~/.bashrc)"`
+
+	install func(shell string, rc bool) error
+}
+
+// Execute implements flags.Commander.
+func (c *completionInstallCommand) Execute(_ []string) error {
+	return c.install(c.Shell, !c.NoRC)
+}
+
+// detectShell returns the name of the user's shell, from $SHELL.
+func detectShell() (string, error) {
+	shell := filepath.Base(getenv("SHELL"))
+
+	switch shell {
+	case "bash", "zsh", "fish":
+		return shell, nil
+	case ".", "":
+		return "", errors.New("unable to detect shell, as $SHELL is not set (use --shell)")
+	default:
+		return "", fmt.Errorf("unsupported shell %q (use --shell)", shell)
+	}
+}
+
+// xdgDir returns the value of the provided XDG environment variable, or the
+// provided directory within the user's home directory.
+func xdgDir(env string, fallback ...string) (string, error) {
+	if dir := getenv(env); dir != "" {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{home}, fallback...)...), nil
+}
+
+// completionPaths returns the paths of the completion script, and the startup
+// file which should source it (empty if the shell loads it automatically).
+func completionPaths(shell, name string) (script, rc string, err error) {
+	if shell == "fish" {
+		config, err := xdgDir("XDG_CONFIG_HOME", ".config")
+		if err != nil {
+			return "", "", err
+		}
+
+		return filepath.Join(config, "fish", "completions", name+".fish"), "", nil
+	}
+
+	data, err := xdgDir("XDG_DATA_HOME", ".local", "share")
+	if err != nil {
+		return "", "", err
+	}
+
+	script = filepath.Join(data, name, "completion."+shell)
+
+	home := getenv("ZDOTDIR")
+	if shell == "bash" || home == "" {
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return script, filepath.Join(home, "."+shell+"rc"), nil
+}
+
+// addSourceLine idempotently adds a line sourcing script to the provided
+// startup file, writing a backup of the original first. Returns false if the
+// file already sources the script.
+func addSourceLine(rc, name, script string) (bool, error) {
+	source := fmt.Sprintf("[ -f %[1]s ] && source %[1]s", ShellQuote(script))
+
+	data, err := os.ReadFile(rc)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == source {
+			return false, nil
+		}
+	}
+
+	if data != nil {
+		if err = writeFileAtomic(rc+".bak", data, 0o600); err != nil {
+			return false, fmt.Errorf("failed to back up %s: %w", rc, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(data)
+
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&buf, "\n# %s shell completion (added by %q).\n%s\n", name, name+" completion install", source)
+
+	return true, writeFileAtomic(rc, buf.Bytes(), 0o644)
+}
+
+// installCompletion installs the completion script for the provided shell (or
+// the detected shell), printing what was changed to stdout.
+func (cli *CLI[T]) installCompletion(shell string, rc bool) error {
+	var err error
+
+	if shell == "" {
+		if shell, err = detectShell(); err != nil {
+			return err
+		}
+	}
+
+	m := cli.DocModel()
+
+	script, rcFile, err := completionPaths(shell, m.Name)
+	if err != nil {
+		return fmt.Errorf("failed to resolve completion paths: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err = m.Shell(&buf, shell+"-completion"); err != nil {
+		return err
+	}
+
+	if _, err = mkdir(filepath.Dir(script)); err != nil {
+		return fmt.Errorf("failed to create completion directory: %w", err)
+	}
+
+	if err = writeFileAtomic(script, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write completion script: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "installed %s completion to %s\n", shell, script)
+
+	if rcFile == "" {
+		return nil
+	}
+
+	if !rc {
+		fmt.Fprintf(os.Stdout, "add the following to %s to enable it:\n  source %s\n", rcFile, ShellQuote(script))
+		return nil
+	}
+
+	added, err := addSourceLine(rcFile, m.Name, script)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", rcFile, err)
+	}
+
+	if added {
+		fmt.Fprintf(os.Stdout, "updated %s (backup at %s), restart your shell to enable it\n", rcFile, rcFile+".bak")
+	} else {
+		fmt.Fprintf(os.Stdout, "%s already sources the completion script\n", rcFile)
+	}
+
+	return nil
+}
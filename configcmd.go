@@ -0,0 +1,358 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/joho/godotenv"
+)
+
+// DotEnvFile is the configuration file loaded on startup (unless
+// OptDisableDotEnv is set), and managed by the "config" command (see
+// WithConfigCommand).
+const DotEnvFile = ".env"
+
+// WithConfigCommand adds a "config" command, with "get", "set" and "unset"
+// sub-commands, which manage the values of flags in the .env file (see
+// DotEnvFile). Keys are flag names (e.g. "log.level") or environment variables
+// (e.g. "LOG_LEVEL"), and values are validated against the flag's type and
+// choices before being written. Comments, ordering and unrelated lines in the
+// file are preserved. Values of secret flags (see RedactArgs) are encrypted
+// with a key stored in the keyring (see CLI.Keyring), and decrypted when the
+// file is loaded, so the file never contains them in plaintext. If no keyring
+// is available, "config set" refuses to write them. Conflicts with
+// OptDisableDotEnv.
+func WithConfigCommand() Option {
+	return func(s *settings) error {
+		if s.options&OptDisableDotEnv != 0 {
+			return errors.New("WithConfigCommand: conflicts with OptDisableDotEnv")
+		}
+
+		s.configCommand = true
+		return nil
+	}
+}
+
+// configCommand is the "config" command, registered with WithConfigCommand.
+type configCommand struct {
+	Get   configGetCommand   `command:"get" description:"print configured values, of all or the provided keys"`
+	Set   configSetCommand   `command:"set" description:"set a configuration value (KEY VALUE)"`
+	Unset configUnsetCommand `command:"unset" description:"remove configuration values (KEY...)"`
+}
+
+// configGetCommand is the "config get" command.
+type configGetCommand struct {
+	run func(keys []string) error
+}
+
+// Execute implements flags.Commander.
+func (c *configGetCommand) Execute(args []string) error {
+	return c.run(args)
+}
+
+// configSetCommand is the "config set" command.
+type configSetCommand struct {
+	run func(key, value string) error
+}
+
+// Execute implements flags.Commander.
+func (c *configSetCommand) Execute(args []string) error {
+	if len(args) != 2 {
+		return errors.New("expected a key and a value")
+	}
+
+	return c.run(args[0], args[1])
+}
+
+// configUnsetCommand is the "config unset" command.
+type configUnsetCommand struct {
+	run func(keys []string) error
+}
+
+// Execute implements flags.Commander.
+func (c *configUnsetCommand) Execute(args []string) error {
+	if len(args) == 0 {
+		return errors.New("expected one or more keys")
+	}
+
+	return c.run(args)
+}
+
+// configOption returns the option (with an environment variable) matching the
+// provided key, which is either a flag name or an environment variable.
+func (cli *CLI[T]) configOption(key string) (*flags.Option, error) {
+	name := strings.TrimPrefix(key, "--")
+
+	var found *flags.Option
+
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		if found != nil || option.EnvKeyWithNamespace() == "" {
+			return
+		}
+
+		if option.EnvKeyWithNamespace() == key || (option.LongName != "" && option.LongNameWithNamespace() == name) {
+			found = option
+		}
+	})
+
+	if found == nil {
+		return nil, fmt.Errorf("unknown configuration key %q", key)
+	}
+
+	return found, nil
+}
+
+// validateConfigValue validates the provided value, as it would be provided
+// through the option's environment variable.
+func validateConfigValue(option *flags.Option, value string) error {
+	if len(option.Choices) > 0 && !slices.Contains(option.Choices, value) {
+		return fmt.Errorf("expected one of: %s", strings.Join(option.Choices, ", "))
+	}
+
+	t := reflect.TypeOf(option.Value())
+
+	if t.Kind() == reflect.Bool {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("expected a boolean (true or false)")
+		}
+		return nil
+	}
+
+	values := []string{value}
+	if option.EnvDefaultDelim != "" && (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) {
+		values = strings.Split(value, option.EnvDefaultDelim)
+	}
+
+	for _, v := range values {
+		if err := validateOptionValue(option, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dotEnvKey returns the key of the provided .env line, or an empty string if
+// it's empty or a comment.
+func dotEnvKey(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	line = strings.TrimPrefix(line, "export ")
+
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(line[:i])
+}
+
+// readDotEnv reads the .env file, returning its lines and parsed values. If
+// the file doesn't exist, an error wrapping ErrConfigNotFound is returned,
+// along with empty values, so callers can treat it as empty.
+func (cli *CLI[T]) readDotEnv() (lines []string, env map[string]string, err error) {
+	data, err := readFileLimit(DotEnvFile, limit(cli.settings.maxConfigFileSize, DefaultMaxConfigFileSize))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, map[string]string{}, fmt.Errorf("%w: %s", ErrConfigNotFound, DotEnvFile)
+		}
+		return nil, nil, &ConfigError{File: DotEnvFile, Err: err}
+	}
+
+	env, err = godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, nil, &ConfigError{File: DotEnvFile, Line: dotEnvErrorLine(data), Err: err}
+	}
+
+	lines = strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines, env, nil
+}
+
+// writeDotEnv atomically writes the provided lines to the .env file, keeping
+// its permissions (or 0600 for new files), after verifying they still parse.
+func writeDotEnv(lines []string) error {
+	data := []byte(strings.Join(lines, ""))
+
+	if _, err := godotenv.UnmarshalBytes(data); err != nil {
+		return fmt.Errorf("refusing to write %s, as the result doesn't parse: %w", DotEnvFile, err)
+	}
+
+	return writeFileAtomic(DotEnvFile, data, 0o600)
+}
+
+// configGet prints the values of the provided keys (or all keys of flags) in
+// the .env file.
+func (cli *CLI[T]) configGet(keys []string) error {
+	_, env, err := cli.readDotEnv()
+	if err != nil && (len(keys) > 0 || !errors.Is(err, ErrConfigNotFound)) {
+		return err
+	}
+
+	var options []*flags.Option
+
+	if len(keys) == 0 {
+		walkOptions(cli.Parser.Command, func(option *flags.Option) {
+			if _, ok := env[option.EnvKeyWithNamespace()]; ok {
+				options = append(options, option)
+			}
+		})
+	}
+
+	for _, key := range keys {
+		option, err := cli.configOption(key)
+		if err != nil {
+			return err
+		}
+		options = append(options, option)
+	}
+
+	for _, option := range options {
+		key := option.EnvKeyWithNamespace()
+
+		value, ok := env[key]
+		if !ok {
+			if len(keys) > 0 {
+				return fmt.Errorf("%s is not set in %s", key, DotEnvFile)
+			}
+			continue
+		}
+
+		switch {
+		case (isSecretOption(option) || isEncryptedValue(value)) && !cli.Reveal:
+			value = Redacted
+		case isEncryptedValue(value):
+			value, err = cli.openConfigValue(key, value)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt %s: %w", key, err)
+			}
+		}
+
+		if len(keys) == 1 {
+			fmt.Fprintln(os.Stdout, value)
+			continue
+		}
+
+		fmt.Fprintf(os.Stdout, "%s=%s\n", key, value)
+	}
+
+	return nil
+}
+
+// configSet sets the value of the provided key in the .env file, replacing
+// the existing assignment (if any), or appending it.
+func (cli *CLI[T]) configSet(key, value string) error {
+	option, err := cli.configOption(key)
+	if err != nil {
+		return err
+	}
+
+	if err = validateConfigValue(option, value); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+
+	envKey := option.EnvKeyWithNamespace()
+
+	if isSecretOption(option) {
+		value, err = cli.sealConfigValue(envKey, value)
+		if err != nil {
+			return fmt.Errorf("refusing to store secret %s in plaintext: %w", envKey, err)
+		}
+	}
+
+	assignment, err := godotenv.Marshal(map[string]string{envKey: value})
+	if err != nil {
+		return err
+	}
+	assignment += "\n"
+
+	lines, _, err := cli.readDotEnv()
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return err
+	}
+
+	var replaced bool
+	out := make([]string, 0, len(lines)+1)
+
+	for _, line := range lines {
+		if dotEnvKey(line) != envKey {
+			out = append(out, line)
+			continue
+		}
+
+		// Replace the first assignment, and remove any duplicates.
+		if !replaced {
+			out = append(out, assignment)
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		if n := len(out); n > 0 && !strings.HasSuffix(out[n-1], "\n") {
+			out[n-1] += "\n"
+		}
+		out = append(out, assignment)
+	}
+
+	return writeDotEnv(out)
+}
+
+// configUnset removes the provided keys from the .env file.
+func (cli *CLI[T]) configUnset(keys []string) error {
+	remove := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		option, err := cli.configOption(key)
+		if err != nil {
+			return err
+		}
+		remove[option.EnvKeyWithNamespace()] = true
+	}
+
+	lines, _, err := cli.readDotEnv()
+	if errors.Is(err, ErrConfigNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !remove[dotEnvKey(line)] {
+			out = append(out, line)
+		}
+	}
+
+	if len(out) == len(lines) {
+		return nil
+	}
+
+	return writeDotEnv(out)
+}
+
+// configCommandData returns the "config" command, with its sub-commands bound
+// to the CLI.
+func (cli *CLI[T]) configCommandData() *configCommand {
+	return &configCommand{
+		Get:   configGetCommand{run: cli.configGet},
+		Set:   configSetCommand{run: cli.configSet},
+		Unset: configUnsetCommand{run: cli.configUnset},
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+type configFlags struct {
+	Name  string `long:"name" env:"NAME" description:"name"`
+	Mode  string `long:"mode" env:"MODE" choice:"fast" choice:"slow" description:"mode"`
+	Token string `long:"token" env:"TOKEN" description:"token"`
+}
+
+// chdirTemp changes the working directory to a temporary directory, for the
+// duration of the test.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	return dir
+}
+
+func TestConfigSetUnset(t *testing.T) {
+	dir := chdirTemp(t)
+
+	const initial = "# leading comment\nNAME=before\n\n# mode comment\nMODE=fast\nOTHER=kept # trailing\n"
+
+	if err := os.WriteFile(clix.DotEnvFile, []byte(initial), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cli := &clix.CLI[configFlags]{}
+	if err := cli.Apply(clix.WithConfigCommand(), clix.WithKeyring(clix.FileKeyring(filepath.Join(dir, "keyring.json")))); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) *clixtest.RunResult {
+		t.Helper()
+
+		// Values loaded from the .env file by previous runs aren't overridden,
+		// so they have to be cleared.
+		for _, key := range []string{"NAME", "MODE", "TOKEN", "OTHER"} {
+			t.Setenv(key, "")
+			os.Unsetenv(key)
+		}
+
+		res := clixtest.Run(t, cli, args, nil)
+		if res.Err != nil && !errors.Is(res.Err, clix.ErrBuiltin) {
+			t.Fatalf("%v: unexpected error: %v (stderr: %s)", args, res.Err, res.Stderr)
+		}
+
+		if res.ExitCode != 0 {
+			t.Fatalf("%v: unexpected exit code %d", args, res.ExitCode)
+		}
+		return res
+	}
+
+	read := func() string {
+		t.Helper()
+
+		b, err := os.ReadFile(clix.DotEnvFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	run("config", "set", "NAME", "after")
+	run("config", "set", "mode", "slow")
+	run("config", "set", "token", "hunter22")
+
+	got := read()
+
+	want := "# leading comment\nNAME=\"after\"\n\n# mode comment\nMODE=\"slow\"\nOTHER=kept # trailing\nTOKEN="
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("unexpected file after set:\n%s\nwant prefix:\n%s", got, want)
+	}
+
+	if strings.Contains(got, "hunter22") {
+		t.Fatalf("secret value written in plaintext:\n%s", got)
+	}
+
+	if res := run("config", "get", "TOKEN"); strings.TrimSpace(res.Stdout) != clix.Redacted {
+		t.Fatalf("expected redacted token, got %q", res.Stdout)
+	}
+
+	if res := run("config", "get", "--reveal", "TOKEN"); strings.TrimSpace(res.Stdout) != "hunter22" {
+		t.Fatalf("expected revealed token, got %q", res.Stdout)
+	}
+
+	run()
+	if cli.Flags.Token != "hunter22" || cli.Flags.Name != "after" || cli.Flags.Mode != "slow" {
+		t.Fatalf("unexpected flags loaded from %s: %+v", clix.DotEnvFile, *cli.Flags)
+	}
+
+	run("config", "unset", "TOKEN", "name")
+
+	want = "# leading comment\n\n# mode comment\nMODE=\"slow\"\nOTHER=kept # trailing\n"
+	if got = read(); got != want {
+		t.Fatalf("unexpected file after unset:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConfigSetInvalid(t *testing.T) {
+	chdirTemp(t)
+
+	cli := &clix.CLI[configFlags]{}
+	if err := cli.Apply(clix.WithConfigCommand()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown-key", args: []string{"config", "set", "UNKNOWN", "value"}},
+		{name: "invalid-choice", args: []string{"config", "set", "MODE", "medium"}},
+		{name: "missing-value", args: []string{"config", "set", "NAME"}},
+		{name: "unset-nothing", args: []string{"config", "unset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := clixtest.Run(t, cli, tt.args, nil); res.Err == nil || errors.Is(res.Err, clix.ErrBuiltin) {
+				t.Fatalf("expected error, got none (stdout: %s)", res.Stdout)
+			}
+
+			if _, err := os.Stat(clix.DotEnvFile); !os.IsNotExist(err) {
+				t.Fatalf("expected %s to not be written", clix.DotEnvFile)
+			}
+		})
+	}
+}
+
+func TestConfigNotFound(t *testing.T) {
+	chdirTemp(t)
+
+	cli := &clix.CLI[configFlags]{}
+	if err := cli.Apply(clix.WithConfigCommand()); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := clixtest.Run(t, cli, []string{"config", "get", "NAME"}, nil); !errors.Is(res.Err, clix.ErrConfigNotFound) {
+		t.Fatalf("expected ErrConfigNotFound, got %v", res.Err)
+	}
+
+	// Without keys, and when unsetting, a missing file is treated as empty.
+	for _, args := range [][]string{{"config", "get"}, {"config", "unset", "NAME"}} {
+		if res := clixtest.Run(t, cli, args, nil); res.ExitCode != 0 {
+			t.Fatalf("%v: unexpected error: %v", args, res.Err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// encryptedValuePrefix is the prefix of encrypted configuration values. The
+// remainder is the base64 (raw URL encoding) of the AES-GCM nonce and sealed
+// value.
+const encryptedValuePrefix = "clix:enc:v1:"
+
+// configKeyEntry is the name of the keyring entry (see CLI.Keyring) which
+// holds the key used to encrypt configuration values.
+const configKeyEntry = "config-encryption-key"
+
+// isEncryptedValue returns true if the provided configuration value was
+// encrypted with sealConfigValue.
+func isEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// configCipher returns the AEAD used to encrypt configuration values, using
+// the key stored in the keyring. If create is true, and the keyring has no
+// key, a new random key is generated and stored.
+func (cli *CLI[T]) configCipher(create bool) (cipher.AEAD, error) {
+	keyring := cli.Keyring()
+
+	encoded, err := keyring.Get(configKeyEntry)
+
+	switch {
+	case errors.Is(err, ErrKeyringNotFound) && create:
+		key := make([]byte, 32)
+		if _, err = rand.Read(key); err != nil {
+			return nil, err
+		}
+
+		encoded = base64.StdEncoding.EncodeToString(key)
+
+		if err = keyring.Set(configKeyEntry, encoded); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to read configuration key: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid configuration key in keyring entry %q", configKeyEntry)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealConfigValue encrypts the provided configuration value of the provided
+// environment variable, using the key stored in the keyring (which is created
+// if needed). The variable name is authenticated with the value, so encrypted
+// values can't be moved to other variables.
+func (cli *CLI[T]) sealConfigValue(key, value string) (string, error) {
+	aead, err := cli.configCipher(true)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(key))
+
+	return encryptedValuePrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// openConfigValue decrypts the provided configuration value of the provided
+// environment variable, if it was encrypted with sealConfigValue. Other values
+// are returned as-is.
+func (cli *CLI[T]) openConfigValue(key, value string) (string, error) {
+	if !isEncryptedValue(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, encryptedValuePrefix))
+	if err != nil {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	aead, err := cli.configCipher(false)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", errors.New("failed to decrypt value (was the keyring entry replaced, or the value moved?)")
+	}
+
+	return string(plain), nil
+}
+
+// decryptDotEnv decrypts the encrypted values loaded from .env files (see
+// loadDotEnv), in place, so they're resolved as regular environment variables.
+// The keyring is only accessed if there are encrypted values.
+func (cli *CLI[T]) decryptDotEnv() error {
+	var err error
+
+	dotEnvSources.Range(func(k, file any) bool {
+		value := os.Getenv(k.(string))
+		if !isEncryptedValue(value) {
+			return true
+		}
+
+		var plain string
+
+		plain, err = cli.openConfigValue(k.(string), value)
+		if err != nil {
+			err = &ConfigError{File: file.(string), Err: fmt.Errorf("failed to decrypt %s: %w", k, err)}
+			return false
+		}
+
+		_ = os.Setenv(k.(string), plain)
+		return true
+	})
+
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// ConsoleInfo describes the capabilities of a console stream.
+type ConsoleInfo struct {
+	// Terminal is true if the stream is a terminal.
+	Terminal bool
+
+	// VirtualTerminal is true if the stream supports ANSI escape sequences.
+	// On Windows, clix enables virtual terminal processing on the console
+	// where possible (Windows 10+). Older Windows consoles don't support it,
+	// in which case colors are disabled by default (see ColorEnabled).
+	VirtualTerminal bool
+}
+
+var (
+	consoleMu    sync.Mutex
+	consoleCache = map[uintptr]ConsoleInfo{}
+)
+
+// Console returns the detected capabilities of the provided stream (e.g.
+// os.Stdout or os.Stderr). On Windows, the first call for a console stream
+// attempts to enable virtual terminal processing. Results are cached.
+func Console(f *os.File) ConsoleInfo {
+	if f == nil {
+		return ConsoleInfo{}
+	}
+
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+
+	if info, ok := consoleCache[f.Fd()]; ok {
+		return info
+	}
+
+	info := ConsoleInfo{Terminal: term.IsTerminal(int(f.Fd()))}
+	info.VirtualTerminal = enableVirtualTerminal(f, info.Terminal)
+
+	consoleCache[f.Fd()] = info
+	return info
+}
+
+// ansiRegex matches ANSI escape sequences (CSI and OSC).
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// stripANSI removes all ANSI escape sequences from the provided string.
+func stripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+// ansiStripWriter is an io.Writer which removes ANSI escape sequences, for
+// writing to consoles which don't support them. Writes are expected to contain
+// complete sequences (which is the case for log handlers).
+type ansiStripWriter struct {
+	w io.Writer
+}
+
+func (w *ansiStripWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, stripANSI(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// consoleWriter returns w, wrapped to strip ANSI escape sequences if w is a
+// stream which color is disabled for (see ColorEnabled).
+func consoleWriter(w io.Writer) io.Writer {
+	if f, ok := w.(*os.File); ok && !ColorEnabled(f) {
+		return &ansiStripWriter{w: w}
+	}
+	return w
+}
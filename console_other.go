@@ -0,0 +1,15 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build !windows
+
+package clix
+
+import "os"
+
+// enableVirtualTerminal is a no-op on non-Windows platforms, where terminals
+// support ANSI escape sequences natively.
+func enableVirtualTerminal(_ *os.File, _ bool) bool {
+	return true
+}
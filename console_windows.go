@@ -0,0 +1,36 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build windows
+
+package clix
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal enables virtual terminal processing (ANSI escape
+// sequences) on the provided console, returning false if it isn't supported
+// (e.g. consoles prior to Windows 10). Non-console streams (pipes, files) are
+// passed through untouched, and are assumed to support escape sequences.
+func enableVirtualTerminal(f *os.File, terminal bool) bool {
+	if !terminal {
+		return true
+	}
+
+	handle := windows.Handle(f.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return false
+	}
+
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+
+	return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
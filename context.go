@@ -0,0 +1,87 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+
+	"github.com/apex/log"
+)
+
+// contextKey is the type of context keys used by clix, which prevents
+// collisions with keys defined in other packages.
+type contextKey int
+
+const (
+	contextLogger contextKey = iota
+	contextVersion
+	contextFlags
+	contextProcesses
+	contextClock
+	contextRand
+	contextInvocationID
+)
+
+// Context returns a copy of parent which carries the CLI's logger, version
+// information, flags, clock, random number generator and invocation ID, which
+// can be retrieved with LoggerFrom, VersionFrom, FlagsFrom, ClockFrom, RandFrom
+// and InvocationIDFrom,
+// so deeply nested code can access them without passing the CLI around. The context passed to middleware and commands (see
+// UseMiddleware) is populated automatically. Must be called after Parse().
+//
+// Example:
+//
+//	err := clix.RunCtx(cli.Context(context.Background()), httpServer)
+func (cli *CLI[T]) Context(parent context.Context) context.Context {
+	cli.mu.Lock()
+	logger := cli.Logger
+	cli.mu.Unlock()
+
+	ctx := parent
+
+	if logger != nil {
+		ctx = context.WithValue(ctx, contextLogger, log.Interface(logger))
+	}
+
+	if cli.VersionInfo != nil {
+		ctx = context.WithValue(ctx, contextVersion, cli.VersionInfo)
+	}
+
+	if cli.Flags != nil {
+		ctx = context.WithValue(ctx, contextFlags, cli.Flags)
+	}
+
+	ctx = context.WithValue(ctx, contextProcesses, cli.recordProcess)
+	ctx = context.WithValue(ctx, contextClock, cli.Clock())
+	ctx = context.WithValue(ctx, contextRand, cli.Rand())
+	ctx = context.WithValue(ctx, contextInvocationID, cli.InvocationID())
+
+	return ctx
+}
+
+// LoggerFrom returns the logger carried by the provided context (see
+// CLI.Context), or the global apex/log logger if there is none.
+func LoggerFrom(ctx context.Context) log.Interface {
+	if logger, ok := ctx.Value(contextLogger).(log.Interface); ok {
+		return logger
+	}
+
+	return log.Log
+}
+
+// VersionFrom returns the version information carried by the provided context
+// (see CLI.Context), or nil if there is none (or it belongs to a CLI with a
+// different flags type).
+func VersionFrom[T any](ctx context.Context) *VersionInfo[T] {
+	v, _ := ctx.Value(contextVersion).(*VersionInfo[T])
+	return v
+}
+
+// FlagsFrom returns the flags carried by the provided context (see
+// CLI.Context), or nil if there are none (or they're of a different type).
+func FlagsFrom[T any](ctx context.Context) *T {
+	flags, _ := ctx.Value(contextFlags).(*T)
+	return flags
+}
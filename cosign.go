@@ -0,0 +1,81 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidSignature is returned when an artifact doesn't match its signature
+// (see VerifyCosignBlob).
+var ErrInvalidSignature = errors.New("clix: artifact signature is invalid")
+
+// VerifyCosignBlob verifies the signature of an artifact (e.g. a downloaded
+// release binary, before replacing the running one), as produced by
+// "cosign sign-blob --key", against the pinned public key (PEM encoded, as
+// written by "cosign generate-key-pair"). The signature may be base64 encoded
+// (cosign's default output) or raw. ECDSA and RSA keys are supported. Keyless
+// (Fulcio certificate and Rekor transparency log) verification isn't
+// supported. Returns an error matching ErrInvalidSignature if the signature
+// doesn't match.
+//
+// Example:
+//
+//	//go:embed cosign.pub
+//	var releaseKey []byte
+//	[...]
+//	f, _ := os.Open(downloaded)
+//	defer f.Close()
+//	if err := clix.VerifyCosignBlob(f, sig, releaseKey); err != nil {
+//		return fmt.Errorf("refusing to install update: %w", err)
+//	}
+func VerifyCosignBlob(artifact io.Reader, signature, publicKeyPEM []byte) error {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return errors.New("invalid public key: no PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+
+	signature = bytes.TrimSpace(signature)
+	if sig, err := base64.StdEncoding.DecodeString(string(signature)); err == nil {
+		signature = sig
+	}
+
+	h := sha256.New()
+	if _, err = io.Copy(h, artifact); err != nil {
+		return fmt.Errorf("failed to read artifact: %w", err)
+	}
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, signature) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature) != nil &&
+			rsa.VerifyPSS(key, crypto.SHA256, digest, signature, nil) != nil {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+
+	return nil
+}
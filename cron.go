@@ -0,0 +1,234 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when something should be invoked.
+type Schedule interface {
+	// Next returns the next activation time, after the provided time.
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule parses the provided schedule, which can be either:
+//   - a standard 5-field cron expression (minute, hour, day of month, month,
+//     day of week), e.g. "*/5 * * * *" or "0 9 * * MON-FRI".
+//   - a descriptor, i.e. "@yearly", "@monthly", "@weekly", "@daily", or
+//     "@hourly".
+//   - an interval, e.g. "5m" or "@every 5m".
+//
+// Cron expressions are evaluated in the local timezone.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		spec = strings.TrimSpace(d)
+	}
+
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", spec)
+		}
+		return intervalSchedule(d), nil
+	}
+
+	if expr, ok := cronDescriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected an interval or 5 cron fields, got %d", spec, len(fields))
+	}
+
+	s := &cronSchedule{}
+	var err error
+
+	for i, f := range cronFields {
+		if s.fields[i], err = parseCronField(fields[i], f); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s field: %w", spec, f.name, err)
+		}
+	}
+
+	// Sunday can be provided as either 0 or 7.
+	if s.fields[4]&(1<<7) != 0 {
+		s.fields[4] |= 1
+	}
+
+	s.domAny = strings.HasPrefix(fields[2], "*")
+	s.dowAny = strings.HasPrefix(fields[4], "*")
+
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, errNoActivation)
+	}
+
+	return s, nil
+}
+
+// intervalSchedule is a schedule which activates on a fixed interval.
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// errNoActivation is used when a schedule never activates (e.g. "0 0 31 2 *").
+var errNoActivation = errors.New("schedule never activates")
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Names for values, starting at min.
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{
+		"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC",
+	}},
+	{name: "day of week", min: 0, max: 7, names: []string{
+		"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT",
+	}},
+}
+
+// cronSchedule is a schedule parsed from a cron expression, where each field
+// is a bitmask of the matching values.
+type cronSchedule struct {
+	fields [5]uint64
+
+	// domAny and dowAny are true if the day of month or day of week are
+	// unrestricted. If both are restricted, either matching is sufficient
+	// (matching the behavior of cron).
+	domAny, dowAny bool
+}
+
+// parseCronField parses a single cron field (e.g. "*/5", "1-5", "MON,WED").
+func parseCronField(spec string, f cronField) (uint64, error) {
+	var mask uint64
+
+	for _, part := range strings.Split(spec, ",") {
+		rng, stepSpec, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepSpec)
+			}
+		}
+
+		start, end := f.min, f.max
+
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if start, err = f.value(from); err != nil {
+				return 0, err
+			}
+
+			end = start
+			if isRange {
+				if end, err = f.value(to); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = f.max
+			}
+
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			mask |= 1 << v
+		}
+	}
+
+	return mask, nil
+}
+
+// value parses a single value (number or name) of the field.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d]", v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// matchDay returns true if the day of the provided time matches the schedule.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.fields[2]&(1<<t.Day()) != 0
+	dow := s.fields[4]&(1<<t.Weekday()) != 0
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the next matching minute after the provided time, or the zero
+// time if the schedule never matches.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Searching more than 5 years ahead means the schedule can never match
+	// (e.g. February 31st).
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.fields[3]&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.fields[1]&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.fields[0]&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
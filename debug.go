@@ -0,0 +1,96 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// debugAll is the debug category which enables debugging for all categories.
+const debugAll = "all"
+
+// normalizeDebug splits, trims and lowercases the provided debug categories.
+// Boolean values (e.g. DEBUG=true) are treated as all categories, and false
+// values are dropped, for compatibility with the previous boolean flag.
+func normalizeDebug(values []string) []string {
+	var categories []string
+
+	for _, value := range values {
+		for _, category := range strings.Split(value, ",") {
+			category = strings.ToLower(strings.TrimSpace(category))
+
+			switch category {
+			case "", "false", "0", "no", "off":
+				continue
+			case "true", "1", "yes", "on", "*":
+				category = debugAll
+			}
+
+			if !slices.Contains(categories, category) {
+				categories = append(categories, category)
+			}
+		}
+	}
+
+	return categories
+}
+
+// DebugEnabled returns true if debugging is enabled for the provided category
+// (e.g. "http"), either explicitly (--debug=http) or through all categories
+// (--debug, or Debug being set). An empty category only returns true when
+// debugging is enabled for all categories.
+//
+// Example:
+//
+//	if cli.DebugEnabled("sql") {
+//		db.LogQueries(true)
+//	}
+func (cli *CLI[T]) DebugEnabled(category string) bool {
+	if cli.Debug {
+		return true
+	}
+
+	category = strings.ToLower(category)
+
+	for _, c := range normalizeDebug(cli.DebugCategories) {
+		if c == debugAll || (category != "" && c == category) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ComponentLogger returns a logger for the provided component (e.g. "http"),
+// which includes a "component" field. The log level of the component is
+// resolved from the component log level override (--log.component), if any,
+// otherwise the CLI's log level is used. Debug logging is always enabled if
+// debugging is enabled for the component (see DebugEnabled), so debugging can
+// be enabled for specific components, without flooding all other components.
+func (cli *CLI[T]) ComponentLogger(component string) *log.Entry {
+	cli.mu.Lock()
+	base := cli.Logger
+	cli.mu.Unlock()
+
+	if base == nil {
+		return log.WithField("component", component)
+	}
+
+	logger := &log.Logger{Handler: base.Handler, Level: base.Level}
+
+	levels, _ := cli.LoggerConfig.componentLevels()
+	if level, ok := levels[strings.ToLower(component)]; ok {
+		logger.Level = level
+	}
+
+	if cli.DebugEnabled(component) {
+		logger.Level = log.DebugLevel
+	}
+
+	return logger.WithField("component", component)
+}
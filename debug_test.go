@@ -0,0 +1,67 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeDebug(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "empty", values: nil, want: nil},
+		{name: "bare-flag", values: []string{"all"}, want: []string{"all"}},
+		{name: "bool-true", values: []string{"true"}, want: []string{"all"}},
+		{name: "bool-false", values: []string{"false"}, want: nil},
+		{name: "wildcard", values: []string{"*"}, want: []string{"all"}},
+		{name: "comma-separated", values: []string{"http, SQL"}, want: []string{"http", "sql"}},
+		{name: "repeated", values: []string{"http", "sql,http"}, want: []string{"http", "sql"}},
+		{name: "empty-parts", values: []string{",http,,"}, want: []string{"http"}},
+		{name: "mixed", values: []string{"off", "on", "http"}, want: []string{"all", "http"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDebug(tt.values); !slices.Equal(got, tt.want) {
+				t.Fatalf("normalizeDebug(%q) = %q, want %q", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		debug      bool
+		categories []string
+		category   string
+		want       bool
+	}{
+		{name: "disabled", category: "http", want: false},
+		{name: "disabled-global", category: "", want: false},
+		{name: "all", categories: []string{"all"}, category: "http", want: true},
+		{name: "all-global", categories: []string{"all"}, category: "", want: true},
+		{name: "category", categories: []string{"http"}, category: "http", want: true},
+		{name: "category-case", categories: []string{"HTTP"}, category: "Http", want: true},
+		{name: "other-category", categories: []string{"http"}, category: "sql", want: false},
+		{name: "category-not-global", categories: []string{"http"}, category: "", want: false},
+		{name: "unnormalized", categories: []string{"sql,http"}, category: "http", want: true},
+		{name: "debug-bool", debug: true, category: "http", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := &CLI[struct{}]{Debug: tt.debug, DebugCategories: tt.categories}
+
+			if got := cli.DebugEnabled(tt.category); got != tt.want {
+				t.Fatalf("DebugEnabled(%q) = %v, want %v", tt.category, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// DefaultDeployBaseImage is the base image used by generated Dockerfiles, when
+// DeployOptions.BaseImage isn't provided.
+const DefaultDeployBaseImage = "gcr.io/distroless/static-debian12:nonroot"
+
+// DeployOptions configures the generated systemd unit and Dockerfile (see
+// CLI.SystemdUnit and CLI.Dockerfile). All fields are optional.
+type DeployOptions struct {
+	// Name is the service name. Defaults to AppName.
+	Name string
+
+	// Description defaults to the first paragraph of CLI.Description.
+	Description string
+
+	// Binary is the path the binary is installed to. Defaults to
+	// "/usr/local/bin/<name>".
+	Binary string
+
+	// Args are the arguments the service is started with. Defaults to the
+	// sub-command which declares a ListenerConfig, if any.
+	Args []string
+
+	// User is the user the systemd service runs as. Defaults to a dynamic
+	// user (DynamicUser=yes).
+	User string
+
+	// BaseImage is the base image of the Dockerfile. Defaults to
+	// DefaultDeployBaseImage.
+	BaseImage string
+}
+
+// deployListener is a ListenerConfig registered with the parser.
+type deployListener struct {
+	command  string // Path of the command declaring the listener.
+	envKey   string
+	listen   []string
+	shutdown time.Duration
+}
+
+// deployListeners returns the ListenerConfigs registered with the parser,
+// identified by the tags of their options.
+func deployListeners(p *flags.Parser) []deployListener {
+	listenField, _ := reflect.TypeOf(ListenerConfig{}).FieldByName("Listen")
+	shutdownField, _ := reflect.TypeOf(ListenerConfig{}).FieldByName("ShutdownTimeout")
+
+	var listeners []deployListener
+
+	var walkGroup func(path string, group *flags.Group)
+	walkGroup = func(path string, group *flags.Group) {
+		var l *deployListener
+
+		for _, option := range group.Options() {
+			switch option.Field().Tag {
+			case listenField.Tag:
+				listen, _ := option.Value().([]string)
+				l = &deployListener{
+					command: path,
+					envKey:  option.EnvKeyWithNamespace(),
+					listen:  listen,
+				}
+			case shutdownField.Tag:
+				if l != nil {
+					l.shutdown, _ = option.Value().(time.Duration)
+				}
+			}
+		}
+
+		if l != nil {
+			listeners = append(listeners, *l)
+		}
+
+		for _, g := range group.Groups() {
+			walkGroup(path, g)
+		}
+	}
+
+	var walkCommand func(path string, cmd *flags.Command)
+	walkCommand = func(path string, cmd *flags.Command) {
+		walkGroup(path, cmd.Group)
+
+		for _, sub := range cmd.Commands() {
+			walkCommand(strings.TrimSpace(path+" "+sub.Name), sub)
+		}
+	}
+
+	walkCommand("", p.Command)
+
+	return listeners
+}
+
+// deployConfig is DeployOptions, with defaults applied, and the information
+// derived from the parser.
+type deployConfig struct {
+	DeployOptions
+	app       string
+	homepage  string
+	cache     bool
+	listeners []deployListener
+	ports     []int
+	unix      bool
+	shutdown  time.Duration
+}
+
+// deployConfig returns DeployOptions, with defaults applied.
+func (cli *CLI[T]) deployConfig() *deployConfig {
+	p := cli.Parser
+	if p == nil {
+		p, _ = cli.newParser()
+	}
+
+	c := &deployConfig{
+		DeployOptions: cli.DeployOptions,
+		app:           cli.AppName(),
+		cache:         cli.settings.cache,
+		listeners:     deployListeners(p),
+	}
+
+	if c.Name == "" {
+		c.Name = c.app
+	}
+
+	if c.Description == "" {
+		c.Description = packageDescription(cli.Description)
+	}
+
+	if c.Description == "" {
+		c.Description = c.Name
+	}
+
+	if c.Binary == "" {
+		c.Binary = "/usr/local/bin/" + c.Name
+	}
+
+	if c.BaseImage == "" {
+		c.BaseImage = DefaultDeployBaseImage
+	}
+
+	if l, ok := FindLink(cli.VersionInfo.Links, LinkWebsite); ok {
+		c.homepage = l.URL
+	} else if l, ok = FindLink(cli.VersionInfo.Links, LinkDocs); ok {
+		c.homepage = l.URL
+	}
+
+	for _, l := range c.listeners {
+		if c.Args == nil && l.command != "" {
+			c.Args = strings.Fields(l.command)
+		}
+
+		c.shutdown = max(c.shutdown, l.shutdown)
+
+		for _, addr := range l.listen {
+			if strings.HasPrefix(addr, "unix:") {
+				c.unix = true
+				continue
+			}
+
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				continue
+			}
+
+			if n, err := strconv.Atoi(port); err == nil && n > 0 && !slices.Contains(c.ports, n) {
+				c.ports = append(c.ports, n)
+			}
+		}
+	}
+
+	slices.Sort(c.ports)
+
+	return c
+}
+
+// SystemdUnit writes a hardened systemd service unit to out, using
+// DeployOptions, and the registered flags: listen addresses (and shutdown
+// timeouts) of ListenerConfigs, and the state and cache directories (see
+// StateDir and CacheDir), which are provided by systemd. Listen addresses are
+// set through their environment variables, so they can be changed with a
+// drop-in. Must be called after Parse().
+func (cli *CLI[T]) SystemdUnit(out io.Writer) error {
+	c := cli.deployConfig()
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "[Unit]\n")
+	fmt.Fprintf(&buf, "Description=%s\n", c.Description)
+	if c.homepage != "" {
+		fmt.Fprintf(&buf, "Documentation=%s\n", c.homepage)
+	}
+	fmt.Fprintf(&buf, "After=network-online.target\n")
+	fmt.Fprintf(&buf, "Wants=network-online.target\n")
+
+	fmt.Fprintf(&buf, "\n[Service]\n")
+	fmt.Fprintf(&buf, "Type=simple\n")
+	fmt.Fprintf(&buf, "ExecStart=%s\n", strings.Join(append([]string{c.Binary}, quoteArgs(c.Args)...), " "))
+	fmt.Fprintf(&buf, "Restart=on-failure\n")
+	fmt.Fprintf(&buf, "RestartSec=5s\n")
+
+	if c.shutdown > 0 {
+		fmt.Fprintf(&buf, "TimeoutStopSec=%s\n", (c.shutdown + 5*time.Second).String())
+	}
+
+	for _, l := range c.listeners {
+		if l.envKey != "" && len(l.listen) > 0 {
+			fmt.Fprintf(&buf, "Environment=%s\n", strconv.Quote(l.envKey+"="+strings.Join(l.listen, ",")))
+		}
+	}
+
+	if c.User != "" {
+		fmt.Fprintf(&buf, "User=%s\n", c.User)
+	} else {
+		fmt.Fprintf(&buf, "DynamicUser=yes\n")
+	}
+
+	fmt.Fprintf(&buf, "Environment=XDG_STATE_HOME=/var/lib\n")
+	fmt.Fprintf(&buf, "StateDirectory=%s\n", c.app)
+
+	if c.cache {
+		fmt.Fprintf(&buf, "Environment=XDG_CACHE_HOME=/var/cache\n")
+		fmt.Fprintf(&buf, "CacheDirectory=%s\n", c.app)
+	}
+
+	if c.unix {
+		fmt.Fprintf(&buf, "RuntimeDirectory=%s\n", c.app)
+	}
+
+	fmt.Fprintf(&buf, "\n# Hardening (see systemd-analyze security %s.service).\n", c.Name)
+
+	if slices.ContainsFunc(c.ports, func(p int) bool { return p < 1024 }) {
+		fmt.Fprintf(&buf, "AmbientCapabilities=CAP_NET_BIND_SERVICE\n")
+		fmt.Fprintf(&buf, "CapabilityBoundingSet=CAP_NET_BIND_SERVICE\n")
+	} else {
+		fmt.Fprintf(&buf, "CapabilityBoundingSet=\n")
+	}
+
+	for _, directive := range []string{
+		"NoNewPrivileges=yes",
+		"ProtectSystem=strict",
+		"ProtectHome=yes",
+		"PrivateTmp=yes",
+		"PrivateDevices=yes",
+		"ProtectClock=yes",
+		"ProtectHostname=yes",
+		"ProtectKernelTunables=yes",
+		"ProtectKernelModules=yes",
+		"ProtectKernelLogs=yes",
+		"ProtectControlGroups=yes",
+		"RestrictAddressFamilies=AF_INET AF_INET6 AF_UNIX",
+		"RestrictNamespaces=yes",
+		"RestrictRealtime=yes",
+		"RestrictSUIDSGID=yes",
+		"LockPersonality=yes",
+		"MemoryDenyWriteExecute=yes",
+		"SystemCallArchitectures=native",
+		"SystemCallFilter=@system-service",
+		"SystemCallFilter=~@privileged @resources",
+		"UMask=0077",
+	} {
+		fmt.Fprintln(&buf, directive)
+	}
+
+	fmt.Fprintf(&buf, "\n[Install]\n")
+	fmt.Fprintf(&buf, "WantedBy=multi-user.target\n")
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// Dockerfile writes a minimal Dockerfile snippet to out, which copies the
+// binary into a non-root base image (see DeployOptions.BaseImage), using the
+// same listen addresses, state and cache directories as SystemdUnit, exposing
+// the ports of TCP listen addresses. Must be called after Parse().
+func (cli *CLI[T]) Dockerfile(out io.Writer) error {
+	c := cli.deployConfig()
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "FROM %s\n", c.BaseImage)
+	fmt.Fprintf(&buf, "COPY %s %s\n", c.Name, c.Binary)
+
+	env := []string{"XDG_STATE_HOME=/var/lib"}
+	if c.cache {
+		env = append(env, "XDG_CACHE_HOME=/var/cache")
+	}
+
+	for _, l := range c.listeners {
+		if l.envKey != "" && len(l.listen) > 0 {
+			env = append(env, l.envKey+"="+strconv.Quote(strings.Join(l.listen, ",")))
+		}
+	}
+
+	fmt.Fprintf(&buf, "ENV %s\n", strings.Join(env, " \\\n    "))
+	fmt.Fprintf(&buf, "VOLUME [%s]\n", strconv.Quote("/var/lib/"+c.app))
+
+	if len(c.ports) > 0 {
+		ports := make([]string, len(c.ports))
+		for i, p := range c.ports {
+			ports[i] = strconv.Itoa(p)
+		}
+		fmt.Fprintf(&buf, "EXPOSE %s\n", strings.Join(ports, " "))
+	}
+
+	entrypoint := make([]string, 0, len(c.Args)+1)
+	for _, arg := range append([]string{c.Binary}, c.Args...) {
+		entrypoint = append(entrypoint, strconv.Quote(arg))
+	}
+
+	fmt.Fprintf(&buf, "ENTRYPOINT [%s]\n", strings.Join(entrypoint, ", "))
+
+	_, err := buf.WriteTo(out)
+	return err
+}
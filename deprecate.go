@@ -0,0 +1,161 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Deprecation describes the deprecation of a command. See
+// WithDeprecatedCommand.
+type Deprecation struct {
+	// Message is shown with the deprecation warning, typically describing
+	// what to use instead (e.g. "use 'deploy' instead").
+	Message string
+
+	// Sunset is the (optional) date after which the command is retired.
+	Sunset time.Time
+
+	// Enforce fails invocations of the command after the sunset date (and
+	// hides it from help, documentation and completions), rather than only
+	// warning.
+	Enforce bool
+}
+
+// commandDeprecation is a Deprecation for a specific command.
+type commandDeprecation struct {
+	path string
+	Deprecation
+}
+
+// WithDeprecatedCommand marks the command with the provided path (e.g.
+// "deploy" or "cluster create", including any sub-commands) as deprecated.
+// Invocations log a warning (see Warn) including the message and sunset date,
+// and the command is annotated in help, generated documentation and
+// completions. With Enforce, invocations fail once the sunset date has passed
+// (based on CLI.Clock, so it can be tested with --frozen-time).
+//
+// Example:
+//
+//	cli.Apply(clix.WithDeprecatedCommand("deploy-legacy", clix.Deprecation{
+//		Message: "use 'deploy' instead",
+//		Sunset:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+//		Enforce: true,
+//	}))
+func WithDeprecatedCommand(path string, d Deprecation) Option {
+	return func(s *settings) error {
+		path = strings.Join(strings.Fields(path), " ")
+		if path == "" {
+			return errors.New("WithDeprecatedCommand: command path is empty")
+		}
+
+		for _, c := range s.deprecations {
+			if c.path == path {
+				return fmt.Errorf("WithDeprecatedCommand: command %q already deprecated", path)
+			}
+		}
+
+		s.deprecations = append(s.deprecations, commandDeprecation{path: path, Deprecation: d})
+		return nil
+	}
+}
+
+// retired returns true if the sunset date has passed, and is enforced.
+func (d *Deprecation) retired(now time.Time) bool {
+	return d.Enforce && !d.Sunset.IsZero() && !now.Before(d.Sunset)
+}
+
+// summary returns a short description of the deprecation.
+func (d *Deprecation) summary() string {
+	s := "deprecated"
+	if !d.Sunset.IsZero() {
+		s += ", removed after " + d.Sunset.Format(time.DateOnly)
+	}
+
+	if d.Message != "" {
+		s += ": " + d.Message
+	}
+
+	return s
+}
+
+// findCommand returns the command with the provided (space-separated) path,
+// or nil if it doesn't exist.
+func findCommand(p *flags.Parser, path string) *flags.Command {
+	cmd := p.Command
+
+	for _, name := range strings.Fields(path) {
+		if cmd = cmd.Find(name); cmd == nil {
+			return nil
+		}
+	}
+
+	return cmd
+}
+
+// applyDeprecations annotates deprecated commands (see WithDeprecatedCommand)
+// in the parser, hiding those which are retired.
+func (cli *CLI[T]) applyDeprecations(p *flags.Parser) error {
+	now := cli.Clock().Now()
+
+	for _, d := range cli.settings.deprecations {
+		cmd := findCommand(p, d.path)
+		if cmd == nil {
+			return fmt.Errorf("WithDeprecatedCommand: unknown command %q", d.path)
+		}
+
+		if d.retired(now) {
+			cmd.Hidden = true
+			continue
+		}
+
+		summary := d.summary()
+
+		cmd.ShortDescription = strings.TrimSpace(cmd.ShortDescription + " (" + summary + ")")
+		cmd.LongDescription = strings.TrimSpace(strings.ToUpper(summary[:1]) + summary[1:] + ".\n\n" + cmd.LongDescription)
+	}
+
+	return nil
+}
+
+// checkDeprecation warns if the active command (or any of its parents) is
+// deprecated, or returns an error if it's retired.
+func (cli *CLI[T]) checkDeprecation(path string) error {
+	now := cli.Clock().Now()
+
+	for _, d := range cli.settings.deprecations {
+		if path != d.path && !strings.HasPrefix(path, d.path+" ") {
+			continue
+		}
+
+		if d.retired(now) {
+			msg := fmt.Sprintf("command %q was retired on %s", d.path, d.Sunset.Format(time.DateOnly))
+			if d.Message != "" {
+				msg += ": " + d.Message
+			}
+			return errors.New(msg)
+		}
+
+		fields := log.Fields{"command": d.path}
+		if !d.Sunset.IsZero() {
+			fields["sunset"] = d.Sunset.Format(time.DateOnly)
+		}
+
+		msg := fmt.Sprintf("command %q is deprecated", d.path)
+		if d.Message != "" {
+			msg += ": " + d.Message
+		}
+
+		cli.Warn(msg, fields)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+package clix
+
+import "errors"
+
+// diskFree is unsupported on this platform.
+func diskFree(_ string) (uint64, error) {
+	return 0, errors.New("disk space checks are not supported on this platform")
+}
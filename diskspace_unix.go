@@ -0,0 +1,20 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build linux || darwin || freebsd || dragonfly
+
+package clix
+
+import "golang.org/x/sys/unix"
+
+// diskFree returns the free space (available to unprivileged users) of the
+// filesystem containing the provided path, in bytes.
+func diskFree(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), nil //nolint:gosec
+}
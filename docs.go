@@ -0,0 +1,306 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// DocModel is the documentation model of a CLI, which all documentation
+// formats (e.g. markdown) are rendered from. It can be generated from any
+// go-flags parser, using ModelFromParser, so legacy go-flags projects can
+// generate the same output as clix CLIs.
+type DocModel struct {
+	Name             string        `json:"name"`
+	Version          string        `json:"version,omitempty"`
+	ShortDescription string        `json:"short_description,omitempty"`
+	LongDescription  string        `json:"long_description,omitempty"`
+	Groups           []*DocGroup   `json:"groups,omitempty"`
+	Commands         []*DocCommand `json:"commands,omitempty"`
+	Meta             *DocsMeta     `json:"meta,omitempty"`
+}
+
+// DocsMeta is application-provided documentation metadata, which isn't
+// derived from flags or commands, rendered by all documentation formats (e.g.
+// as the EXIT STATUS, ENVIRONMENT, FILES and SEE ALSO sections of man pages).
+type DocsMeta struct {
+	// ExitCodes are the exit codes of the application.
+	ExitCodes []DocExitCode `json:"exit_codes,omitempty"`
+
+	// Environment are environment variables used by the application, in
+	// addition to those of flags (which are documented automatically).
+	Environment []DocEnv `json:"environment,omitempty"`
+
+	// Files are the files used by the application (e.g. configuration files).
+	Files []DocFile `json:"files,omitempty"`
+
+	// SeeAlso are references to related documentation, e.g. "git(1)" or a URL.
+	SeeAlso []string `json:"see_also,omitempty"`
+}
+
+// DocExitCode is a documented exit code.
+type DocExitCode struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+}
+
+// DocEnv is a documented environment variable.
+type DocEnv struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// DocFile is a documented file.
+type DocFile struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// empty returns true if no metadata is provided.
+func (d *DocsMeta) empty() bool {
+	return len(d.ExitCodes) == 0 && len(d.Environment) == 0 && len(d.Files) == 0 && len(d.SeeAlso) == 0
+}
+
+// DocGroup is a group of options, and any sub-groups.
+type DocGroup struct {
+	Name    string       `json:"name,omitempty"`
+	Options []*DocOption `json:"options,omitempty"`
+	Groups  []*DocGroup  `json:"groups,omitempty"`
+}
+
+// DocOption is a single option (flag).
+type DocOption struct {
+	Short       string   `json:"short,omitempty"`
+	Long        string   `json:"long,omitempty"`
+	Flag        string   `json:"flag"`
+	Env         string   `json:"env,omitempty"`
+	EnvDelim    string   `json:"env_delim,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Default     []string `json:"default,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+
+	// Secret is true if the option is a secret (see RedactArgs).
+	Secret bool `json:"secret,omitempty"`
+
+	// KubernetesSource is how the option's environment variable should be
+	// sourced when deployed to Kubernetes (see EnvReference), and FieldPath is
+	// the Downward API field path, for KubernetesSourceDownwardAPI.
+	KubernetesSource KubernetesSource `json:"kubernetes_source,omitempty"`
+	FieldPath        string           `json:"field_path,omitempty"`
+}
+
+// DocCommand is a command (or sub-command), including its options and any
+// sub-commands.
+type DocCommand struct {
+	Name             string        `json:"name"`
+	Path             string        `json:"path"`
+	Aliases          []string      `json:"aliases,omitempty"`
+	ShortDescription string        `json:"short_description,omitempty"`
+	LongDescription  string        `json:"long_description,omitempty"`
+	Options          []*DocOption  `json:"options,omitempty"`
+	Groups           []*DocGroup   `json:"groups,omitempty"`
+	Commands         []*DocCommand `json:"commands,omitempty"`
+}
+
+// DocModel returns the documentation model for the CLI. Commands are sorted
+// using VersionOptions.SortKey (by name, matching the order used in help
+// output, unless SortNone is used), while options and groups retain the order
+// in which they were defined. The model is extracted in a
+// single pass, and should be reused when rendering multiple formats.
+func (cli *CLI[T]) DocModel() *DocModel {
+	// Reuse the parser once parsed (e.g. for --generate-markdown), rather than
+	// re-reflecting the flags and commands.
+	p := cli.Parser
+	if p == nil {
+		// Commands which fail to be added are excluded, and the error is
+		// surfaced when parsing.
+		p, _ = cli.newParser()
+	}
+
+	m := ModelFromParser(p)
+	m.Sort(cli.VersionOptions.SortKey)
+
+	// The parser's long description is rendered for the terminal (or is the
+	// version information), so use the original markdown.
+	m.LongDescription = cli.Description
+
+	if cli.VersionInfo != nil {
+		m.Version = cli.VersionInfo.Version
+	}
+
+	if !cli.DocsMeta.empty() {
+		meta := cli.DocsMeta
+		m.Meta = &meta
+	}
+
+	return m
+}
+
+// EncodeJSON writes the documentation model as (indented) JSON to the provided
+// io.Writer. This is the output of --help-json.
+func (m *DocModel) EncodeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// helpJSONArg returns true if --help-json was provided, before any "--".
+func helpJSONArg(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--help-json":
+			return true
+		}
+	}
+	return false
+}
+
+// Sort sorts all commands (recursively) using the provided sort key, so
+// generated documentation is stable regardless of command registration order.
+// Options and groups are not sorted, as their order is defined by the struct
+// they were generated from. SortByVersion is treated as SortByName.
+func (m *DocModel) Sort(key SortKey) {
+	if key == SortNone {
+		return
+	}
+
+	sortDocCommands(m.Commands)
+}
+
+func sortDocCommands(commands []*DocCommand) {
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	for _, cmd := range commands {
+		sortDocCommands(cmd.Commands)
+	}
+}
+
+// ModelFromParser converts the provided go-flags parser into a documentation
+// model. Hidden options, groups, and commands are excluded.
+func ModelFromParser(parser *flags.Parser) *DocModel {
+	return &DocModel{
+		Name:             parser.Name,
+		ShortDescription: parser.ShortDescription,
+		LongDescription:  parser.LongDescription,
+		Groups:           docGroups(parser.Groups()),
+		Commands:         docCommands("", parser.Commands()),
+	}
+}
+
+// EnvDefault returns the default value of the option, as it would be provided
+// through its environment variable.
+func (o *DocOption) EnvDefault() string {
+	delim := o.EnvDelim
+	if delim == "" {
+		delim = ","
+	}
+
+	return strings.Join(o.Default, delim)
+}
+
+func docGroups(groups []*flags.Group) []*DocGroup {
+	out := make([]*DocGroup, 0, len(groups))
+
+	for _, group := range groups {
+		if group.Hidden {
+			continue
+		}
+
+		dg := &DocGroup{Name: group.LongDescription}
+		if dg.Name == "" {
+			dg.Name = group.ShortDescription
+		}
+
+		dg.Options = docOptions(group.Options())
+		dg.Groups = docGroups(group.Groups())
+		out = append(out, dg)
+	}
+
+	return out
+}
+
+func docOptions(options []*flags.Option) []*DocOption {
+	out := make([]*DocOption, 0, len(options))
+
+	for _, option := range options {
+		if option.Hidden {
+			continue
+		}
+
+		out = append(out, docOption(option))
+	}
+
+	return out
+}
+
+func docOption(option *flags.Option) *DocOption {
+	do := &DocOption{
+		Long:        option.LongNameWithNamespace(),
+		Flag:        option.String(),
+		Env:         option.EnvKeyWithNamespace(),
+		EnvDelim:    option.EnvDefaultDelim,
+		Type:        fmt.Sprintf("%T", option.Value()),
+		Description: option.Description,
+		Required:    option.Required,
+		Default:     option.Default,
+		Choices:     option.Choices,
+	}
+
+	if option.ShortName != 0 {
+		do.Short = string(option.ShortName)
+	}
+
+	do.Secret = isSecretOption(option)
+
+	if do.Env != "" {
+		do.KubernetesSource, do.FieldPath = kubernetesSource(option)
+	}
+
+	if strings.Contains(strings.ToLower(do.Type), "func") {
+		do.Type = ""
+	}
+
+	return do
+}
+
+func docCommands(parent string, commands []*flags.Command) []*DocCommand {
+	out := make([]*DocCommand, 0, len(commands))
+
+	for _, cmd := range commands {
+		if cmd.Hidden {
+			continue
+		}
+
+		path := cmd.Name
+		if parent != "" {
+			path = parent + " " + cmd.Name
+		}
+
+		out = append(out, &DocCommand{
+			Name:             cmd.Name,
+			Path:             path,
+			Aliases:          cmd.Aliases,
+			ShortDescription: cmd.ShortDescription,
+			LongDescription:  cmd.LongDescription,
+			Options:          docOptions(cmd.Options()),
+			Groups:           docGroups(cmd.Groups()),
+			Commands:         docCommands(path, cmd.Commands()),
+		})
+	}
+
+	return out
+}
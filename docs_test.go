@@ -0,0 +1,102 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+// benchCommandFlags are the flags of each command of the CLI returned by
+// newBenchCLI.
+type benchCommandFlags struct {
+	Name     string   `long:"name" env:"NAME" description:"name of the resource"`
+	Region   string   `long:"region" env:"REGION" default:"us-east-1" description:"region of the resource"`
+	Output   string   `long:"output" short:"o" choice:"text" choice:"json" choice:"yaml" default:"text" description:"output format"`
+	Labels   []string `long:"label" env:"LABELS" env-delim:"," description:"labels to apply (repeatable)"`
+	Timeout  string   `long:"timeout" default:"30s" description:"maximum time to wait"`
+	Force    bool     `long:"force" short:"f" description:"skip confirmation"`
+	Replicas int      `long:"replicas" default:"1" description:"number of replicas"`
+	Token    string   `long:"token" env:"TOKEN" description:"api token"`
+}
+
+// newBenchCLI returns a CLI with 80 commands of 8 flags each (640 flags), to
+// benchmark documentation generation for very large CLIs.
+func newBenchCLI(b *testing.B, parsed bool) *CLI[struct{}] {
+	b.Helper()
+
+	cli := &CLI[struct{}]{Flags: &struct{}{}}
+	cli.VersionInfo = cli.GetVersionInfo()
+
+	for i := range 80 {
+		cli.AddCommand(fmt.Sprintf("command-%02d", i), &benchCommandFlags{}, fmt.Sprintf("runs command %d", i))
+	}
+
+	if parsed {
+		var err error
+		if cli.Parser, err = cli.newParser(); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return cli
+}
+
+func BenchmarkDocModel(b *testing.B) {
+	for _, parsed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("parsed=%v", parsed), func(b *testing.B) {
+			cli := newBenchCLI(b, parsed)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				cli.DocModel()
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateMarkdown(b *testing.B) {
+	cli := newBenchCLI(b, true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cli.DocModel().Markdown(io.Discard)
+	}
+}
+
+func TestDocModelSortKey(t *testing.T) {
+	for _, tt := range []struct {
+		key  SortKey
+		want []string
+	}{
+		{key: "", want: []string{"apply", "delete", "get"}},
+		{key: SortByName, want: []string{"apply", "delete", "get"}},
+		{key: SortNone, want: []string{"get", "apply", "delete"}},
+	} {
+		t.Run(string(tt.key), func(t *testing.T) {
+			cli := &CLI[struct{}]{Flags: &struct{}{}, VersionOptions: VersionOptions{SortKey: tt.key}}
+			cli.VersionInfo = cli.GetVersionInfo()
+
+			for _, name := range []string{"get", "apply", "delete"} {
+				cli.AddCommand(name, &benchCommandFlags{}, name)
+			}
+
+			var got []string
+			for _, cmd := range cli.DocModel().Commands {
+				got = append(got, cmd.Name)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("unexpected order %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
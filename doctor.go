@@ -0,0 +1,225 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultCheckTimeout is the default maximum duration of each doctor check.
+// See DoctorCheck.
+const DefaultCheckTimeout = 10 * time.Second
+
+// WithDoctor registers the provided diagnostic checks, and enables the
+// built-in "doctor" command, which runs them (in order), printing whether each
+// passed, warned or failed (with remediation hints), and exits non-zero if any
+// failed. Can be provided multiple times, to register additional checks.
+//
+// Example:
+//
+//	cli.Apply(clix.WithDoctor(
+//		clix.CheckBinary("git", "install git from https://git-scm.com/downloads"),
+//		clix.CheckDiskSpace(os.TempDir(), 1<<30, "free up space in the temp directory"),
+//		clix.DoctorCheck{
+//			Name: "config is valid",
+//			Hint: "see --generate-markdown for the supported flags",
+//			Run: func(ctx context.Context) error {
+//				return validateConfig(cli.Flags)
+//			},
+//		},
+//	))
+func WithDoctor(checks ...DoctorCheck) Option {
+	return func(s *settings) error {
+		if len(checks) == 0 {
+			return errors.New("WithDoctor: no checks provided")
+		}
+
+		for _, c := range checks {
+			if c.Name == "" || c.Run == nil {
+				return errors.New("WithDoctor: checks must have a name and run function")
+			}
+		}
+
+		s.doctorChecks = append(s.doctorChecks, checks...)
+		return nil
+	}
+}
+
+// DoctorCheck is a diagnostic check, run by the "doctor" command (see
+// WithDoctor).
+type DoctorCheck struct {
+	// Name is a short description of what is checked (e.g. "git is
+	// installed").
+	Name string
+
+	// Hint is a remediation hint, shown if the check fails or warns. Can be
+	// overridden by returning a CheckError.
+	Hint string
+
+	// Warn only warns when the check fails (e.g. for optional dependencies),
+	// rather than failing the doctor command.
+	Warn bool
+
+	// Timeout is the maximum duration of the check, after which its context
+	// is cancelled. Defaults to DefaultCheckTimeout.
+	Timeout time.Duration
+
+	// Run runs the check, returning an error if it failed.
+	Run func(ctx context.Context) error
+}
+
+// CheckError can be returned by DoctorCheck.Run, to provide a remediation hint
+// specific to the failure, or to only warn about it.
+type CheckError struct {
+	Err     error
+	Hint    string
+	Warning bool
+}
+
+// Error implements the error interface.
+func (e *CheckError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// CheckBinary returns a check that the provided binary is in PATH.
+func CheckBinary(name, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: name + " is installed",
+		Hint: hint,
+		Run: func(_ context.Context) error {
+			_, err := exec.LookPath(name)
+			return err
+		},
+	}
+}
+
+// CheckPort returns a check that the provided TCP address (host:port) is
+// reachable. DNS overrides are applied (see NetworkConfig).
+func CheckPort(addr, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: addr + " is reachable",
+		Hint: hint,
+		Run: func(ctx context.Context) error {
+			conn, err := DefaultNetwork().DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			return conn.Close()
+		},
+	}
+}
+
+// CheckDiskSpace returns a check that the filesystem containing the provided
+// path has at least minFree bytes free.
+func CheckDiskSpace(path string, minFree uint64, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: fmt.Sprintf("%s has at least %s free", path, formatBytes(minFree)),
+		Hint: hint,
+		Run: func(_ context.Context) error {
+			free, err := diskFree(path)
+			if err != nil {
+				return err
+			}
+
+			if free < minFree {
+				return fmt.Errorf("only %s free", formatBytes(free))
+			}
+
+			return nil
+		},
+	}
+}
+
+// doctorCommand is the "doctor" command, registered with WithDoctor.
+type doctorCommand struct {
+	run func() error
+}
+
+// Execute implements flags.Commander.
+func (c *doctorCommand) Execute(_ []string) error {
+	return c.run()
+}
+
+// runDoctor runs all registered checks, printing the results to stdout, and
+// returns an error if any failed.
+func (cli *CLI[T]) runDoctor() error {
+	ctx := cli.Context(context.Background())
+
+	printf := func(format string, args ...any) {
+		fmt.Fprint(os.Stdout, colorize(os.Stdout, fmt.Sprintf(format, args...)))
+	}
+
+	var passed, warned, failed int
+
+	for _, check := range cli.settings.doctorChecks {
+		err := runCheck(ctx, check)
+
+		hint, warn := check.Hint, check.Warn
+
+		var cerr *CheckError
+		if errors.As(err, &cerr) {
+			if cerr.Hint != "" {
+				hint = cerr.Hint
+			}
+			warn = warn || cerr.Warning
+		}
+
+		switch {
+		case err == nil:
+			passed++
+			printf("<greenB>PASS</>  %s\n", check.Name)
+			continue
+		case warn:
+			warned++
+			printf("<yellowB>WARN</>  %s: %v\n", check.Name, err)
+		default:
+			failed++
+			printf("<redB>FAIL</>  %s: %v\n", check.Name, err)
+		}
+
+		if hint != "" {
+			printf("      <gray>hint: %s</>\n", strings.ReplaceAll(hint, "\n", "\n      "))
+		}
+	}
+
+	printf("\n%d passed, %d warnings, %d failed\n", passed, warned, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(cli.settings.doctorChecks))
+	}
+
+	return nil
+}
+
+// runCheck runs the provided check with its timeout, recovering from panics.
+func runCheck(ctx context.Context, check DoctorCheck) (err error) {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+
+	return check.Run(ctx)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// DryParse parses and validates the provided arguments (excluding the program
+// name) and environment variables, against a fresh copy of the CLI's flags and
+// commands, without side effects: the CLI itself isn't modified, commands
+// aren't executed, the process environment isn't read, nothing is printed,
+// no files are read or written (e.g. .env files), the logger isn't
+// initialized, and the process never exits. This makes it suitable for fuzz
+// testing the flag surface. Parse errors are returned as described in
+// ParseWithInit (with OptNoExit), and help requests return ErrHelp.
+//
+// Example:
+//
+//	func FuzzParse(f *testing.F) {
+//		f.Add("--port", "8080")
+//		f.Fuzz(func(t *testing.T, a, b string) {
+//			_ = cli.DryParse([]string{a, b}, nil)
+//		})
+//	}
+func (cli *CLI[T]) DryParse(args []string, env map[string]string) error {
+	dry := &CLI[T]{
+		Flags:       new(T),
+		VersionInfo: &VersionInfo[T]{},
+	}
+
+	cli.mu.Lock()
+	dry.options = cli.options
+	dry.settings = cli.settings
+	dry.features = cli.features
+
+	for _, c := range cli.commands {
+		// Parse into a copy of the command, so the original isn't modified.
+		data := c.data
+		if v := reflect.ValueOf(c.data); v.Kind() == reflect.Pointer && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			clone := reflect.New(v.Elem().Type())
+			clone.Elem().Set(v.Elem())
+			data = clone.Interface()
+		}
+
+		dry.commands = append(dry.commands, &command{name: c.name, help: c.help, data: data})
+	}
+
+	for _, m := range cli.mounts {
+		dry.mounts = append(dry.mounts, &mount{name: m.name, description: m.description})
+	}
+	cli.mu.Unlock()
+
+	p, err := dry.newParser()
+	if err != nil {
+		return err
+	}
+
+	p.Options &^= flags.PrintErrors
+	p.CommandHandler = func(_ flags.Commander, _ []string) error {
+		return dry.validateFeatures()
+	}
+
+	// Resolve environment variables from env, rather than the process
+	// environment, by providing them as defaults.
+	walkOptions(p.Command, func(option *flags.Option) {
+		key := option.EnvKeyWithNamespace()
+		option.EnvDefaultKey = ""
+
+		value, ok := env[key]
+		if key == "" || !ok {
+			return
+		}
+
+		if option.EnvDefaultDelim != "" {
+			option.Default = strings.Split(value, option.EnvDefaultDelim)
+		} else {
+			option.Default = []string{value}
+		}
+	})
+
+	args, err = dry.normalizeArgs(p, dry.splitMountArgs(p, args))
+	if err == nil {
+		_, err = p.ParseArgs(args)
+	}
+	if err != nil {
+		var ferr *flags.Error
+		if errors.As(err, &ferr) && ferr.Type == flags.ErrHelp {
+			return ErrHelp
+		}
+
+		return wrapParseError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesSource is how an environment variable should be sourced when
+// deployed to Kubernetes. See DocModel.EnvReference.
+type KubernetesSource string
+
+const (
+	KubernetesSourceConfigMap   KubernetesSource = "configmap"    // Non-secret configuration.
+	KubernetesSourceSecret      KubernetesSource = "secret"       // Secret options (see RedactArgs).
+	KubernetesSourceDownwardAPI KubernetesSource = "downward-api" // Options with a `downward` tag.
+)
+
+// kubernetesSource returns the Kubernetes source of the provided option, and
+// the Downward API field path, if any. Options can declare a Downward API
+// field path with a `downward:"metadata.namespace"` struct tag.
+func kubernetesSource(option *flags.Option) (KubernetesSource, string) {
+	if path := option.Field().Tag.Get("downward"); path != "" {
+		return KubernetesSourceDownwardAPI, path
+	}
+
+	if isSecretOption(option) {
+		return KubernetesSourceSecret, ""
+	}
+
+	return KubernetesSourceConfigMap, ""
+}
+
+// EnvReference writes a deployment-oriented configuration reference (in
+// markdown) to out, listing all environment variables of the CLI, and how they
+// should be sourced in Kubernetes. See DocModel.EnvReference.
+func (cli *CLI[T]) EnvReference(out io.Writer) error {
+	return cli.DocModel().EnvReference(out)
+}
+
+// EnvReference writes a deployment-oriented configuration reference (in
+// markdown) to out, for platform teams. It lists all environment variables,
+// with their default, whether they're required, and whether they should be
+// sourced from a Secret (secret options, see RedactArgs), the Downward API
+// (options with a `downward:"<field path>"` struct tag, e.g.
+// `downward:"metadata.namespace"`), or a ConfigMap (everything else), followed
+// by a matching container "env" stanza.
+//
+// Example:
+//
+//	type Flags struct {
+//		Namespace string `long:"namespace" env:"POD_NAMESPACE" downward:"metadata.namespace" description:"namespace to watch"`
+//	}
+func (m *DocModel) EnvReference(out io.Writer) error {
+	options := m.EnvOptions()
+
+	w := bufio.NewWriter(out)
+
+	fmt.Fprintf(w, "## Configuration reference\n\n")
+	fmt.Fprintf(w, "| Environment variable | Source | Required | Default | Description |\n| --- | --- | --- | --- | --- |\n")
+
+	for _, option := range options {
+		source := "ConfigMap"
+		switch option.KubernetesSource {
+		case KubernetesSourceSecret:
+			source = "Secret"
+		case KubernetesSourceDownwardAPI:
+			source = fmt.Sprintf("Downward API (`%s`)", option.FieldPath)
+		}
+
+		required := "-"
+		if option.Required {
+			required = "yes"
+		}
+
+		def := "-"
+		if len(option.Default) > 0 && !option.Secret {
+			def = "`" + option.EnvDefault() + "`"
+		}
+
+		fmt.Fprintf(
+			w, "| `%s` | %s | %s | %s | %s |\n",
+			option.Env, source, required, markdownCell(def), markdownCell(option.Description),
+		)
+	}
+
+	if len(options) > 0 {
+		var env []k8sEnvVar
+
+		for _, option := range options {
+			ref := &k8sKeyRef{Name: m.Name, Key: option.Env}
+
+			switch option.KubernetesSource {
+			case KubernetesSourceSecret:
+				env = append(env, k8sEnvVar{Name: option.Env, ValueFrom: &k8sEnvSource{SecretKeyRef: ref}})
+			case KubernetesSourceDownwardAPI:
+				env = append(env, k8sEnvVar{Name: option.Env, ValueFrom: &k8sEnvSource{
+					FieldRef: &k8sFieldRef{FieldPath: option.FieldPath},
+				}})
+			default:
+				env = append(env, k8sEnvVar{Name: option.Env, ValueFrom: &k8sEnvSource{ConfigMapKeyRef: ref}})
+			}
+		}
+
+		var stanza bytes.Buffer
+
+		enc := yaml.NewEncoder(&stanza)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(map[string][]k8sEnvVar{"env": env}); err != nil {
+			return fmt.Errorf("failed to encode container env: %w", err)
+		}
+
+		if err := enc.Close(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "\n### Container environment\n\n")
+		fmt.Fprintf(w, "Keys of the ConfigMap and Secret (both named `%s`) match the environment variable names.\n\n", m.Name)
+		fmt.Fprintf(w, "```yaml\n%s```\n", strings.TrimLeft(stanza.String(), "\n"))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,71 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// envUsed are the environment variables which were read (and set) through
+// lookupEnv/getenv.
+var envUsed sync.Map
+
+// lookupEnv is os.LookupEnv, which records variables which are set, for
+// EnvVarsUsed.
+func lookupEnv(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	if ok {
+		envUsed.Store(key, struct{}{})
+	}
+	return v, ok
+}
+
+// getenv is os.Getenv, which records variables which are set, for
+// EnvVarsUsed.
+func getenv(key string) string {
+	v, _ := lookupEnv(key)
+	return v
+}
+
+// EnvVarsUsed returns the sorted names of all environment variables which
+// were set, and consumed, by the CLI: those which provided the value of an
+// option (including those loaded from a .env file), and those read by clix
+// itself (e.g. NO_COLOR, XDG_STATE_HOME, LISTEN_FDS). Variables read by clix
+// itself are tracked process-wide. This can be used in tests to assert there
+// are no unexpected environment dependencies, or to audit the variables
+// required by an environment. Must be called after Parse().
+func (cli *CLI[T]) EnvVarsUsed() []string {
+	used := make(map[string]struct{})
+
+	envUsed.Range(func(key, _ any) bool {
+		used[key.(string)] = struct{}{}
+		return true
+	})
+
+	if cli.Parser != nil {
+		walkOptions(cli.Parser.Command, func(option *flags.Option) {
+			key := option.EnvKeyWithNamespace()
+			if option.EnvDefaultKey == "" || !option.IsSetDefault() {
+				return
+			}
+
+			if _, ok := os.LookupEnv(key); ok {
+				used[key] = struct{}{}
+			}
+		})
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,214 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/joho/godotenv"
+)
+
+// Sentinel errors for failure causes, which can be matched with errors.Is,
+// regardless of the structured error type they're wrapped in.
+var (
+	// ErrConfigNotFound is returned (wrapped) when a configuration file is
+	// required, but doesn't exist (e.g. "config get" with keys, without a .env
+	// file). Files which are optional (e.g. .env on startup) are skipped.
+	ErrConfigNotFound = errors.New("clix: configuration file not found")
+
+	// ErrConfigInvalid is returned (wrapped in a *ConfigError) when a
+	// configuration file (e.g. .env) can't be parsed.
+	ErrConfigInvalid = errors.New("clix: invalid configuration file")
+
+	// ErrFlagValidation is returned (wrapped in a *FlagError) when a flag
+	// fails to parse or validate (e.g. required, invalid choice, invalid
+	// value, or unknown flags).
+	ErrFlagValidation = errors.New("clix: flag validation failed")
+
+	// ErrUpdateCheck is returned (wrapped) when the update check
+	// configuration can't be applied, e.g. an unsupported --update-channel, or
+	// a channel preference which can't be persisted (see WithUpdateChannels).
+	ErrUpdateCheck = errors.New("clix: update check failed")
+)
+
+// ConfigError is returned when a configuration file (e.g. .env) can't be
+// parsed. Matches ErrConfigInvalid with errors.Is.
+type ConfigError struct {
+	// File is the path to the configuration file.
+	File string
+
+	// Line is the line number (1-indexed) of the error, or 0 if unknown.
+	Line int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("invalid configuration file %s (line %d): %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("invalid configuration file %s: %v", e.File, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+func (e *ConfigError) Is(target error) bool { return target == ErrConfigInvalid }
+
+// FlagError is returned when a flag fails to parse or validate. Matches
+// ErrFlagValidation with errors.Is, and the underlying *flags.Error with
+// errors.As.
+type FlagError struct {
+	// Flag is the flag which failed (e.g. "--name"), or empty if unknown.
+	// Unknown flags are reported as provided, without dashes.
+	Flag string
+
+	// Type is the go-flags error type (e.g. flags.ErrRequired).
+	Type flags.ErrorType
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *FlagError) Error() string { return e.Err.Error() }
+
+func (e *FlagError) Unwrap() error { return e.Err }
+
+func (e *FlagError) Is(target error) bool { return target == ErrFlagValidation }
+
+// flagNameRegex matches quoted flag names in go-flags error messages, e.g.
+// "the required flag `-n, --name' was not specified".
+var flagNameRegex = regexp.MustCompile("`(-[^']*)'")
+
+// wrapParseError wraps go-flags errors related to a flag in a *FlagError.
+// Other errors are returned as-is.
+func wrapParseError(err error) error {
+	var ferr *flags.Error
+	if !errors.As(err, &ferr) {
+		return err
+	}
+
+	switch ferr.Type {
+	case flags.ErrRequired, flags.ErrMarshal, flags.ErrInvalidChoice,
+		flags.ErrExpectedArgument, flags.ErrUnknownFlag, flags.ErrNoArgumentForBool,
+		flags.ErrDuplicatedFlag, flags.ErrShortNameTooLong:
+	default:
+		return err
+	}
+
+	var name string
+	if m := flagNameRegex.FindAllStringSubmatch(ferr.Message, -1); len(m) > 0 {
+		// Invalid choice errors quote the value before the flag.
+		match := m[0]
+		if ferr.Type == flags.ErrInvalidChoice {
+			match = m[len(m)-1]
+		}
+
+		// Prefer the long name, e.g. "-n, --name" -> "--name".
+		parts := strings.Split(match[1], ", ")
+		name = parts[len(parts)-1]
+	} else if ferr.Type == flags.ErrUnknownFlag {
+		// Unknown flags are quoted without the dashes.
+		if _, after, ok := strings.Cut(ferr.Message, "`"); ok {
+			name, _, _ = strings.Cut(after, "'")
+		}
+	}
+
+	return &FlagError{Flag: name, Type: ferr.Type, Err: err}
+}
+
+// dotEnvSources maps the environment variables loaded from .env files to the
+// file they were loaded from, and dotEnvFiles records the .env files which
+// were read, for --debug-cli.
+var (
+	dotEnvSources sync.Map
+	dotEnvFiles   sync.Map
+)
+
+// loadDotEnv loads environment variables from the provided files (which
+// aren't overridden if already set), ignoring missing files. Parse errors, and
+// files larger than maxSize bytes (unless negative), are returned as a
+// *ConfigError.
+func loadDotEnv(maxSize int, files ...string) error {
+	for _, file := range files {
+		data, err := readFileLimit(file, maxSize)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if errors.Is(err, ErrConfigLimit) {
+				return &ConfigError{File: file, Err: err}
+			}
+			return fmt.Errorf("failed to load %s file: %w", file, err)
+		}
+
+		env, err := godotenv.UnmarshalBytes(data)
+		if err != nil {
+			return &ConfigError{File: file, Line: dotEnvErrorLine(data), Err: err}
+		}
+
+		dotEnvFiles.Store(file, struct{}{})
+
+		for k, v := range env {
+			if _, ok := os.LookupEnv(k); !ok {
+				_ = os.Setenv(k, v)
+				dotEnvSources.Store(k, file)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readFileLimit reads the provided file, returning an error wrapping
+// ErrConfigLimit if it's larger than maxSize bytes (unless negative).
+func readFileLimit(file string, maxSize int) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if maxSize < 0 {
+		return io.ReadAll(f)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("%w: file is larger than %d bytes", ErrConfigLimit, maxSize)
+	}
+
+	return data, nil
+}
+
+// dotEnvErrorLine returns the line number (1-indexed) of the first line which
+// causes the provided dotenv data to fail to parse, or 0 if unknown. godotenv
+// doesn't report line numbers, so this parses increasingly larger prefixes.
+func dotEnvErrorLine(data []byte) int {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	var prefix []byte
+	for i, line := range lines {
+		prefix = append(prefix, line...)
+
+		if _, err := godotenv.UnmarshalBytes(prefix); err != nil {
+			return i + 1
+		}
+	}
+
+	return 0
+}
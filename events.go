@@ -0,0 +1,125 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventKind is the kind of a lifecycle event. See CLI.Events.
+type EventKind string
+
+// Lifecycle events, in the order they are emitted.
+const (
+	EventConfigLoaded EventKind = "config-loaded" // .env file loaded (if enabled).
+	EventParsed       EventKind = "parsed"        // flags, environment and args parsed.
+	EventLoggerReady  EventKind = "logger-ready"  // logger initialized (if enabled).
+	EventShutdown     EventKind = "shutdown"      // command completed, Finish invoked.
+	EventExit         EventKind = "exit"          // process about to exit.
+)
+
+// Event is a lifecycle event, emitted on the CLI's event bus.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+
+	// Err is the associated error, if any (EventShutdown and EventExit).
+	Err error
+
+	// Code is the exit code (EventExit only).
+	Code int
+
+	// InvocationID is the ID of the run which emitted the event (see
+	// CLI.InvocationID).
+	InvocationID string
+}
+
+// EventBus dispatches lifecycle events to subscribers. Subscribers are invoked
+// synchronously, in the order they subscribed, so they should not block.
+type EventBus struct {
+	mu   sync.RWMutex
+	next int
+	subs []*subscriber
+}
+
+type subscriber struct {
+	id    int
+	kinds []EventKind
+	fn    func(event *Event)
+}
+
+// Subscribe invokes fn for all emitted events of the provided kinds (or all
+// events, if no kinds are provided). The returned function unsubscribes.
+//
+// Example:
+//
+//	cli.Events().Subscribe(func(e *clix.Event) {
+//		tracer.Flush()
+//	}, clix.EventShutdown)
+func (b *EventBus) Subscribe(fn func(event *Event), kinds ...EventKind) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.next++
+	id := b.next
+	b.subs = append(b.subs, &subscriber{id: id, kinds: kinds, fn: fn})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.subs = slices.DeleteFunc(b.subs, func(s *subscriber) bool {
+			return s.id == id
+		})
+	}
+}
+
+// Emit dispatches the provided event to all matching subscribers. If the
+// event has no time, the current time is used.
+func (b *EventBus) Emit(event *Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if len(s.kinds) == 0 || slices.Contains(s.kinds, event.Kind) {
+			s.fn(event)
+		}
+	}
+}
+
+// Events returns the CLI's lifecycle event bus, which can be used to hook into
+// the lifecycle (see the Event* constants) of the CLI. Subscriptions should be
+// made before Parse().
+func (cli *CLI[T]) Events() *EventBus {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.events == nil {
+		cli.events = &EventBus{}
+	}
+
+	return cli.events
+}
+
+// emit emits the provided event, if the event bus has been initialized.
+func (cli *CLI[T]) emit(kind EventKind, code int, err error) {
+	cli.mu.Lock()
+	bus := cli.events
+	id := cli.invocationID
+	cli.mu.Unlock()
+
+	if bus == nil {
+		return
+	}
+
+	bus.Emit(&Event{Kind: kind, Err: err, Code: code, InvocationID: id})
+}
@@ -0,0 +1,283 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"golang.org/x/term"
+)
+
+// DefaultKillGrace is the default time a child process has to exit after being
+// interrupted, before it's killed. See ExecOptions.
+const DefaultKillGrace = 5 * time.Second
+
+// ExecOptions are the options used by Exec.
+type ExecOptions struct {
+	// Dir is the working directory of the process. Defaults to the current
+	// directory.
+	Dir string
+
+	// Env are additional environment variables (KEY=VALUE), added to the
+	// current environment.
+	Env []string
+
+	// Stdin is the input of the process. Defaults to no input (or the
+	// terminal, when using a PTY).
+	Stdin io.Reader
+
+	// Stdout and Stderr optionally receive the output of the process, in
+	// addition to it being logged. When using a PTY, the (combined) output is
+	// only written to Stdout, which defaults to os.Stdout.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Prefix is the value of the "process" field of logged output. Defaults to
+	// the base name of the command.
+	Prefix string
+
+	// Timeout is the maximum duration of the process (0 is no timeout).
+	Timeout time.Duration
+
+	// KillGrace is how long the process has to exit after being interrupted
+	// (when the context is cancelled, or the timeout is reached), before it's
+	// killed. Defaults to DefaultKillGrace.
+	KillGrace time.Duration
+
+	// PTY runs the process in a pseudo-terminal, for interactive programs
+	// (e.g. editors, or programs prompting for passwords). Output isn't logged,
+	// and the terminal is put into raw mode while the process is running.
+	// Only supported on Linux.
+	PTY bool
+}
+
+// ProcessResult is the result of a process invoked with Exec, included in the
+// result envelope (see --result-json).
+type ProcessResult struct {
+	Command    []string `json:"command"`
+	ExitCode   int      `json:"exit_code"`
+	Error      string   `json:"error,omitempty"`
+	TimedOut   bool     `json:"timed_out,omitempty"`
+	Duration   string   `json:"duration"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Exec runs the provided command (name and arguments), streaming its output
+// line-by-line into the logger of the context (see LoggerFrom), stdout at
+// info level and stderr at warn level. When the context is cancelled or the
+// timeout is reached, the process is interrupted, and killed if it hasn't
+// exited within the kill grace period. The exit code is recorded in the result
+// envelope, when the context was provided by the CLI (see CLI.Context). Use
+// ExitCode to get the exit code from the returned error.
+//
+// Example:
+//
+//	err := clix.Exec(ctx, []string{"git", "fetch", "--all"}, clix.ExecOptions{Timeout: time.Minute})
+func Exec(ctx context.Context, command []string, opts ExecOptions) error {
+	if len(command) == 0 {
+		return errors.New("no command provided")
+	}
+
+	if opts.Prefix == "" {
+		opts.Prefix = filepath.Base(command[0])
+	}
+
+	if opts.KillGrace == 0 {
+		opts.KillGrace = DefaultKillGrace
+	}
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	logger := LoggerFrom(ctx).WithField("process", opts.Prefix)
+
+	cmd := exec.CommandContext(runCtx, command[0], command[1:]...) //nolint:gosec
+	cmd.Dir = opts.Dir
+	cmd.WaitDelay = opts.KillGrace
+
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	// Interrupt first, so the process can clean up (not supported on Windows).
+	if runtime.GOOS != "windows" {
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(os.Interrupt)
+		}
+	}
+
+	logger.WithField("args", command[1:]).Debug("starting process")
+	started := time.Now()
+
+	var err error
+	if opts.PTY {
+		err = execPTY(cmd, opts)
+	} else {
+		err = execLogged(cmd, opts, logger)
+	}
+
+	duration := time.Since(started)
+	timedOut := opts.Timeout > 0 && errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+
+	result := ProcessResult{
+		Command:    command,
+		ExitCode:   ExitCode(err),
+		TimedOut:   timedOut,
+		Duration:   duration.String(),
+		DurationMS: duration.Milliseconds(),
+	}
+
+	if err != nil {
+		if timedOut {
+			err = fmt.Errorf("process %q timed out after %s: %w", opts.Prefix, opts.Timeout, err)
+		} else {
+			err = fmt.Errorf("process %q failed: %w", opts.Prefix, err)
+		}
+
+		result.Error = err.Error()
+	}
+
+	if record, ok := ctx.Value(contextProcesses).(func(ProcessResult)); ok {
+		record(result)
+	}
+
+	logger.WithFields(log.Fields{
+		"exit_code": result.ExitCode,
+		"duration":  duration.Round(time.Millisecond),
+	}).Debug("process exited")
+
+	return err
+}
+
+// execLogged runs the provided command, logging its output.
+func execLogged(cmd *exec.Cmd, opts ExecOptions, logger *log.Entry) error {
+	stdout := &lineLogger{fn: logger.WithField("stream", "stdout").Info}
+	stderr := &lineLogger{fn: logger.WithField("stream", "stderr").Warn}
+
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if opts.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(stdout, opts.Stdout)
+	}
+
+	if opts.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(stderr, opts.Stderr)
+	}
+
+	err := cmd.Run()
+
+	stdout.flush()
+	stderr.flush()
+
+	return err
+}
+
+// execPTY runs the provided command in a pseudo-terminal, connected to the
+// current terminal (or the provided input/output).
+func execPTY(cmd *exec.Cmd, opts ExecOptions) error {
+	ptm, pts, err := openPTY()
+	if err != nil {
+		return err
+	}
+	defer ptm.Close()
+
+	cmd.Stdin = pts
+	cmd.Stdout = pts
+	cmd.Stderr = pts
+	setControllingTTY(cmd)
+
+	copyWinsize(os.Stdout, ptm)
+
+	err = cmd.Start()
+	_ = pts.Close()
+	if err != nil {
+		return err
+	}
+
+	stdin := opts.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+
+		if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+			if state, rerr := term.MakeRaw(fd); rerr == nil {
+				defer func() { _ = term.Restore(fd, state) }()
+			}
+		}
+	}
+
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	go func() { _, _ = io.Copy(ptm, stdin) }()
+
+	done := make(chan struct{})
+	go func() {
+		// Reading fails once the child (and any of its children) close the pty.
+		_, _ = io.Copy(stdout, ptm)
+		close(done)
+	}()
+
+	err = cmd.Wait()
+	<-done
+
+	return err
+}
+
+// lineLogger is an io.Writer which invokes fn for each line written.
+type lineLogger struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	fn  func(msg string)
+}
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Write(p)
+
+	for {
+		line, err := l.buf.ReadBytes('\n')
+		if err != nil {
+			// Incomplete line, keep it for the next write.
+			l.buf.Write(line)
+			break
+		}
+
+		l.fn(string(bytes.TrimRight(line, "\r\n")))
+	}
+
+	return len(p), nil
+}
+
+// flush logs any remaining incomplete line.
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buf.Len() > 0 {
+		l.fn(l.buf.String())
+		l.buf.Reset()
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Environment export formats, supported by ExportEnv and --export-env.
+const (
+	ExportDotenv = "dotenv"
+	ExportShell  = "shell"
+)
+
+// EnvValue is the resolved value of an option, as it would be provided
+// through its environment variable.
+type EnvValue struct {
+	Name   string
+	Value  string
+	Secret bool
+}
+
+// ResolvedEnv returns the resolved values of all options which can be
+// configured with an environment variable (including those of groups and
+// commands, but excluding hidden options), in the order they were defined.
+// Must be called after Parse().
+func (cli *CLI[T]) ResolvedEnv() []EnvValue {
+	var values []EnvValue
+
+	if cli.Parser == nil {
+		return values
+	}
+
+	seen := make(map[string]bool)
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		name := option.EnvKeyWithNamespace()
+		if option.EnvDefaultKey == "" || option.Hidden || seen[name] {
+			return
+		}
+
+		seen[name] = true
+		values = append(values, EnvValue{
+			Name:   name,
+			Value:  formatEnvValue(reflect.ValueOf(option.Value()), option.EnvDefaultDelim),
+			Secret: isSecretOption(option),
+		})
+	})
+
+	return values
+}
+
+// ExportEnv writes the resolved configuration (see ResolvedEnv) to out as
+// environment variable assignments, in the provided format (see the Export*
+// constants), so a working configuration can be captured (e.g. into CI
+// variables). Secret values (see RedactArgs) are masked unless reveal is true.
+// Must be called after Parse().
+func (cli *CLI[T]) ExportEnv(out io.Writer, format string, reveal bool) error {
+	if format != ExportDotenv && format != ExportShell {
+		return fmt.Errorf("unknown env export format %q", format)
+	}
+
+	for _, env := range cli.ResolvedEnv() {
+		value := env.Value
+		if env.Secret && !reveal {
+			value = Redacted
+		}
+
+		if format == ExportShell {
+			fmt.Fprintf(out, "export %s=%s\n", env.Name, ShellQuote(value))
+			continue
+		}
+
+		fmt.Fprintf(out, "%s=%s\n", env.Name, dotenvQuote(value))
+	}
+
+	return nil
+}
+
+// dotenvQuote quotes the provided string for use in dotenv files, using double
+// quotes.
+func dotenvQuote(s string) string {
+	return `"` + strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+	).Replace(s) + `"`
+}
+
+// formatEnvValue formats the provided option value as it would be provided
+// through an environment variable, using delim for slices and maps.
+func formatEnvValue(v reflect.Value, delim string) string {
+	if !v.IsValid() {
+		return ""
+	}
+
+	if delim == "" {
+		delim = ","
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.CanInterface() {
+		switch i := v.Interface().(type) {
+		case time.Duration:
+			return i.String()
+		case encoding.TextMarshaler:
+			if b, err := i.MarshalText(); err == nil {
+				return string(b)
+			}
+		case fmt.Stringer:
+			return i.String()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			items = append(items, formatEnvValue(v.Index(i), ""))
+		}
+		return strings.Join(items, delim)
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			items = append(items, formatEnvValue(k, "")+":"+formatEnvValue(v.MapIndex(k), ""))
+		}
+		sort.Strings(items)
+		return strings.Join(items, delim)
+	case reflect.Func:
+		return ""
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// walkOptions invokes fn for all options of the provided command (including
+// its groups and sub-commands), in the order they were defined.
+func walkOptions(cmd *flags.Command, fn func(option *flags.Option)) {
+	var walk func(groups []*flags.Group)
+	walk = func(groups []*flags.Group) {
+		for _, group := range groups {
+			for _, option := range group.Options() {
+				fn(option)
+			}
+
+			walk(group.Groups())
+		}
+	}
+
+	// Options can be defined on the command itself (e.g. sub-commands), as
+	// well as within its groups.
+	walk([]*flags.Group{cmd.Group})
+
+	for _, sub := range cmd.Commands() {
+		walkOptions(sub, fn)
+	}
+}
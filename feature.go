@@ -0,0 +1,187 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Feature sources, as reported by FeatureState.Source.
+const (
+	FeatureSourceDefault  = "default"
+	FeatureSourceProvider = "provider"
+	FeatureSourceOverride = "override" // --feature flag, or FEATURES env var.
+)
+
+// FeatureProvider is an optional (e.g. remote) source of feature flag state.
+// Implementations should return ok=false if they have no opinion about the
+// provided feature, in which case the feature default is used. Implementations
+// are responsible for any caching, as Feature() may be called frequently.
+type FeatureProvider interface {
+	Feature(name string) (enabled, ok bool)
+}
+
+// FeatureState is the resolved state of a declared feature flag.
+type FeatureState struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// feature is a declared feature flag.
+type feature struct {
+	name        string
+	description string
+	enabled     bool
+}
+
+// DeclareFeature declares a feature flag, which can be used to gate
+// experimental behavior. Features can be overridden with the --feature flag
+// (e.g. "--feature name", "--feature name=off", repeatable) or the FEATURES
+// environment variable (comma-separated, same format), or by a FeatureProvider.
+// Overrides take precedence over the provider, which takes precedence over
+// the default. Must be called before Parse().
+//
+// Example:
+//
+//	cli.DeclareFeature("new-scheduler", false, "use the new job scheduler")
+//	cli.Parse()
+//
+//	if cli.Feature("new-scheduler") {
+//		[...]
+//	}
+func (cli *CLI[T]) DeclareFeature(name string, enabled bool, description string) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.features == nil {
+		cli.features = make(map[string]*feature)
+	}
+
+	cli.features[name] = &feature{name: name, description: description, enabled: enabled}
+}
+
+// SetFeatureProvider sets the provider used to resolve feature flags which
+// aren't overridden with --feature or FEATURES.
+func (cli *CLI[T]) SetFeatureProvider(provider FeatureProvider) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.featureProvider = provider
+}
+
+// Feature returns true if the provided feature flag is enabled. Undeclared
+// features are always disabled.
+func (cli *CLI[T]) Feature(name string) bool {
+	state, ok := cli.featureState(name)
+	return ok && state.Enabled
+}
+
+// Features returns the resolved state of all declared feature flags, sorted
+// by name.
+func (cli *CLI[T]) Features() []FeatureState {
+	cli.mu.Lock()
+	names := make([]string, 0, len(cli.features))
+	for name := range cli.features {
+		names = append(names, name)
+	}
+	cli.mu.Unlock()
+
+	sort.Strings(names)
+
+	states := make([]FeatureState, 0, len(names))
+	for _, name := range names {
+		if state, ok := cli.featureState(name); ok {
+			states = append(states, state)
+		}
+	}
+
+	return states
+}
+
+// featureState resolves the state of the provided feature.
+func (cli *CLI[T]) featureState(name string) (state FeatureState, ok bool) {
+	cli.mu.Lock()
+	f, ok := cli.features[name]
+	provider := cli.featureProvider
+	overrides := cli.FeatureOverrides
+	cli.mu.Unlock()
+
+	if !ok {
+		return state, false
+	}
+
+	state = FeatureState{
+		Name:        f.name,
+		Description: f.description,
+		Default:     f.enabled,
+		Enabled:     f.enabled,
+		Source:      FeatureSourceDefault,
+	}
+
+	if provider != nil {
+		if enabled, ok := provider.Feature(name); ok {
+			state.Enabled = enabled
+			state.Source = FeatureSourceProvider
+		}
+	}
+
+	// Last override wins.
+	for _, o := range overrides {
+		oname, enabled, err := parseFeatureOverride(o)
+		if err == nil && oname == name {
+			state.Enabled = enabled
+			state.Source = FeatureSourceOverride
+		}
+	}
+
+	return state, true
+}
+
+// validateFeatures ensures all feature overrides reference declared features,
+// and have valid values.
+func (cli *CLI[T]) validateFeatures() error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	for _, o := range cli.FeatureOverrides {
+		name, _, err := parseFeatureOverride(o)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cli.features[name]; !ok {
+			return fmt.Errorf("unknown feature %q", name)
+		}
+	}
+
+	return nil
+}
+
+// parseFeatureOverride parses a feature override in the format of "name",
+// or "name=value", where value is on/off, true/false, etc.
+func parseFeatureOverride(o string) (name string, enabled bool, err error) {
+	name, value, hasValue := strings.Cut(strings.TrimSpace(o), "=")
+	if name == "" {
+		return "", false, fmt.Errorf("invalid feature override %q", o)
+	}
+
+	if !hasValue {
+		return name, true, nil
+	}
+
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes", "enable", "enabled":
+		return name, true, nil
+	case "off", "false", "0", "no", "disable", "disabled":
+		return name, false, nil
+	default:
+		return "", false, fmt.Errorf("invalid value %q for feature %q (must be on or off)", value, name)
+	}
+}
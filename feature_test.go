@@ -0,0 +1,47 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+func TestTraceResolutionFeatures(t *testing.T) {
+	cli := &clix.CLI[struct{}]{}
+	cli.DeclareFeature("new-scheduler", false, "use the new job scheduler")
+	cli.DeclareFeature("fast-sync", true, "sync in parallel")
+
+	res := clixtest.Run(t, cli, []string{"--debug-cli", "--feature", "new-scheduler"}, &clixtest.RunOptions{
+		Run: func() error { return nil },
+	})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(res.Stdout, "\n") {
+		if strings.Contains(line, "resolved feature") {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 resolved features, got %d:\n%s", len(lines), res.Stdout)
+	}
+
+	for i, want := range []string{"fast-sync", "new-scheduler"} {
+		if !strings.Contains(lines[i], want) {
+			t.Fatalf("expected line %d to contain %q, got %q", i, want, lines[i])
+		}
+	}
+
+	if !strings.Contains(lines[1], clix.FeatureSourceOverride) {
+		t.Fatalf("expected new-scheduler to be overridden, got %q", lines[1])
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// DefaultFIPSPolicyEnv is the environment variable used by WithFIPSPolicy when
+// none is provided.
+const DefaultFIPSPolicyEnv = "REQUIRE_FIPS"
+
+// ErrNotFIPS is returned when a FIPS policy is enforced (see WithFIPSPolicy),
+// and the binary wasn't built with a FIPS validated crypto module.
+var ErrNotFIPS = errors.New("clix: binary was not built with a FIPS validated crypto module")
+
+// WithFIPSPolicy refuses to run binaries which weren't built with a FIPS
+// validated crypto module (see VersionInfo.FIPS), when the provided environment
+// variable (DefaultFIPSPolicyEnv if empty) is set to a true value (e.g.
+// "true" or "1"). The check is done after --version is handled (so the build
+// can still be inspected), and before any command is invoked, failing with an
+// error matching ErrNotFIPS.
+//
+// Example:
+//
+//	cli.Apply(clix.WithFIPSPolicy("ACME_REQUIRE_FIPS"))
+func WithFIPSPolicy(envKey string) Option {
+	return func(s *settings) error {
+		if envKey == "" {
+			envKey = DefaultFIPSPolicyEnv
+		}
+
+		if strings.ContainsAny(envKey, "= \t\n") {
+			return fmt.Errorf("WithFIPSPolicy: invalid environment variable %q", envKey)
+		}
+
+		s.fipsPolicyEnv = envKey
+		return nil
+	}
+}
+
+// fipsSetting returns true if the provided build setting indicates the binary
+// was built with a FIPS validated crypto module, i.e. with
+// GOEXPERIMENT=boringcrypto (or the systemcrypto experiment of Microsoft's Go
+// fork), GOFIPS140 (Go 1.24+), or the "requirefips" build tag.
+func fipsSetting(key, value string) bool {
+	switch key {
+	case "GOEXPERIMENT":
+		for _, exp := range strings.Split(value, ",") {
+			if exp == "boringcrypto" || exp == "systemcrypto" {
+				return true
+			}
+		}
+	case "GOFIPS140":
+		return value != "" && value != "off"
+	case "-tags":
+		for _, tag := range strings.Split(value, ",") {
+			if tag == "requirefips" || tag == "goexperiment.boringcrypto" {
+				return true
+			}
+		}
+	case "DefaultGODEBUG":
+		for _, kv := range strings.Split(value, ",") {
+			if kv == "fips140=on" || kv == "fips140=only" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// detectFIPS returns true if the build settings indicate the binary was built
+// with a FIPS validated crypto module (see fipsSetting). Collected settings are
+// preferred (as they include those of VersionOptions.BuildInfoJSON), otherwise
+// the embedded build information is used.
+func (v *VersionInfo[T]) detectFIPS(build *debug.BuildInfo) bool {
+	if v.Settings != nil {
+		for _, s := range v.Settings {
+			if fipsSetting(s.Key, s.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if build != nil {
+		for _, s := range build.Settings {
+			if fipsSetting(s.Key, s.Value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkFIPSPolicy returns an error if a FIPS policy is configured, enabled
+// through its environment variable, and the binary isn't a FIPS build.
+func (cli *CLI[T]) checkFIPSPolicy() error {
+	key := cli.settings.fipsPolicyEnv
+	if key == "" {
+		return nil
+	}
+
+	v := getenv(key)
+	if v == "" {
+		return nil
+	}
+
+	required, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+
+	if !required || cli.VersionInfo.FIPS {
+		return nil
+	}
+
+	return fmt.Errorf("%w (required by %s)", ErrNotFIPS, key)
+}
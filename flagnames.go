@@ -0,0 +1,167 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// NoShort can be used as FlagOptions.Short to remove a flag's short name.
+const NoShort rune = -1
+
+// FlagOptions are overrides for a flag, typically one of the built-in flags
+// (e.g. -v/--version, -D/--debug, or --generate-markdown). See WithFlag.
+type FlagOptions struct {
+	// Long is the new long name. Empty keeps the existing long name.
+	Long string
+
+	// Short is the new short name. 0 keeps the existing short name, and
+	// NoShort removes it.
+	Short rune
+
+	// Hidden hides the flag from help and generated documentation. The flag
+	// can still be used.
+	Hidden bool
+}
+
+// flagOverride is a FlagOptions for a specific flag.
+type flagOverride struct {
+	long string
+	opts FlagOptions
+}
+
+// WithFlag overrides the name and visibility of the flag with the provided
+// long name (including any namespace, e.g. "log.level"), e.g. to free up a
+// short name, or to hide a built-in flag. Flags are looked up by their
+// original name, and renamed flags must not conflict with other flags. Note
+// that the short names of built-in flags (-v and -D) are already skipped if
+// used by the application's flags, however long names must still be unique.
+//
+// Example:
+//
+//	// -v is used for verbose, so move version to -V.
+//	cli.Apply(clix.WithFlag("version", clix.FlagOptions{Short: 'V'}))
+func WithFlag(long string, opts FlagOptions) Option {
+	return func(s *settings) error {
+		if long == "" {
+			return errors.New("WithFlag: flag name is empty")
+		}
+
+		for _, o := range s.flagOverrides {
+			if o.long == long {
+				return fmt.Errorf("WithFlag: flag %q already overridden", long)
+			}
+		}
+
+		s.flagOverrides = append(s.flagOverrides, flagOverride{long: long, opts: opts})
+		return nil
+	}
+}
+
+// builtinShortNames are the default short names of built-in flags. These are
+// assigned after the flags are scanned (rather than through struct tags), so
+// the application's flags take precedence when they conflict.
+var builtinShortNames = []struct {
+	long  string
+	short rune
+}{
+	{"version", 'v'},
+	{"debug", 'D'},
+}
+
+// applyFlagOverrides assigns the short names of built-in flags (unless already
+// used by another flag), and applies the configured flag overrides (see
+// WithFlag) to the parser, returning an error if a flag doesn't exist, or if
+// the result conflicts with another flag.
+func (cli *CLI[T]) applyFlagOverrides(p *flags.Parser) error {
+	for _, b := range builtinShortNames {
+		option := p.FindOptionByLongName(b.long)
+		if option == nil || p.FindOptionByShortName(b.short) != nil {
+			continue
+		}
+
+		var overridden bool
+		for _, o := range cli.settings.flagOverrides {
+			if o.long == b.long && o.opts.Short != 0 {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			option.ShortName = b.short
+		}
+	}
+
+	if len(cli.settings.flagOverrides) == 0 {
+		return nil
+	}
+
+	// Resolve all flags by their original names first, so overrides can swap
+	// names (e.g. -v for verbose, and -V for version).
+	options := make([]*flags.Option, len(cli.settings.flagOverrides))
+	for i, o := range cli.settings.flagOverrides {
+		if options[i] = p.FindOptionByLongName(o.long); options[i] == nil {
+			return fmt.Errorf("WithFlag: unknown flag %q", o.long)
+		}
+	}
+
+	for i, o := range cli.settings.flagOverrides {
+		option := options[i]
+
+		if o.opts.Long != "" {
+			option.LongName = o.opts.Long
+		}
+
+		switch o.opts.Short {
+		case 0:
+		case NoShort:
+			option.ShortName = 0
+		default:
+			option.ShortName = o.opts.Short
+		}
+
+		if o.opts.Hidden {
+			option.Hidden = true
+		}
+	}
+
+	longs := make(map[string]bool)
+	shorts := make(map[rune]bool)
+
+	var errs []error
+	walkGroupOptions(p.Command.Group, func(option *flags.Option) {
+		if long := option.LongNameWithNamespace(); long != "" {
+			if longs[long] {
+				errs = append(errs, fmt.Errorf("WithFlag: flag --%s is defined more than once", long))
+			}
+			longs[long] = true
+		}
+
+		if option.ShortName != 0 {
+			if shorts[option.ShortName] {
+				errs = append(errs, fmt.Errorf("WithFlag: flag -%c is defined more than once", option.ShortName))
+			}
+			shorts[option.ShortName] = true
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// walkGroupOptions invokes fn for all options of the provided group, and its
+// sub-groups.
+func walkGroupOptions(group *flags.Group, fn func(option *flags.Option)) {
+	for _, option := range group.Options() {
+		fn(option)
+	}
+
+	for _, g := range group.Groups() {
+		walkGroupOptions(g, fn)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/lrstanley/clix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Config are the flags used to dial a gRPC endpoint. Embed it in your flags
+// struct, and then call Config.Dial() to get a ready-to-use connection.
+//
+// Example (where you can set GRPC_ADDRESS as an environment variable, for example):
+//
+//	type Flags struct {
+//		GRPC grpcclient.Config `group:"gRPC Options" namespace:"grpc" env-namespace:"GRPC"`
+//	}
+//	[...]
+//	conn, err := cli.Flags.GRPC.Dial(ctx, cli.Logger)
+type Config struct {
+	// Address is the target address of the gRPC endpoint.
+	Address string `env:"ADDRESS" long:"address" required:"true" description:"address of the gRPC endpoint (e.g. host:port)"`
+
+	// Insecure disables transport security.
+	Insecure bool `env:"INSECURE" long:"insecure" description:"disable transport security (plaintext)"`
+
+	// TLSCert and TLSKey are used for mTLS authentication.
+	TLSCert string `env:"TLS_CERT" long:"tls-cert" description:"path to client certificate file (for mTLS)"`
+	TLSKey  string `env:"TLS_KEY" long:"tls-key" description:"path to client key file (for mTLS)"`
+
+	// TLSCA is an optional CA bundle used to verify the server.
+	TLSCA string `env:"TLS_CA" long:"tls-ca" description:"path to CA bundle used to verify the server (defaults to system roots, and --ca-bundle)"`
+
+	// TLSServerName overrides the server name used for verification.
+	TLSServerName string `env:"TLS_SERVER_NAME" long:"tls-server-name" description:"override the server name used to verify the server certificate"`
+
+	// TLSSkipVerify disables server certificate verification.
+	TLSSkipVerify bool `env:"TLS_SKIP_VERIFY" long:"tls-skip-verify" description:"skip server certificate verification (insecure)"`
+
+	// KeepaliveTime and KeepaliveTimeout configure client-side keepalive pings.
+	KeepaliveTime    time.Duration `env:"KEEPALIVE_TIME" long:"keepalive-time" default:"0s" description:"interval between keepalive pings (0 disables)"`
+	KeepaliveTimeout time.Duration `env:"KEEPALIVE_TIMEOUT" long:"keepalive-timeout" default:"20s" description:"time to wait for a keepalive ping ack"`
+
+	// Token is sent as a bearer token with each RPC.
+	Token string `env:"TOKEN" long:"token" description:"bearer token sent with each RPC"`
+
+	// TokenFile is read for the bearer token, if Token isn't provided.
+	TokenFile string `env:"TOKEN_FILE" long:"token-file" description:"path to file containing bearer token sent with each RPC"`
+
+	// TokenKeyring is the name of the keyring entry (see clix.DefaultKeyring)
+	// read for the bearer token, if neither Token nor TokenFile are provided.
+	TokenKeyring string `env:"TOKEN_KEYRING" long:"token-keyring" description:"name of the keyring entry containing bearer token sent with each RPC"`
+
+	// ConnectTimeout is the maximum time to wait for the connection to become
+	// ready.
+	ConnectTimeout time.Duration `env:"CONNECT_TIMEOUT" long:"connect-timeout" default:"10s" description:"time to wait for the connection to become ready (0 to not wait)"`
+}
+
+// TLSConfig returns the tls.Config generated from the provided flags, or nil if
+// transport security is disabled.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c.Insecure {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.TLSServerName,
+		InsecureSkipVerify: c.TLSSkipVerify, //nolint:gosec
+	}
+
+	if c.TLSCert != "" || c.TLSKey != "" {
+		if c.TLSCert == "" || c.TLSKey == "" {
+			return nil, errors.New("both tls-cert and tls-key must be provided")
+		}
+
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client keypair: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.TLSCA != "" {
+		b, err := os.ReadFile(c.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %q", c.TLSCA)
+		}
+	} else {
+		// Use the CA bundles shared by all clix-provided clients.
+		shared, err := clix.DefaultNetwork().TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = shared.RootCAs
+	}
+
+	return cfg, nil
+}
+
+// DialOptions returns the dial options generated from the provided flags. Use
+// this if you need to provide additional options of your own to grpc.NewClient.
+func (c *Config) DialOptions() ([]grpc.DialOption, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{}
+
+	if tlsConfig == nil {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.KeepaliveTime,
+			Timeout:             c.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	token := c.Token
+	if token == "" && c.TokenFile != "" {
+		b, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+
+		token = strings.TrimSpace(string(b))
+	}
+
+	if token == "" && c.TokenKeyring != "" {
+		token, err = clix.DefaultKeyring().Get(c.TokenKeyring)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token from keyring: %w", err)
+		}
+	}
+
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCredentials{
+			token:  token,
+			secure: tlsConfig != nil,
+		}))
+	}
+
+	return opts, nil
+}
+
+// Dial creates a new gRPC client connection using the provided flags. If
+// ConnectTimeout is non-zero, Dial will block until the connection is ready,
+// or the timeout is reached. Connection metadata is logged to the provided
+// logger, if not nil.
+func (c *Config) Dial(ctx context.Context, logger log.Interface, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	base, err := c.DialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	// DNS overrides (see clix.NetworkConfig) bypass the resolver, while still
+	// using the original address as the authority (and TLS server name).
+	target := c.Address
+
+	addr, overridden, err := clix.DefaultNetwork().ResolveAddr(c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if overridden {
+		target = "passthrough:///" + addr
+		base = append(base, grpc.WithAuthority(c.Address))
+	}
+
+	conn, err := grpc.NewClient(target, append(base, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+
+	if logger != nil {
+		logger = logger.WithFields(log.Fields{
+			"address":   c.Address,
+			"insecure":  c.Insecure,
+			"mtls":      c.TLSCert != "",
+			"keepalive": c.KeepaliveTime,
+		})
+	}
+
+	if c.ConnectTimeout > 0 {
+		ctx, cancel := context.WithTimeout(ctx, c.ConnectTimeout)
+		defer cancel()
+
+		conn.Connect()
+
+		for {
+			state := conn.GetState()
+			if state == connectivity.Ready {
+				break
+			}
+
+			if !conn.WaitForStateChange(ctx, state) {
+				_ = conn.Close()
+				return nil, fmt.Errorf("failed to connect to %q (last state: %s): %w", c.Address, state, ctx.Err())
+			}
+		}
+	}
+
+	if logger != nil {
+		logger.Debug("grpc client initialized")
+	}
+
+	return conn, nil
+}
+
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t *tokenCredentials) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t *tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
@@ -0,0 +1,454 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+
+	flags "github.com/jessevdk/go-flags"
+	"golang.org/x/term"
+)
+
+// DefaultPager is the pager used by CLI.Pager, when PAGER isn't set.
+const DefaultPager = "less"
+
+// ErrNotConfirmed is returned by CLI.Confirm when an action isn't confirmed.
+var ErrNotConfirmed = errors.New("clix: action not confirmed")
+
+// WithGuidelines enables behaviors recommended by the Command Line Interface
+// Guidelines (https://clig.dev):
+//
+//   - Logs are written to stderr (unless LoggerConfig.Writer is set), so
+//     stdout only contains the command's output.
+//   - --no-color disables color, in addition to NO_COLOR (see ColorEnabled).
+//   - --no-pager disables paging of output written through CLI.Pager.
+//   - --yes skips confirmation of destructive actions (see CLI.Confirm), and
+//     --no-input makes prompts fail instead of waiting for input.
+//   - --guidelines-report (hidden) prints a checklist of the guidelines the
+//     application follows, and those it still violates (see
+//     CLI.CheckGuidelines), and exits.
+//
+// Errors are always written to stderr, and flags which read or write files
+// should accept "-" for stdin or stdout (see InputConfig and OutputConfig).
+func WithGuidelines() Option {
+	return func(s *settings) error {
+		s.guidelines = true
+		return nil
+	}
+}
+
+// hasFlagArg returns true if the provided flag was provided, before any "--".
+func hasFlagArg(args []string, flag string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case flag:
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm prompts (on stderr) for confirmation of a destructive action (e.g.
+// "delete 3 files?"), returning an error wrapping ErrNotConfirmed if it isn't
+// confirmed. Confirmation fails without prompting if stdin isn't a terminal.
+// When WithGuidelines is used, --yes confirms without prompting, and
+// --no-input fails without prompting.
+//
+// Example:
+//
+//	if err := cli.Confirm(fmt.Sprintf("delete %d files?", len(files))); err != nil {
+//		return err
+//	}
+func (cli *CLI[T]) Confirm(prompt string) error {
+	var hint string
+
+	if cli.settings.guidelines {
+		if cli.Yes {
+			return nil
+		}
+
+		hint = ", use --yes to confirm"
+
+		if cli.NoInput {
+			return fmt.Errorf("%w: %s (--no-input was provided%s)", ErrNotConfirmed, prompt, hint)
+		}
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("%w: %s (stdin is not a terminal%s)", ErrNotConfirmed, prompt, hint)
+	}
+
+	_, stderr := cli.OriginalStreams()
+	p := &prompter{in: bufio.NewReader(os.Stdin), fd: int(os.Stdin.Fd()), out: stderr}
+
+	ok, err := p.confirm(prompt, false)
+	if err != nil && !errors.Is(err, errInteractiveAborted) {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotConfirmed, prompt)
+	}
+
+	return nil
+}
+
+// nopWriteCloser is an io.WriteCloser with a no-op Close.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// pagerWriter writes to the stdin of a pager process.
+type pagerWriter struct {
+	stdin io.WriteCloser
+	cmd   *exec.Cmd
+}
+
+// Write implements io.Writer. Output written after the pager exits (e.g. the
+// user quit early) is discarded.
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	n, err := w.stdin.Write(p)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return len(p), nil
+	}
+	return n, err
+}
+
+// Close closes the pager's input, and waits for it to exit.
+func (w *pagerWriter) Close() error {
+	err := w.stdin.Close()
+
+	if werr := w.cmd.Wait(); err == nil {
+		err = werr
+	}
+
+	return err
+}
+
+// Pager returns a writer which pages output through PAGER (or DefaultPager,
+// with LESS defaulting to "FRX", so short output isn't paged), if stdout is a
+// terminal. Otherwise, or if PAGER is empty or "cat", the pager isn't found,
+// or --no-pager was provided (see WithGuidelines), the writer writes to stdout
+// directly. Close must be called once all output is written, which waits for
+// the user to exit the pager.
+//
+// Example:
+//
+//	w, err := cli.Pager()
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Close()
+//	// [write to w]
+func (cli *CLI[T]) Pager() (io.WriteCloser, error) {
+	stdout, _ := cli.OriginalStreams()
+
+	pager, ok := lookupEnv("PAGER")
+	if !ok {
+		pager = DefaultPager
+	}
+
+	args := strings.Fields(pager)
+
+	if (cli.settings.guidelines && cli.NoPager) || len(args) == 0 || args[0] == "cat" || !Console(stdout).Terminal {
+		return nopWriteCloser{Writer: os.Stdout}, nil
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	if _, ok = lookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start pager: %w", err)
+	}
+
+	if err = cmd.Start(); err != nil {
+		_ = stdin.Close()
+
+		if errors.Is(err, exec.ErrNotFound) {
+			return nopWriteCloser{Writer: os.Stdout}, nil
+		}
+		return nil, fmt.Errorf("failed to start pager: %w", err)
+	}
+
+	return &pagerWriter{stdin: stdin, cmd: cmd}, nil
+}
+
+// GuidelineCheck is the result of checking the application against one of the
+// Command Line Interface Guidelines (see CLI.CheckGuidelines).
+type GuidelineCheck struct {
+	// Name describes the guideline.
+	Name string
+
+	// Passed is true if the application follows the guideline.
+	Passed bool
+
+	// Violations are the flags or commands which violate the guideline, if
+	// any.
+	Violations []string
+
+	// Hint describes how to follow the guideline.
+	Hint string
+}
+
+// destructiveCommands are the names of commands which likely perform
+// destructive actions, and should be confirmed.
+var destructiveCommands = []string{
+	"delete", "destroy", "drop", "erase", "nuke", "prune", "purge", "remove",
+	"reset", "rm", "uninstall", "wipe",
+}
+
+// fileFlagHints are the substrings of flag names and value names, which
+// indicate the flag is a file path.
+var fileFlagHints = []string{"file", "path", "input", "output"}
+
+// CheckGuidelines checks the application's flags, commands and configuration
+// against the Command Line Interface Guidelines (https://clig.dev), returning
+// the result of each check. Built-in flags and commands are excluded, as they
+// already follow the guidelines. This is the output of --guidelines-report
+// (see WithGuidelines).
+func (cli *CLI[T]) CheckGuidelines() []GuidelineCheck {
+	p := cli.Parser
+	if p == nil {
+		// Commands which fail to be added are excluded, and the error is
+		// surfaced when parsing.
+		p, _ = cli.newParser()
+	}
+
+	builtinFlags := builtinOptions()
+	builtinCommands := cli.builtinCommands()
+
+	var (
+		noLong, noDescription, noStdio, destructive []string
+		machineOutput                               bool
+	)
+
+	walkOptions(p.Command, func(option *flags.Option) {
+		name := option.LongNameWithNamespace()
+		if name != "" && builtinFlags[name] {
+			return
+		}
+
+		if name == "" {
+			name = "-" + string(option.ShortName)
+			noLong = append(noLong, name)
+		} else {
+			name = "--" + name
+		}
+
+		if option.Description == "" && !option.Hidden {
+			noDescription = append(noDescription, name)
+		}
+
+		switch strings.ToLower(option.LongName) {
+		case "json", "plain", "format", "output", "output-format":
+			machineOutput = true
+		}
+
+		if isFileOption(option) && !slices.Contains(option.Default, "-") && !strings.Contains(option.Description, "'-'") && !strings.Contains(option.Description, `"-"`) {
+			noStdio = append(noStdio, name)
+		}
+	})
+
+	var walkCommands func(cmd *flags.Command, path string)
+	walkCommands = func(cmd *flags.Command, path string) {
+		for _, sub := range cmd.Commands() {
+			if path == "" && builtinCommands[sub.Name] {
+				continue
+			}
+
+			name := strings.TrimSpace(path + " " + sub.Name)
+
+			if sub.ShortDescription == "" && !sub.Hidden {
+				noDescription = append(noDescription, name)
+			}
+
+			// --yes is global when using WithGuidelines (see CLI.Confirm).
+			if !cli.settings.guidelines && slices.Contains(destructiveCommands, strings.ToLower(sub.Name)) && sub.FindOptionByLongName("force") == nil && sub.FindOptionByLongName("yes") == nil {
+				destructive = append(destructive, name)
+			}
+
+			walkCommands(sub, name)
+		}
+	}
+	walkCommands(p.Command, "")
+
+	logsToStdout := cli.LoggerConfig.Writer != nil && cli.LoggerConfig.Writer != os.Stderr
+	if cli.LoggerConfig.Writer == nil && !cli.settings.guidelines && !cli.IsSet(OptDisableLogging) && cli.settings.logger == nil {
+		logsToStdout = true
+	}
+
+	var links []Link
+	if cli.VersionInfo != nil {
+		links = cli.VersionInfo.Links
+	} else {
+		links = cli.Links
+	}
+
+	_, hasDocs := FindLink(links, LinkDocs)
+	_, hasIssues := FindLink(links, LinkIssues)
+	_, hasSupport := FindLink(links, LinkSupport)
+
+	return []GuidelineCheck{
+		{
+			Name:   "-h/--help prints help",
+			Passed: !cli.IsSet(OptDisableHelpFlag),
+			Hint:   "don't use OptDisableHelpFlag",
+		},
+		{
+			Name:   "--version prints version information",
+			Passed: !cli.IsSet(OptDisableVersion),
+			Hint:   "don't use OptDisableVersion",
+		},
+		{
+			Name:   "the application is described in help output",
+			Passed: cli.Description != "",
+			Hint:   "set CLI.Description (e.g. embedded from a markdown file)",
+		},
+		{
+			Name:   "help output links to documentation or support",
+			Passed: hasDocs || hasIssues || hasSupport,
+			Hint:   "provide CLI.Links (e.g. docs, issues or support)",
+		},
+		{
+			Name:       "all flags have a long name",
+			Passed:     len(noLong) == 0,
+			Violations: noLong,
+			Hint:       `add a long name to each flag (e.g. long:"verbose")`,
+		},
+		{
+			Name:       "all flags and commands have a description",
+			Passed:     len(noDescription) == 0,
+			Violations: noDescription,
+			Hint:       `add a description to each flag and command (e.g. description:"...")`,
+		},
+		{
+			Name:   "errors and logs are written to stderr",
+			Passed: !logsToStdout,
+			Hint:   "use WithGuidelines, or set LoggerConfig.Writer to os.Stderr",
+		},
+		{
+			Name:   "color can be disabled with NO_COLOR or --no-color",
+			Passed: cli.settings.guidelines,
+			Hint:   "use WithGuidelines",
+		},
+		{
+			Name:   "paging can be disabled with --no-pager",
+			Passed: cli.settings.guidelines,
+			Hint:   "use WithGuidelines, and write long output through CLI.Pager",
+		},
+		{
+			Name:       "destructive commands can be confirmed without prompting (--yes or --force)",
+			Passed:     len(destructive) == 0,
+			Violations: destructive,
+			Hint:       "use WithGuidelines and CLI.Confirm (which adds --yes), or add a --force flag",
+		},
+		{
+			Name:       "file flags accept '-' for stdin or stdout",
+			Passed:     len(noStdio) == 0,
+			Violations: noStdio,
+			Hint:       "use InputConfig or OutputConfig, or support '-' and mention it in the flag description",
+		},
+		{
+			Name:   "machine-readable output is available",
+			Passed: machineOutput,
+			Hint:   "add a --json (or --plain, --format) flag for output which is parsed by other programs",
+		},
+	}
+}
+
+// isFileOption returns true if the option is likely a file path (a string
+// option, named or with a value name like "file", "path", "input" or
+// "output").
+func isFileOption(option *flags.Option) bool {
+	if option.Field().Type.Kind() != reflect.String {
+		return false
+	}
+
+	name := strings.ToLower(option.LongName + " " + option.ValueName)
+
+	for _, hint := range fileFlagHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// builtinOptions returns the long names (with namespaces) of the built-in
+// flags.
+func builtinOptions() map[string]bool {
+	names := make(map[string]bool)
+
+	p := flags.NewParser(&CLI[struct{}]{}, flags.HelpFlag)
+	walkOptions(p.Command, func(option *flags.Option) {
+		if name := option.LongNameWithNamespace(); name != "" {
+			names[name] = true
+		}
+	})
+
+	return names
+}
+
+// builtinCommands returns the names of the built-in commands which are
+// enabled (see newParser).
+func (cli *CLI[T]) builtinCommands() map[string]bool {
+	return map[string]bool{
+		"cache":        cli.settings.cache,
+		"completion":   cli.settings.completionCommand,
+		"config":       cli.settings.configCommand,
+		"doctor":       len(cli.settings.doctorChecks) > 0,
+		"usage-report": cli.IsSet(OptEnableUsageReport),
+	}
+}
+
+// printGuidelinesReport prints the result of CheckGuidelines.
+func (cli *CLI[T]) printGuidelinesReport(f *os.File) {
+	printf := func(format string, args ...any) {
+		fmt.Fprint(f, colorize(f, fmt.Sprintf(format, args...)))
+	}
+
+	checks := cli.CheckGuidelines()
+
+	var passed int
+
+	for _, check := range checks {
+		if check.Passed {
+			passed++
+			printf("<greenB>PASS</>  %s\n", check.Name)
+			continue
+		}
+
+		if len(check.Violations) > 0 {
+			printf("<redB>FAIL</>  %s: %s\n", check.Name, strings.Join(check.Violations, ", "))
+		} else {
+			printf("<redB>FAIL</>  %s\n", check.Name)
+		}
+
+		printf("      <gray>hint: %s</>\n", check.Hint)
+	}
+
+	printf("\n%d of %d guidelines followed (see https://clig.dev)\n", passed, len(checks))
+}
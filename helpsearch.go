@@ -0,0 +1,203 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// HelpMatch is a single result of a help search (see DocModel.Search).
+type HelpMatch struct {
+	// Kind is either "flag" or "command".
+	Kind string `json:"kind"`
+
+	// Path is the full path of the match, e.g. "app serve --tls.cert" or
+	// "app serve".
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+	Env         string `json:"env,omitempty"`
+
+	// Score is the relevance of the match (higher is more relevant).
+	Score int `json:"score"`
+}
+
+// Search fuzzy-searches the names, descriptions and environment variables of
+// all flags and commands (recursively), returning matches ordered by
+// relevance. Hidden flags and commands are excluded.
+func (m *DocModel) Search(query string) []HelpMatch {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var matches []HelpMatch
+
+	addOptions := func(path string, options []*DocOption) {
+		for _, option := range options {
+			name := "--" + option.Long
+			if option.Long == "" {
+				name = "-" + option.Short
+			}
+
+			score := max(
+				fuzzyScore(query, name, true)*2,
+				fuzzyScore(query, option.Description, false),
+				fuzzyScore(query, option.Env, true),
+			)
+			if score == 0 {
+				continue
+			}
+
+			matches = append(matches, HelpMatch{
+				Kind:        "flag",
+				Path:        path + " " + name,
+				Description: option.Description,
+				Env:         option.Env,
+				Score:       score,
+			})
+		}
+	}
+
+	var addGroups func(path string, groups []*DocGroup)
+	addGroups = func(path string, groups []*DocGroup) {
+		for _, group := range groups {
+			addOptions(path, group.Options)
+			addGroups(path, group.Groups)
+		}
+	}
+
+	var addCommands func(commands []*DocCommand)
+	addCommands = func(commands []*DocCommand) {
+		for _, cmd := range commands {
+			path := m.Name + " " + cmd.Path
+
+			score := max(fuzzyScore(query, cmd.Name, true)*2, fuzzyScore(query, cmd.ShortDescription, false))
+			for _, alias := range cmd.Aliases {
+				score = max(score, fuzzyScore(query, alias, true)*2)
+			}
+
+			if score > 0 {
+				matches = append(matches, HelpMatch{
+					Kind:        "command",
+					Path:        path,
+					Description: cmd.ShortDescription,
+					Score:       score,
+				})
+			}
+
+			addOptions(path, cmd.Options)
+			addGroups(path, cmd.Groups)
+			addCommands(cmd.Commands)
+		}
+	}
+
+	addGroups(m.Name, m.Groups)
+	addCommands(m.Commands)
+
+	slices.SortStableFunc(matches, func(a, b HelpMatch) int {
+		return b.Score - a.Score
+	})
+
+	return matches
+}
+
+// fuzzyScore returns how well query (lowercase) matches s, or 0 if it doesn't.
+// Substring matches score higher than subsequence matches (only used when
+// fuzzy is true, e.g. for names), and matches at the start of s, or of a word
+// in s, score higher than others.
+func fuzzyScore(query, s string, fuzzy bool) int {
+	if s == "" {
+		return 0
+	}
+
+	s = strings.ToLower(s)
+
+	if i := strings.Index(s, query); i >= 0 {
+		score := 100
+		if i == 0 || !isWordByte(s[i-1]) {
+			score += 50
+		}
+		return score - min(i, 50)
+	}
+
+	if !fuzzy {
+		return 0
+	}
+
+	// Subsequence match, penalized by the gaps between matched characters.
+	qi, gaps, last := 0, 0, -1
+	for i := 0; i < len(s) && qi < len(query); i++ {
+		if s[i] != query[qi] {
+			continue
+		}
+
+		if last >= 0 {
+			gaps += i - last - 1
+		}
+
+		last = i
+		qi++
+	}
+
+	if qi < len(query) || gaps > 2*len(query) {
+		return 0
+	}
+
+	return max(50-gaps, 1)
+}
+
+func isWordByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+// SearchHelp writes the results of a fuzzy search of all flags and commands
+// (see DocModel.Search) to out, including their full paths. This is invoked
+// automatically when --help-search is provided.
+func (cli *CLI[T]) SearchHelp(out io.Writer, query string) {
+	m := cli.DocModel()
+	matches := m.Search(query)
+
+	if len(matches) == 0 {
+		fmt.Fprintf(out, "no flags or commands match %q\n", query)
+		return
+	}
+
+	width := 0
+	for _, match := range matches {
+		width = max(width, len(match.Path))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d match(es) for %q:\n\n", len(matches), query)
+
+	for _, match := range matches {
+		fmt.Fprintf(&b, "  <cyan>%-*s</>  %s", width, match.Path, match.Description)
+		if match.Env != "" {
+			fmt.Fprintf(&b, " <gray>[$%s]</>", match.Env)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprint(out, colorize(streamFor(out), b.String()))
+}
+
+// helpSearchArg returns the query provided with --help-search (if any), so
+// searches work regardless of required flags or sub-commands.
+func helpSearchArg(args []string) (query string, ok bool) {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return "", false
+		case arg == "--help-search" && i+1 < len(args):
+			return args[i+1], true
+		case strings.HasPrefix(arg, "--help-search="):
+			return strings.TrimPrefix(arg, "--help-search="), true
+		}
+	}
+	return "", false
+}
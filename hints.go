@@ -0,0 +1,74 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errorHint is a remediation hint, registered with RegisterHint.
+type errorHint struct {
+	matcher func(error) bool
+	hint    string
+}
+
+// RegisterHint registers a remediation hint, which is printed after the error
+// output when the invocation fails with an error for which matcher returns
+// true (e.g. using errors.Is or errors.As). All matching hints are printed, in
+// the order they were registered, followed by the documentation link (see
+// LinkDocs), if any. Hints are only printed when the parser prints errors
+// (flags.PrintErrors, the default). Must be called before Parse().
+//
+// Example:
+//
+//	cli.RegisterHint(func(err error) bool {
+//		return errors.Is(err, fs.ErrPermission)
+//	}, "check that the config directory is writable, or set --config-dir")
+func (cli *CLI[T]) RegisterHint(matcher func(error) bool, hint string) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.hints = append(cli.hints, errorHint{matcher: matcher, hint: hint})
+}
+
+// matchHints returns the hints matching the provided error.
+func (cli *CLI[T]) matchHints(err error) []string {
+	cli.mu.Lock()
+	hints := cli.hints
+	cli.mu.Unlock()
+
+	var matched []string
+
+	for _, h := range hints {
+		if h.matcher != nil && h.matcher(err) {
+			matched = append(matched, h.hint)
+		}
+	}
+
+	return matched
+}
+
+// printHints prints the hints matching the provided error to f, followed by
+// the documentation link, if any.
+func (cli *CLI[T]) printHints(f *os.File, err error) {
+	hints := cli.matchHints(err)
+	if len(hints) == 0 {
+		return
+	}
+
+	for _, h := range hints {
+		fmt.Fprint(f, colorize(f, fmt.Sprintf("<gray>hint: %s</>\n", strings.ReplaceAll(h, "\n", "\n      "))))
+	}
+
+	if cli.VersionInfo == nil {
+		return
+	}
+
+	if l, ok := FindLink(cli.VersionInfo.Links, LinkDocs); ok {
+		fmt.Fprint(f, colorize(f, fmt.Sprintf("<gray>docs: %s</>\n", l.URL)))
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryEntry is a single entry in the local command history. See
+// OptEnableHistory.
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	ID         string    `json:"invocation_id,omitempty"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	Dir        string    `json:"dir,omitempty"`
+	User       string    `json:"user,omitempty"`
+	PID        int       `json:"pid"`
+	ExitCode   int       `json:"exit_code"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Version    string    `json:"version"`
+}
+
+// HistoryPath returns the path to the local command history file, which is
+// "history.ndjson" within the state directory (see StateDir).
+func (cli *CLI[T]) HistoryPath() (string, error) {
+	dir, err := cli.StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "history.ndjson"), nil
+}
+
+// recordHistory appends an entry for the current invocation to the local
+// command history, with secret flag values redacted (see RedactArgs and
+// RedactText).
+func (cli *CLI[T]) recordHistory(runErr error) error {
+	fn, err := cli.HistoryPath()
+	if err != nil {
+		return err
+	}
+
+	cli.mu.Lock()
+	started := cli.started
+	id := cli.invocationID
+	cli.mu.Unlock()
+
+	entry := &HistoryEntry{
+		Time:       started,
+		ID:         id,
+		Command:    filepath.Base(os.Args[0]),
+		Args:       cli.RedactArgs(os.Args[1:]),
+		PID:        os.Getpid(),
+		ExitCode:   ExitCode(runErr),
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+
+	if runErr != nil {
+		entry.Error = cli.RedactText(runErr.Error())
+	}
+
+	entry.Dir, _ = os.Getwd()
+	entry.User = getenv("USER")
+
+	if cli.VersionInfo != nil {
+		entry.Version = cli.VersionInfo.Version
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Appends only need a shared lock, as they're single writes, however they
+	// must not happen while the file is being rewritten (see PruneHistory).
+	unlock, err := lockHistory(fn, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	// Single write, so concurrent invocations don't interleave entries.
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// ReadHistory reads all entries from the provided command history file
+// (see HistoryPath), oldest first. Malformed entries are skipped. A missing
+// file returns no entries.
+func ReadHistory(path string) ([]*HistoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*HistoryEntry
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		entry := &HistoryEntry{}
+		if err = json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// PruneHistory removes entries from the provided command history file (see
+// HistoryPath) which are older than maxAge, and all but the newest maxEntries
+// entries. Zero values disable the respective limit. Returns the number of
+// removed entries.
+func PruneHistory(path string, maxAge time.Duration, maxEntries int) (removed int, err error) {
+	if _, err = os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	unlock, err := lockHistory(path, true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := ReadHistory(path)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if maxAge > 0 && time.Since(entry.Time) > maxAge {
+			continue
+		}
+
+		kept = append(kept, entry)
+	}
+
+	if maxEntries > 0 && len(kept) > maxEntries {
+		kept = kept[len(kept)-maxEntries:]
+	}
+
+	removed = len(entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	for _, entry := range kept {
+		if err = enc.Encode(entry); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = writeFileAtomic(path, buf.Bytes(), 0o600); err != nil {
+		return 0, fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	return removed, nil
+}
+
+// lockHistory acquires the lock file ("<path>.lock") of the provided command
+// history file, returning a function which releases it.
+func lockHistory(path string, exclusive bool) (unlock func(), err error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history lock: %w", err)
+	}
+
+	if err = lockFile(lock, exclusive); err != nil {
+		_ = lock.Close()
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(lock)
+		_ = lock.Close()
+	}, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+func TestHistoryRedactsAndPrunes(t *testing.T) {
+	// Each run uses its own temporary directories, so share the state dir.
+	env := map[string]string{"XDG_STATE_HOME": t.TempDir()}
+	cli := &clix.CLI[redactFlags]{}
+
+	for _, name := range []string{"first", "second", "third"} {
+		clixtest.Run(t, cli, []string{"--name", name, "--password", "hunter2"}, &clixtest.RunOptions{
+			Env:     env,
+			Options: []clix.Options{clix.OptEnableHistory},
+			Run:     func() error { return errors.New("failed to authenticate with hunter2") },
+		})
+	}
+
+	path, err := cli.HistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := clix.ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if want := "failed to authenticate with " + clix.Redacted; entry.Error != want {
+			t.Fatalf("unexpected error %q, want %q", entry.Error, want)
+		}
+
+		if slices.Contains(entry.Args, "hunter2") {
+			t.Fatalf("expected args to be redacted, got %q", entry.Args)
+		}
+	}
+
+	removed, err := clix.PruneHistory(path, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 2 {
+		t.Fatalf("expected 2 removed entries, got %d", removed)
+	}
+
+	if entries, err = clix.ReadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || !slices.Contains(entries[0].Args, "third") {
+		t.Fatalf("expected only the newest entry to be kept, got %+v", entries)
+	}
+}
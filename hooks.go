@@ -0,0 +1,169 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// DefaultExitHookTimeout is the default maximum time all exit hooks are given
+// to run, before the process exits regardless.
+const DefaultExitHookTimeout = 5 * time.Second
+
+// ExitEvent describes why the process is exiting.
+type ExitEvent struct {
+	// Code is the exit code the process will exit with.
+	Code int
+
+	// Err is the error which caused the exit, if any.
+	Err error
+
+	// Entry is the log entry, if the exit was caused by a Fatal log.
+	Entry *log.Entry
+
+	// Output is the tail of the output, if captured (see WithOutputCapture).
+	Output *CapturedOutput
+
+	// InvocationID is the ID of the run which is exiting (see
+	// CLI.InvocationID).
+	InvocationID string
+}
+
+// ExitHook is invoked before the process exits, due to a Fatal log, or clix
+// exiting with a non-zero code (e.g. due to a usage or command error). Hooks
+// can be used to flush traces, send notifications, write crash breadcrumbs,
+// etc. Hooks should respect ctx, which is canceled when the exit hook timeout
+// is reached. See WithExitHook.
+type ExitHook func(ctx context.Context, event *ExitEvent)
+
+// WithExitHook registers a hook to invoke before the process exits due to a
+// Fatal log, or an error exit. Hooks are invoked in order of registration.
+func WithExitHook(hook ExitHook) Option {
+	return func(s *settings) error {
+		if hook == nil {
+			return errors.New("WithExitHook: hook is nil")
+		}
+
+		s.exitHooks = append(s.exitHooks, hook)
+		return nil
+	}
+}
+
+// WithExitHookTimeout sets the maximum time all exit hooks are given to run
+// (defaults to DefaultExitHookTimeout), after which the process exits
+// regardless.
+func WithExitHookTimeout(timeout time.Duration) Option {
+	return func(s *settings) error {
+		if timeout <= 0 {
+			return errors.New("WithExitHookTimeout: timeout must be positive")
+		}
+
+		s.exitHookTimeout = timeout
+		return nil
+	}
+}
+
+// RunExitHooks invokes all registered exit hooks (see WithExitHook) with the
+// provided event, waiting at most for the exit hook timeout. Hooks are only
+// invoked once per run (until the CLI is Reset), even if called multiple
+// times. This is invoked automatically by clix, and only needs to be called
+// when exiting manually (which also flushes captured output, see
+// WithOutputCapture).
+func (cli *CLI[T]) RunExitHooks(event *ExitEvent) {
+	defer cli.flushOutputCapture()
+
+	cli.mu.Lock()
+	hooks := cli.settings.exitHooks
+	timeout := cli.settings.exitHookTimeout
+	cli.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultExitHookTimeout
+	}
+
+	cli.exitHooksOnce.Do(func() {
+		if event.Output == nil {
+			event.Output = cli.CapturedOutput()
+		}
+
+		if event.InvocationID == "" {
+			event.InvocationID = cli.InvocationID()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+
+			for _, hook := range hooks {
+				if ctx.Err() != nil {
+					return
+				}
+
+				hook(ctx, event)
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// exitHookHandler is a log handler which invokes exit hooks on Fatal log
+// entries, before passing them to the underlying handler (after which apex/log
+// exits the process).
+type exitHookHandler struct {
+	mu   sync.Mutex
+	next log.Handler
+	run  func(event *ExitEvent)
+}
+
+func (h *exitHookHandler) HandleLog(e *log.Entry) error {
+	if e.Level == log.FatalLevel {
+		// Ensure the fatal entry is written first, in case the hooks hang.
+		h.mu.Lock()
+		err := h.next.HandleLog(e)
+		h.mu.Unlock()
+
+		h.run(&ExitEvent{Code: 1, Entry: e})
+		return err
+	}
+
+	return h.next.HandleLog(e)
+}
+
+// wrapExitHooks wraps the logger's handler, so exit hooks are invoked (and
+// captured output is flushed) on Fatal log entries, if any hooks are
+// registered, or output is captured.
+func (cli *CLI[T]) wrapExitHooks(logger *log.Logger) {
+	cli.mu.Lock()
+	hooks := len(cli.settings.exitHooks)
+	capture := cli.capture != nil
+	cli.mu.Unlock()
+
+	if (hooks == 0 && !capture) || logger.Handler == nil {
+		return
+	}
+
+	if _, ok := logger.Handler.(*exitHookHandler); ok {
+		return
+	}
+
+	logger.Handler = &exitHookHandler{next: logger.Handler, run: cli.RunExitHooks}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPClientConfig are the flags used to create an HTTP client, with optional
+// response caching in the application's cache (see CLI.Cache).
+//
+// Example (where you can set API_CACHE as an environment variable, for example):
+//
+//	type Flags struct {
+//		API clix.HTTPClientConfig `group:"API Options" namespace:"api" env-namespace:"API"`
+//	}
+//	[...]
+//	cache, err := cli.Cache()
+//	[...]
+//	client, err := cli.Flags.API.Client(cache)
+type HTTPClientConfig struct {
+	// Timeout is the maximum duration of each request.
+	Timeout time.Duration `env:"TIMEOUT" long:"timeout" default:"30s" description:"timeout for each request (0 is no timeout)"`
+
+	// Cache enables caching of GET responses.
+	Cache bool `env:"CACHE" long:"cache" description:"cache GET responses, revalidating them with the server when stale"`
+
+	// CacheTTL is how long cached responses are considered fresh, when the
+	// server doesn't provide a max-age.
+	CacheTTL time.Duration `env:"CACHE_TTL" long:"cache-ttl" default:"0s" description:"how long responses without a max-age are used without revalidation (0 always revalidates)"`
+}
+
+// Client returns an HTTP client generated from the provided flags, using the
+// shared network configuration (see DefaultNetwork). Responses are cached in
+// the provided cache if caching is enabled, and cache isn't nil.
+func (c *HTTPClientConfig) Client(cache *Cache) (*http.Client, error) {
+	transport, err := DefaultNetwork().Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: c.Timeout, Transport: transport}
+
+	if c.Cache && cache != nil {
+		client.Transport = &CacheTransport{Cache: cache, Transport: transport, TTL: c.CacheTTL}
+	}
+
+	return client, nil
+}
+
+const (
+	// cacheStoredHeader is the header used to track when a cached response was
+	// stored (or last revalidated), which is removed before it's returned.
+	cacheStoredHeader = "X-Clix-Cache-Stored"
+
+	// CacheHeader is set on responses served from the cache (whether
+	// revalidated or not).
+	CacheHeader = "X-From-Cache"
+)
+
+// CacheTransport is an http.RoundTripper which caches successful GET
+// responses in a Cache. Fresh responses (see TTL, and the Cache-Control
+// max-age directive) are served without contacting the server, while stale
+// responses are revalidated with a conditional request (using the ETag and
+// Last-Modified headers). Responses marked as no-store are never cached, and
+// requests with Cache-Control: no-cache always revalidate.
+type CacheTransport struct {
+	// Cache is where responses are stored.
+	Cache *Cache
+
+	// Transport is the underlying transport, defaulting to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// TTL is how long responses are considered fresh, when the server doesn't
+	// provide a max-age. 0 always revalidates.
+	TTL time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || t.Cache.Disabled() {
+		return transport.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+
+	cached, stored := t.cached(key, req)
+	if cached != nil && !hasDirective(req.Header, "no-cache") && time.Since(stored) < t.freshness(cached) {
+		return cached, nil
+	}
+
+	if cached != nil {
+		// Revalidate, without modifying the caller's request.
+		req = req.Clone(req.Context())
+
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		_ = resp.Body.Close()
+
+		for k, v := range resp.Header {
+			cached.Header[k] = v
+		}
+
+		t.store(key, cached)
+		return cached, nil
+	}
+
+	if resp.StatusCode != http.StatusOK || hasDirective(resp.Header, "no-store") {
+		return resp, nil
+	}
+
+	// Responses which can't be revalidated are only useful while fresh.
+	if resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" && t.freshness(resp) <= 0 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.store(key, resp)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// cached returns the cached response for the provided key, and when it was
+// stored, if any.
+func (t *CacheTransport) cached(key string, req *http.Request) (*http.Response, time.Time) {
+	b, ok := t.Cache.Get(key)
+	if !ok {
+		return nil, time.Time{}
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+	if err != nil {
+		return nil, time.Time{}
+	}
+
+	stored, _ := strconv.ParseInt(resp.Header.Get(cacheStoredHeader), 10, 64)
+	resp.Header.Del(cacheStoredHeader)
+	resp.Header.Set(CacheHeader, "1")
+
+	return resp, time.Unix(stored, 0)
+}
+
+// store stores the provided response (whose body must be re-readable) in the
+// cache. Failures are ignored, as the response can still be used.
+func (t *CacheTransport) store(key string, resp *http.Response) {
+	resp.Header.Set(cacheStoredHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	defer resp.Header.Del(cacheStoredHeader)
+
+	b, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return
+	}
+
+	_ = t.Cache.Put(key, b, 0)
+}
+
+// freshness returns how long the provided response is considered fresh.
+func (t *CacheTransport) freshness(resp *http.Response) time.Duration {
+	if hasDirective(resp.Header, "no-cache") {
+		return 0
+	}
+
+	for _, directive := range strings.Split(resp.Header.Get("Cache-Control"), ",") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(directive), "max-age="); ok {
+			if seconds, err := strconv.Atoi(v); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return t.TTL
+}
+
+// cacheKey returns the cache key for the provided request. Credentials are
+// included, so responses aren't shared between users/tokens.
+func cacheKey(req *http.Request) string {
+	return "http\n" + req.URL.String() + "\n" + req.Header.Get("Authorization") + "\n" + req.Header.Get("Accept")
+}
+
+// hasDirective returns true if the Cache-Control header contains the provided
+// directive.
+func hasDirective(h http.Header, directive string) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,347 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported input formats.
+const (
+	FormatAuto   = "auto"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatYAML   = "yaml"
+	FormatCSV    = "csv"
+)
+
+// InputConfig are the flags used to read structured input from a file or
+// stdin, so filter-style CLIs handle piped data uniformly.
+//
+// Example:
+//
+//	type Flags struct {
+//		Input clix.InputConfig `group:"Input Options"`
+//	}
+//	[...]
+//	records, err := clix.ReadInputFrom[Record](&cli.Flags.Input)
+type InputConfig struct {
+	// Input is the path to the input file, or "-" for stdin.
+	Input string `short:"f" long:"input" default:"-" description:"path to input file (use '-' for stdin)"`
+
+	// Format is the format of the input.
+	Format string `long:"input-format" default:"auto" choice:"auto" choice:"json" choice:"ndjson" choice:"yaml" choice:"csv" description:"format of the input (auto detects from file extension or content)"`
+}
+
+// Open opens the configured input. If the input is "-" (or empty), stdin is
+// returned, and closing it is a no-op.
+func (c *InputConfig) Open() (io.ReadCloser, error) {
+	if c.Input == "" || c.Input == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(c.Input)
+}
+
+// DetectedFormat returns the configured format, falling back to the format
+// associated with the input file extension, or FormatAuto if unknown.
+func (c *InputConfig) DetectedFormat() string {
+	if c.Format != "" && c.Format != FormatAuto {
+		return c.Format
+	}
+
+	switch strings.ToLower(filepath.Ext(c.Input)) {
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".csv":
+		return FormatCSV
+	}
+
+	return FormatAuto
+}
+
+// ReadInputFrom opens the configured input, and decodes all records using
+// ReadInput.
+func ReadInputFrom[T any](c *InputConfig) ([]T, error) {
+	r, err := c.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ReadInput[T](r, c.DetectedFormat())
+}
+
+// ReadInput decodes all records from r, in the provided format. If format is
+// empty or FormatAuto, the format is detected from the content. A single
+// JSON/YAML document which is an array is returned as multiple records. CSV
+// input must include a header row, which is mapped to fields using the "json"
+// struct tag (or field name), or map keys.
+func ReadInput[T any](r io.Reader, format string) ([]T, error) {
+	br := bufio.NewReader(r)
+
+	if format == "" || format == FormatAuto {
+		var err error
+
+		format, err = sniffFormat(br)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch format {
+	case FormatJSON, FormatNDJSON:
+		return readJSON[T](br)
+	case FormatYAML:
+		return readYAML[T](br)
+	case FormatCSV:
+		return readCSV[T](br)
+	default:
+		return nil, fmt.Errorf("unsupported input format %q", format)
+	}
+}
+
+// sniffFormat peeks at the start of the input to detect the format.
+func sniffFormat(br *bufio.Reader) (string, error) {
+	peek, err := br.Peek(4096)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", err
+	}
+
+	peek = bytes.TrimSpace(peek)
+	if len(peek) == 0 {
+		return FormatJSON, nil
+	}
+
+	switch peek[0] {
+	case '{':
+		if line, _, _ := bytes.Cut(peek, []byte("\n")); json.Valid(bytes.TrimSpace(line)) {
+			return FormatNDJSON, nil
+		}
+		return FormatJSON, nil
+	case '[':
+		return FormatJSON, nil
+	}
+
+	line, _, _ := bytes.Cut(peek, []byte("\n"))
+	if bytes.HasPrefix(peek, []byte("---")) || bytes.HasPrefix(peek, []byte("- ")) ||
+		(bytes.Contains(line, []byte(": ")) && !bytes.Contains(line, []byte(","))) {
+		return FormatYAML, nil
+	}
+
+	return FormatCSV, nil
+}
+
+// readJSON decodes a stream of JSON values (which covers both regular JSON
+// and NDJSON). Top-level arrays are flattened into records.
+func readJSON[T any](r io.Reader) ([]T, error) {
+	var out []T
+
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode json input: %w", err)
+		}
+
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+			var items []T
+			if err = json.Unmarshal(raw, &items); err != nil {
+				return nil, fmt.Errorf("failed to decode json input: %w", err)
+			}
+			out = append(out, items...)
+			continue
+		}
+
+		var item T
+		if err = json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("failed to decode json input: %w", err)
+		}
+		out = append(out, item)
+	}
+}
+
+// readYAML decodes a stream of YAML documents. Top-level sequences are
+// flattened into records.
+func readYAML[T any](r io.Reader) ([]T, error) {
+	var out []T
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var node yaml.Node
+
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode yaml input: %w", err)
+		}
+
+		if len(node.Content) == 1 && node.Content[0].Kind == yaml.SequenceNode {
+			var items []T
+			if err = node.Decode(&items); err != nil {
+				return nil, fmt.Errorf("failed to decode yaml input: %w", err)
+			}
+			out = append(out, items...)
+			continue
+		}
+
+		var item T
+		if err = node.Decode(&item); err != nil {
+			return nil, fmt.Errorf("failed to decode yaml input: %w", err)
+		}
+		out = append(out, item)
+	}
+}
+
+// readCSV decodes CSV records (with a header row).
+func readCSV[T any](r io.Reader) ([]T, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode csv input: %w", err)
+	}
+
+	var out []T
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode csv input: %w", err)
+		}
+
+		var item T
+		if err = setRecord(reflect.ValueOf(&item).Elem(), header, row); err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("failed to decode csv input (line %d): %w", line, err)
+		}
+		out = append(out, item)
+	}
+}
+
+// setRecord sets the fields (or map keys) of v from the provided CSV header
+// and row.
+func setRecord(v reflect.Value, header, row []string) error {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		for i, key := range header {
+			if i >= len(row) {
+				break
+			}
+
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := setScalar(val, row[i]); err != nil {
+				return fmt.Errorf("column %q: %w", key, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), val)
+		}
+
+		return nil
+	case reflect.Struct:
+		fields := map[string]int{}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fields[strings.ToLower(name)] = i
+		}
+
+		for i, key := range header {
+			idx, ok := fields[strings.ToLower(key)]
+			if !ok || i >= len(row) {
+				continue
+			}
+
+			if err := setScalar(v.Field(idx), row[i]); err != nil {
+				return fmt.Errorf("column %q: %w", key, err)
+			}
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported csv record type %s", v.Type())
+	}
+}
+
+// setScalar converts s into the kind of v.
+func setScalar(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
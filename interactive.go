@@ -0,0 +1,420 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"golang.org/x/term"
+)
+
+// errInteractiveAborted is returned when the user aborts interactive mode
+// (e.g. with Ctrl+D).
+var errInteractiveAborted = errors.New("interactive mode aborted")
+
+// interactiveArg returns true if --interactive was provided, before any "--".
+func interactiveArg(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return false
+		case "--interactive":
+			return true
+		}
+	}
+	return false
+}
+
+// prompter reads answers to prompts from a terminal.
+type prompter struct {
+	in  *bufio.Reader
+	fd  int
+	out *os.File
+}
+
+// printf writes the provided (colorized) output.
+func (p *prompter) printf(format string, args ...any) {
+	fmt.Fprint(p.out, colorize(p.out, fmt.Sprintf(format, args...)))
+}
+
+// line prompts for a single line of input.
+func (p *prompter) line(prompt string) (string, error) {
+	p.printf("%s", prompt)
+
+	s, err := p.in.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			p.printf("\n")
+			return "", errInteractiveAborted
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(s), nil
+}
+
+// secret prompts for a single line of input, without echoing it.
+func (p *prompter) secret(prompt string) (string, error) {
+	p.printf("%s", prompt)
+
+	b, err := term.ReadPassword(p.fd)
+	p.printf("\n")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// confirm prompts for a yes/no answer.
+func (p *prompter) confirm(prompt string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+
+	for {
+		s, err := p.line(fmt.Sprintf("%s <gray>[%s]</> ", prompt, hint))
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(s) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// pick prompts for one of the provided items (by number or name), returning
+// its index, or -1 if skipped (only allowed when optional).
+func (p *prompter) pick(items, descriptions []string, optional bool) (int, error) {
+	for i, item := range items {
+		p.printf("  <cyan>%2d</>) %s", i+1, item)
+		if descriptions != nil && descriptions[i] != "" {
+			p.printf(" <gray>- %s</>", descriptions[i])
+		}
+		p.printf("\n")
+	}
+
+	prompt := "select: "
+	if optional {
+		prompt = "select <gray>(enter to skip)</>: "
+	}
+
+	for {
+		s, err := p.line(prompt)
+		if err != nil {
+			return -1, err
+		}
+
+		if s == "" && optional {
+			return -1, nil
+		}
+
+		if n, nerr := strconv.Atoi(s); nerr == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+
+		for i, item := range items {
+			if strings.EqualFold(item, s) {
+				return i, nil
+			}
+		}
+	}
+}
+
+// interactive prompts for a sub-command, flag values and arguments, returning
+// the composed arguments (excluding the program name). Sub-commands and
+// their flags are always prompted for, while top-level flags are only
+// prompted for when required. Values are validated as they're provided, and
+// the composed arguments are validated as a whole with DryParse.
+func (cli *CLI[T]) interactive(in io.Reader, out *os.File) ([]string, error) {
+	f, ok := in.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return nil, errors.New("--interactive requires a terminal")
+	}
+
+	p := &prompter{in: bufio.NewReader(f), fd: int(f.Fd()), out: out}
+
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+
+	for {
+		args, err := cli.promptArgs(p)
+		if err != nil {
+			return nil, err
+		}
+
+		p.printf("\n<bold>command:</> %s\n", strings.Join(quoteArgs(append([]string{cli.Parser.Name}, cli.RedactArgs(args)...)), " "))
+
+		if err = cli.DryParse(args, env); err != nil {
+			p.printf("<redB>invalid:</> %v\n", err)
+
+			retry, cerr := p.confirm("start over?", true)
+			if cerr != nil {
+				return nil, cerr
+			}
+
+			if !retry {
+				return nil, errInteractiveAborted
+			}
+			continue
+		}
+
+		run, err := p.confirm("run?", true)
+		if err != nil {
+			return nil, err
+		}
+
+		if !run {
+			return nil, errInteractiveAborted
+		}
+
+		p.printf("\n")
+		return args, nil
+	}
+}
+
+// promptArgs runs a single pass of interactive prompts.
+func (cli *CLI[T]) promptArgs(p *prompter) (args []string, err error) {
+	cmd := cli.Parser.Command
+	stack := []*flags.Command{cmd}
+
+	for {
+		var subs []*flags.Command
+		for _, sub := range cmd.Commands() {
+			if !sub.Hidden {
+				subs = append(subs, sub)
+			}
+		}
+
+		if len(subs) == 0 {
+			break
+		}
+
+		names := make([]string, len(subs))
+		descriptions := make([]string, len(subs))
+		for i, sub := range subs {
+			names[i], descriptions[i] = sub.Name, sub.ShortDescription
+		}
+
+		p.printf("\n<bold>%s commands:</>\n", strings.Join(append([]string{cli.Parser.Name}, args...), " "))
+
+		i, err := p.pick(names, descriptions, cmd.SubcommandsOptional || (cmd == cli.Parser.Command && cli.Parser.SubcommandsOptional))
+		if err != nil {
+			return nil, err
+		}
+
+		if i < 0 {
+			break
+		}
+
+		cmd = subs[i]
+		args = append(args, cmd.Name)
+		stack = append(stack, cmd)
+	}
+
+	for i, c := range stack {
+		var options []*flags.Option
+		walkGroupOptions(c.Group, func(option *flags.Option) {
+			if option.Hidden || reflect.TypeOf(option.Value()).Kind() == reflect.Func {
+				return
+			}
+
+			// Top-level flags include all built-in flags, so only prompt for
+			// those which must be provided.
+			if i == 0 && (!option.Required || len(option.Default) > 0 || (option.EnvDefaultKey != "" && getenv(option.EnvKeyWithNamespace()) != "")) {
+				return
+			}
+
+			options = append(options, option)
+		})
+
+		if len(options) == 0 {
+			continue
+		}
+
+		p.printf("\n<bold>%s flags:</>\n", strings.Join(append([]string{cli.Parser.Name}, args[:i]...), " "))
+
+		for _, option := range options {
+			values, err := promptOption(p, option)
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, values...)
+		}
+	}
+
+	var positional []string
+	for _, arg := range cmd.Args() {
+		p.printf("\n")
+
+		prompt := fmt.Sprintf("<cyan>%s</>", arg.Name)
+		if arg.Description != "" {
+			prompt += fmt.Sprintf(" <gray>(%s)</>", arg.Description)
+		}
+
+		for {
+			value, err := p.line(prompt + ": ")
+			if err != nil {
+				return nil, err
+			}
+
+			if value == "" && arg.Required > 0 {
+				continue
+			}
+
+			if value != "" {
+				positional = append(positional, value)
+			}
+			break
+		}
+	}
+
+	if len(positional) > 0 {
+		args = append(args, "--")
+		args = append(args, positional...)
+	}
+
+	return args, nil
+}
+
+// promptOption prompts for the value of the provided option, returning the
+// arguments to provide it (or none, to use the default).
+func promptOption(p *prompter, option *flags.Option) ([]string, error) {
+	name := "--" + option.LongNameWithNamespace()
+	if option.LongName == "" {
+		name = "-" + string(option.ShortName)
+	}
+
+	prompt := fmt.Sprintf("<cyan>%s</>", name)
+	if option.Description != "" {
+		prompt += fmt.Sprintf(" <gray>(%s)</>", option.Description)
+	}
+
+	t := reflect.TypeOf(option.Value())
+
+	if t.Kind() == reflect.Bool && !option.OptionalArgument {
+		def := len(option.Default) > 0 && option.Default[0] == "true"
+
+		// Boolean flags can only be enabled.
+		if def {
+			return nil, nil
+		}
+
+		enabled, err := p.confirm(prompt, false)
+		if err != nil || !enabled {
+			return nil, err
+		}
+
+		return []string{name}, nil
+	}
+
+	repeatable := t.Kind() == reflect.Slice || t.Kind() == reflect.Map
+
+	if len(option.Default) > 0 {
+		prompt += fmt.Sprintf(" <gray>[default: %s]</>", strings.Join(option.Default, ", "))
+	} else if repeatable {
+		prompt += " <gray>[repeatable, enter to finish]</>"
+	}
+
+	var out []string
+
+	for {
+		var value string
+		var err error
+
+		switch {
+		case len(option.Choices) > 0:
+			p.printf("%s\n", prompt)
+
+			var i int
+			i, err = p.pick(option.Choices, nil, !option.Required || len(option.Default) > 0 || len(out) > 0)
+			if i >= 0 {
+				value = option.Choices[i]
+			}
+		case isSecretOption(option):
+			value, err = p.secret(prompt + ": ")
+		default:
+			value, err = p.line(prompt + ": ")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value == "" {
+			if option.Required && len(option.Default) == 0 && len(out) == 0 {
+				continue
+			}
+			return out, nil
+		}
+
+		if verr := validateOptionValue(option, value); verr != nil {
+			p.printf("<redB>invalid:</> %v\n", verr)
+			continue
+		}
+
+		out = append(out, name+"="+value)
+
+		if !repeatable {
+			return out, nil
+		}
+	}
+}
+
+// validateOptionValue validates the provided value for an option, by parsing
+// it into a new value of the option's type (using the same unmarshalling as
+// the parser).
+func validateOptionValue(option *flags.Option, value string) error {
+	t := reflect.TypeOf(option.Value())
+
+	v := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: t,
+		Tag:  `long:"v"`,
+	}}))
+
+	_, err := flags.NewParser(v.Interface(), flags.None).ParseArgs([]string{"--v=" + value})
+	if err != nil {
+		return fmt.Errorf("expected %s", expectedValue(t))
+	}
+
+	return nil
+}
+
+// quoteArgs quotes the provided arguments for display, where needed.
+func quoteArgs(args []string) []string {
+	out := make([]string, len(args))
+
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+			out[i] = ShellQuote(arg)
+			continue
+		}
+
+		out[i] = arg
+	}
+
+	return out
+}
@@ -0,0 +1,42 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// newInvocationID returns a random (version 4) UUID.
+func newInvocationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4.
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant.
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// InvocationID returns the unique ID (a random UUID) of the current run,
+// generated when Parse() is invoked. It's included in all log entries (as the
+// "invocation_id" field), lifecycle events (see Events), the result envelope
+// (see --result-json), exit events (see WithExitHook), and the command history
+// (see OptEnableHistory), so artifacts of a single run can be correlated
+// across systems. Returns an empty string before Parse().
+func (cli *CLI[T]) InvocationID() string {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	return cli.invocationID
+}
+
+// InvocationIDFrom returns the invocation ID carried by the provided context
+// (see CLI.Context and CLI.InvocationID), or an empty string if there is none.
+func InvocationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(contextInvocationID).(string)
+	return id
+}
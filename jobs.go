@@ -0,0 +1,449 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Worker is a long-running unit of work, managed by Jobs.
+type Worker interface {
+	// Start runs the worker, blocking until the provided context is cancelled
+	// or the worker fails. Returning an error (or panicking) restarts the
+	// worker, returning nil marks it as finished.
+	Start(ctx context.Context) error
+
+	// Stop gracefully stops the worker, before the provided context is
+	// cancelled. It is invoked during shutdown (in dependency order, see
+	// JobDependsOn), while the worker is running, after which the context
+	// provided to Start is cancelled.
+	Stop(ctx context.Context) error
+}
+
+// WorkerFunc is a Worker which only has to listen to the provided context to
+// be stopped.
+type WorkerFunc func(ctx context.Context) error
+
+// Start invokes the function.
+func (fn WorkerFunc) Start(ctx context.Context) error { return fn(ctx) }
+
+// Stop is a no-op, as the function is stopped by cancelling its context.
+func (fn WorkerFunc) Stop(_ context.Context) error { return nil }
+
+// JobState is the state of a worker managed by Jobs.
+type JobState string
+
+const (
+	JobPending    JobState = "pending"    // Not yet started.
+	JobRunning    JobState = "running"    // Running.
+	JobRestarting JobState = "restarting" // Failed, and waiting to be restarted.
+	JobFinished   JobState = "finished"   // Returned without error, or stopped.
+	JobFailed     JobState = "failed"     // Exceeded the maximum number of restarts.
+)
+
+// JobStatus is the status of a worker managed by Jobs.
+type JobStatus struct {
+	Name      string    `json:"name"`
+	State     JobState  `json:"state"`
+	Restarts  int       `json:"restarts"`
+	LastError string    `json:"last_error,omitempty"`
+	Started   time.Time `json:"started"`
+}
+
+// JobsConfig are the flags used to configure how workers managed by Jobs are
+// restarted and stopped.
+//
+// Example (where you can set JOBS_RESTART_BACKOFF as an environment variable,
+// for example):
+//
+//	type Flags struct {
+//		Jobs clix.JobsConfig `group:"Job Options" namespace:"jobs" env-namespace:"JOBS"`
+//	}
+//	[...]
+//	jobs := cli.Flags.Jobs.New()
+//	jobs.Register("queue", &QueueWorker{})
+//	jobs.Register("cleanup", clix.WorkerFunc(cleanup))
+//	mux.Handle("/healthz", jobs)
+//	err := clix.RunCtx(cli.Context(context.Background()), jobs.Runner(), cli.Flags.HTTP.Serve(srv, cli.Logger))
+type JobsConfig struct {
+	// RestartBackoff is the delay before restarting a failed worker, which
+	// doubles after each consecutive failure.
+	RestartBackoff time.Duration `env:"RESTART_BACKOFF" long:"restart-backoff" default:"1s" description:"initial delay before restarting a failed worker"`
+
+	// MaxRestartBackoff is the maximum delay before restarting a failed worker.
+	// Workers which ran for at least this long before failing have their delay
+	// reset.
+	MaxRestartBackoff time.Duration `env:"MAX_RESTART_BACKOFF" long:"max-restart-backoff" default:"1m" description:"maximum delay before restarting a failed worker"`
+
+	// MaxRestarts is the maximum number of times a worker is restarted, before
+	// all workers are stopped.
+	MaxRestarts int `env:"MAX_RESTARTS" long:"max-restarts" default:"0" description:"maximum number of restarts per worker (0 is unlimited)"`
+
+	// StopTimeout is the maximum amount of time to wait for workers to stop.
+	StopTimeout time.Duration `env:"STOP_TIMEOUT" long:"stop-timeout" default:"30s" description:"time to wait for workers to stop when shutting down"`
+}
+
+// New returns a new Jobs, using the provided flags.
+func (c *JobsConfig) New() *Jobs {
+	return &Jobs{config: *c}
+}
+
+// Jobs runs workers under a managed context, with panic recovery and restart
+// backoff, and reports their status (e.g. for health checks). Use
+// JobsConfig.New to create one.
+type Jobs struct {
+	config JobsConfig
+
+	mu   sync.Mutex
+	jobs []*job
+}
+
+type job struct {
+	name        string
+	worker      Worker
+	status      JobStatus
+	dependsOn   []string
+	stopTimeout time.Duration
+}
+
+// Register registers a worker with the provided name, and options (e.g.
+// JobDependsOn). Workers must be registered before invoking the runner
+// returned by Runner.
+func (j *Jobs) Register(name string, worker Worker, opts ...JobOption) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	jb := &job{
+		name:   name,
+		worker: worker,
+		status: JobStatus{Name: name, State: JobPending},
+	}
+
+	for _, opt := range opts {
+		opt(jb)
+	}
+
+	j.jobs = append(j.jobs, jb)
+}
+
+// Runner returns a Runner which runs all registered workers until the context
+// is cancelled (e.g. when a termination signal is received through Run), and
+// then stops them, in dependency order (see JobDependsOn and StopOrder).
+// Workers log through the logger of the context (see LoggerFrom). An error is
+// returned if the dependencies are invalid, a worker exceeds the maximum
+// number of restarts, or workers fail to stop in time (see StopError).
+func (j *Jobs) Runner() Runner {
+	return func(ctx context.Context) error {
+		j.mu.Lock()
+		jobs := j.jobs
+		j.mu.Unlock()
+
+		if _, err := stopOrder(jobs); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			errMu  sync.Mutex
+			runErr error
+		)
+
+		// Each worker has its own context, so workers can be stopped in order.
+		workers := make(map[*job]*runningJob, len(jobs))
+
+		for _, jb := range jobs {
+			jctx, jcancel := context.WithCancel(context.WithoutCancel(ctx))
+			rj := &runningJob{cancel: jcancel, done: make(chan struct{})}
+			workers[jb] = rj
+
+			go func() {
+				defer close(rj.done)
+
+				if err := j.run(jctx, jb); err != nil {
+					errMu.Lock()
+					runErr = errors.Join(runErr, err)
+					errMu.Unlock()
+					cancel()
+				}
+			}()
+		}
+
+		<-ctx.Done()
+		stopErr := j.stop(ctx, jobs, workers)
+
+		errMu.Lock()
+		defer errMu.Unlock()
+
+		return errors.Join(runErr, stopErr)
+	}
+}
+
+// run runs the provided job, restarting it with backoff when it fails.
+func (j *Jobs) run(ctx context.Context, jb *job) error {
+	logger := LoggerFrom(ctx).WithField("worker", jb.name)
+	backoff := j.config.RestartBackoff
+
+	for {
+		started := time.Now()
+		j.update(jb, func(s *JobStatus) {
+			s.State = JobRunning
+			s.Started = started
+		})
+
+		logger.Debug("starting worker")
+		err := startWorker(ctx, jb.worker)
+
+		if ctx.Err() != nil || err == nil {
+			j.update(jb, func(s *JobStatus) { s.State = JobFinished })
+			logger.Debug("worker finished")
+			return nil
+		}
+
+		var restarts int
+		j.update(jb, func(s *JobStatus) {
+			s.Restarts++
+			s.LastError = err.Error()
+			restarts = s.Restarts
+		})
+
+		if j.config.MaxRestarts > 0 && restarts > j.config.MaxRestarts {
+			j.update(jb, func(s *JobStatus) { s.State = JobFailed })
+			return fmt.Errorf("worker %q failed after %d restarts: %w", jb.name, j.config.MaxRestarts, err)
+		}
+
+		if time.Since(started) >= j.config.MaxRestartBackoff {
+			backoff = j.config.RestartBackoff
+		}
+
+		j.update(jb, func(s *JobStatus) { s.State = JobRestarting })
+
+		entry := logger.WithError(err).WithField("backoff", backoff)
+
+		var wp *workerPanic
+		if errors.As(err, &wp) {
+			entry = entry.WithField("stack", string(wp.stack))
+		}
+
+		entry.Warn("worker failed, restarting")
+
+		select {
+		case <-ctx.Done():
+			j.update(jb, func(s *JobStatus) { s.State = JobFinished })
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, j.config.MaxRestartBackoff)
+	}
+}
+
+// runningJob is a job started by Runner.
+type runningJob struct {
+	cancel context.CancelFunc
+	done   chan struct{} // Closed once the worker returned.
+}
+
+// stop stops all workers, in dependency order (workers are stopped once all
+// workers depending on them have stopped, or failed to stop), waiting at most
+// the configured stop timeout overall, and the stop timeout of each worker
+// (see JobStopTimeout). Returns a *StopError if any workers failed to stop.
+func (j *Jobs) stop(ctx context.Context, jobs []*job, workers map[*job]*runningJob) error {
+	logger := LoggerFrom(ctx)
+	deadline := time.Now().Add(j.config.StopTimeout)
+
+	stopped := make(map[string]chan struct{}, len(jobs))
+	dependents := make(map[string][]string, len(jobs))
+
+	for _, jb := range jobs {
+		stopped[jb.name] = make(chan struct{})
+
+		for _, dep := range jb.dependsOn {
+			dependents[dep] = append(dependents[dep], jb.name)
+		}
+	}
+
+	results := make([]StopResult, len(jobs))
+
+	var wg sync.WaitGroup
+
+	for i, jb := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(stopped[jb.name])
+
+			for _, name := range dependents[jb.name] {
+				<-stopped[name]
+			}
+
+			results[i] = j.stopJob(jb, workers[jb], deadline)
+
+			entry := logger.WithField("worker", jb.name).WithField("duration", results[i].Duration)
+
+			switch {
+			case results[i].TimedOut:
+				entry.Error("worker didn't stop in time")
+			case results[i].Err != nil:
+				entry.WithError(results[i].Err).Error("failed to stop worker")
+			default:
+				entry.Debug("worker stopped")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var failed []StopResult
+	for _, r := range results {
+		if r.Err != nil || r.TimedOut {
+			failed = append(failed, r)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &StopError{Results: failed}
+	}
+
+	return nil
+}
+
+// stopJob gracefully stops the provided worker (if running), cancels its
+// context, and waits for it to return, until the provided deadline or the
+// worker's stop timeout, whichever is earlier.
+func (j *Jobs) stopJob(jb *job, rj *runningJob, deadline time.Time) StopResult {
+	started := time.Now()
+
+	if d := started.Add(jb.stopTimeout); jb.stopTimeout > 0 && d.Before(deadline) {
+		deadline = d
+	}
+
+	sctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	j.mu.Lock()
+	running := jb.status.State == JobRunning
+	j.mu.Unlock()
+
+	result := StopResult{Name: jb.name}
+
+	if running {
+		stopErr := make(chan error, 1)
+		go func() { stopErr <- jb.worker.Stop(sctx) }()
+
+		select {
+		case result.Err = <-stopErr:
+		case <-sctx.Done():
+		}
+	}
+
+	rj.cancel()
+
+	select {
+	case <-rj.done:
+	case <-sctx.Done():
+		result.TimedOut = true
+	}
+
+	result.Duration = time.Since(started)
+	return result
+}
+
+// workerPanic is a recovered worker panic.
+type workerPanic struct {
+	value any
+	stack []byte
+}
+
+func (e *workerPanic) Error() string {
+	return fmt.Sprintf("worker panic: %v", e.value)
+}
+
+// startWorker starts the provided worker, converting panics into errors.
+func startWorker(ctx context.Context, worker Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &workerPanic{value: r, stack: debug.Stack()}
+		}
+	}()
+
+	return worker.Start(ctx)
+}
+
+// update updates the status of the provided job.
+func (j *Jobs) update(jb *job, fn func(s *JobStatus)) {
+	j.mu.Lock()
+	fn(&jb.status)
+	j.mu.Unlock()
+}
+
+// Status returns the status of the worker with the provided name, or a
+// zero-value status if no such worker is registered.
+func (j *Jobs) Status(name string) JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, jb := range j.jobs {
+		if jb.name == name {
+			return jb.status
+		}
+	}
+
+	return JobStatus{}
+}
+
+// Statuses returns the status of all registered workers, in the order they
+// were registered.
+func (j *Jobs) Statuses() []JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out := make([]JobStatus, 0, len(j.jobs))
+	for _, jb := range j.jobs {
+		out = append(out, jb.status)
+	}
+
+	return out
+}
+
+// Healthy returns true if no registered workers are restarting or have failed.
+func (j *Jobs) Healthy() bool {
+	for _, s := range j.Statuses() {
+		if s.State == JobRestarting || s.State == JobFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ServeHTTP implements http.Handler, so Jobs can be used as a health check
+// endpoint. It responds with the status of all workers as JSON, with a 503
+// status code if any are unhealthy (see Healthy).
+func (j *Jobs) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	code := http.StatusOK
+	status := "ok"
+
+	if !j.Healthy() {
+		code = http.StatusServiceUnavailable
+		status = "unhealthy"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"status": status,
+		"jobs":   j.Statuses(),
+	})
+}
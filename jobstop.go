@@ -0,0 +1,151 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JobOption configures a worker registered with Jobs.Register.
+type JobOption func(jb *job)
+
+// JobDependsOn declares that the worker depends on the workers with the
+// provided names, so it's stopped before them during shutdown. Workers without
+// dependencies between them are stopped concurrently.
+//
+// Example (the HTTP server is stopped first, then the database, and the log
+// shipper last):
+//
+//	jobs.Register("logs", logShipper)
+//	jobs.Register("db", dbPool, clix.JobDependsOn("logs"))
+//	jobs.Register("http", httpServer, clix.JobDependsOn("db", "logs"))
+func JobDependsOn(names ...string) JobOption {
+	return func(jb *job) {
+		jb.dependsOn = append(jb.dependsOn, names...)
+	}
+}
+
+// JobStopTimeout sets the maximum amount of time to wait for the worker to
+// stop. The overall stop timeout (see JobsConfig.StopTimeout) still applies.
+func JobStopTimeout(timeout time.Duration) JobOption {
+	return func(jb *job) {
+		jb.stopTimeout = timeout
+	}
+}
+
+// StopResult is the outcome of stopping a worker managed by Jobs.
+type StopResult struct {
+	Name     string
+	Duration time.Duration
+
+	// Err is the error returned by Worker.Stop, if any.
+	Err error
+
+	// TimedOut is true if the worker didn't return from Worker.Start before
+	// its stop timeout.
+	TimedOut bool
+}
+
+// StopError is returned by the runner of Jobs when workers fail to stop, or
+// don't stop in time, and reports which.
+type StopError struct {
+	// Results are the results of the workers which failed to stop, in
+	// registration order.
+	Results []StopResult
+}
+
+func (e *StopError) Error() string {
+	failed := make([]string, 0, len(e.Results))
+
+	for _, r := range e.Results {
+		if r.TimedOut {
+			failed = append(failed, fmt.Sprintf("%s (timed out after %s)", r.Name, r.Duration.Round(time.Millisecond)))
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s (%v)", r.Name, r.Err))
+	}
+
+	return fmt.Sprintf("%d worker(s) didn't stop cleanly: %s", len(e.Results), strings.Join(failed, ", "))
+}
+
+// StopOrder returns the names of the registered workers, in the order they're
+// stopped during shutdown: each stage is stopped (concurrently) once the
+// previous stage has stopped. Returns an error if a worker depends on an
+// unknown worker, or the dependencies contain a cycle.
+func (j *Jobs) StopOrder() ([][]string, error) {
+	j.mu.Lock()
+	jobs := j.jobs
+	j.mu.Unlock()
+
+	return stopOrder(jobs)
+}
+
+// stopOrder returns the stop stages of the provided jobs (see StopOrder).
+func stopOrder(jobs []*job) ([][]string, error) {
+	known := make(map[string]bool, len(jobs))
+	for _, jb := range jobs {
+		if known[jb.name] {
+			return nil, fmt.Errorf("worker %q is registered more than once", jb.name)
+		}
+		known[jb.name] = true
+	}
+
+	// Number of workers depending on each worker, which must be stopped first.
+	pending := make(map[string]int, len(jobs))
+
+	for _, jb := range jobs {
+		for _, dep := range jb.dependsOn {
+			if !known[dep] {
+				return nil, fmt.Errorf("worker %q depends on unknown worker %q", jb.name, dep)
+			}
+			if dep == jb.name {
+				return nil, fmt.Errorf("worker %q depends on itself", jb.name)
+			}
+			pending[dep]++
+		}
+	}
+
+	var stages [][]string
+
+	done := make(map[string]bool, len(jobs))
+
+	for len(done) < len(jobs) {
+		var stage []string
+
+		for _, jb := range jobs {
+			if !done[jb.name] && pending[jb.name] == 0 {
+				stage = append(stage, jb.name)
+			}
+		}
+
+		if len(stage) == 0 {
+			var cycle []string
+			for _, jb := range jobs {
+				if !done[jb.name] {
+					cycle = append(cycle, jb.name)
+				}
+			}
+			return nil, fmt.Errorf("workers have a dependency cycle: %s", strings.Join(cycle, ", "))
+		}
+
+		for _, jb := range jobs {
+			if !slices.Contains(stage, jb.name) {
+				continue
+			}
+
+			done[jb.name] = true
+			for _, dep := range jb.dependsOn {
+				pending[dep]--
+			}
+		}
+
+		stages = append(stages, stage)
+	}
+
+	return stages, nil
+}
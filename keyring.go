@@ -0,0 +1,250 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Sentinel errors returned by Keyring implementations.
+var (
+	ErrKeyringNotFound    = errors.New("clix: keyring entry not found")
+	ErrKeyringUnavailable = errors.New("clix: no keyring available")
+)
+
+// Keyring stores secrets (e.g. tokens, and the key used to encrypt secret
+// configuration values) outside of configuration files. Get returns
+// ErrKeyringNotFound if the entry doesn't exist.
+type Keyring interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// WithKeyring uses the provided keyring, instead of the system keyring (see
+// SystemKeyring), e.g. FileKeyring on headless systems, or a keyring backed
+// by a secrets manager.
+func WithKeyring(keyring Keyring) Option {
+	return func(s *settings) error {
+		if keyring == nil {
+			return errors.New("WithKeyring: keyring is nil")
+		}
+
+		if s.keyring != nil {
+			return errors.New("WithKeyring: keyring already configured")
+		}
+
+		s.keyring = keyring
+		return nil
+	}
+}
+
+// Keyring returns the application's keyring, which is the one provided with
+// WithKeyring, or the system keyring (see SystemKeyring), using the
+// application name (see AppName) as the service name.
+func (cli *CLI[T]) Keyring() Keyring {
+	cli.mu.Lock()
+	keyring := cli.settings.keyring
+	cli.mu.Unlock()
+
+	if keyring != nil {
+		return keyring
+	}
+
+	return SystemKeyring(cli.AppName())
+}
+
+var (
+	defaultKeyringMu sync.RWMutex
+	defaultKeyring   Keyring = unavailableKeyring{}
+)
+
+// DefaultKeyring returns the keyring of the most recently parsed CLI (see
+// CLI.Keyring), used by all clix-provided clients. Before a CLI is parsed,
+// all operations return ErrKeyringUnavailable.
+func DefaultKeyring() Keyring {
+	defaultKeyringMu.RLock()
+	defer defaultKeyringMu.RUnlock()
+
+	return defaultKeyring
+}
+
+// setDefaultKeyring sets the keyring returned by DefaultKeyring.
+func setDefaultKeyring(k Keyring) {
+	defaultKeyringMu.Lock()
+	defaultKeyring = k
+	defaultKeyringMu.Unlock()
+}
+
+// unavailableKeyring is the keyring used before a CLI is parsed.
+type unavailableKeyring struct{}
+
+func (unavailableKeyring) Get(string) (string, error) { return "", ErrKeyringUnavailable }
+func (unavailableKeyring) Set(string, string) error   { return ErrKeyringUnavailable }
+func (unavailableKeyring) Delete(string) error        { return ErrKeyringUnavailable }
+
+// SystemKeyring returns a keyring backed by the OS keychain, with entries
+// stored under the provided service name. On macOS, this uses the login
+// keychain (through the "security" tool), and on other unix-like systems, the
+// Secret Service (through the "secret-tool" tool, from libsecret). If the tool
+// isn't installed, or on other systems (e.g. Windows), all operations return
+// ErrKeyringUnavailable (use WithKeyring to provide an alternative).
+func SystemKeyring(service string) Keyring {
+	return &systemKeyring{service: service}
+}
+
+// systemKeyring is the keyring returned by SystemKeyring.
+type systemKeyring struct {
+	service string
+}
+
+// run invokes the keyring tool with the provided arguments and stdin,
+// returning its trimmed stdout, and exit code (-1 if it didn't run).
+func (k *systemKeyring) run(stdin string, args ...string) (string, int, error) {
+	var name string
+
+	switch runtime.GOOS {
+	case "darwin":
+		name = "security"
+	case "windows", "plan9", "js", "wasip1":
+		return "", -1, ErrKeyringUnavailable
+	default:
+		name = "secret-tool"
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", -1, fmt.Errorf("%w: %s not found", ErrKeyringUnavailable, name)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(path, args...) //nolint:gosec
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", exitErr.ExitCode(), fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return "", -1, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), 0, nil
+}
+
+// Get implements Keyring.
+func (k *systemKeyring) Get(key string) (string, error) {
+	var out string
+	var code int
+	var err error
+
+	if runtime.GOOS == "darwin" {
+		out, code, err = k.run("", "find-generic-password", "-s", k.service, "-a", key, "-w")
+		if code == 44 { // errSecItemNotFound.
+			return "", ErrKeyringNotFound
+		}
+	} else {
+		out, code, err = k.run("", "lookup", "service", k.service, "account", key)
+		if code == 1 && out == "" {
+			return "", ErrKeyringNotFound
+		}
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read keyring entry %q: %w", key, err)
+	}
+
+	return out, nil
+}
+
+// Set implements Keyring.
+func (k *systemKeyring) Set(key, value string) error {
+	var err error
+
+	if runtime.GOOS == "darwin" {
+		// With -w as the last argument, the password is prompted for (twice),
+		// and read from stdin, so it isn't exposed in the process list.
+		_, _, err = k.run(value+"\n"+value+"\n", "add-generic-password", "-U", "-s", k.service, "-a", key, "-w")
+	} else {
+		_, _, err = k.run(value, "store", "--label", k.service+" "+key, "service", k.service, "account", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write keyring entry %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// Delete implements Keyring. Deleting a missing entry isn't an error.
+func (k *systemKeyring) Delete(key string) error {
+	var code int
+	var err error
+
+	if runtime.GOOS == "darwin" {
+		_, code, err = k.run("", "delete-generic-password", "-s", k.service, "-a", key)
+		if code == 44 {
+			return nil
+		}
+	} else {
+		_, _, err = k.run("", "clear", "service", k.service, "account", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete keyring entry %q: %w", key, err)
+	}
+
+	return nil
+}
+
+// FileKeyring returns a keyring persisted as JSON to the provided path (see
+// OpenState), which is created with 0600 permissions. This is meant for
+// systems without an OS keychain (e.g. containers and CI), and only protects
+// secrets as well as the file's permissions do.
+func FileKeyring(path string) Keyring {
+	return &fileKeyring{state: OpenState(path)}
+}
+
+// fileKeyring is the keyring returned by FileKeyring.
+type fileKeyring struct {
+	state *State
+}
+
+// Get implements Keyring.
+func (k *fileKeyring) Get(key string) (string, error) {
+	var value string
+
+	ok, err := k.state.Get(key, &value)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", ErrKeyringNotFound
+	}
+
+	return value, nil
+}
+
+// Set implements Keyring.
+func (k *fileKeyring) Set(key, value string) error {
+	return k.state.Set(key, value)
+}
+
+// Delete implements Keyring.
+func (k *fileKeyring) Delete(key string) error {
+	return k.state.Delete(key)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type k8sMetadata struct {
+	Name string `yaml:"name"`
+}
+
+type k8sConfigMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Data       map[string]string `yaml:"data,omitempty"`
+}
+
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+type k8sKeyRef struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+}
+
+type k8sFieldRef struct {
+	FieldPath string `yaml:"fieldPath"`
+}
+
+type k8sEnvSource struct {
+	ConfigMapKeyRef *k8sKeyRef   `yaml:"configMapKeyRef,omitempty"`
+	SecretKeyRef    *k8sKeyRef   `yaml:"secretKeyRef,omitempty"`
+	FieldRef        *k8sFieldRef `yaml:"fieldRef,omitempty"`
+}
+
+type k8sEnvVar struct {
+	Name      string        `yaml:"name"`
+	ValueFrom *k8sEnvSource `yaml:"valueFrom"`
+}
+
+// KubernetesManifests writes a Kubernetes ConfigMap and Secret (named name,
+// defaulting to AppName) to out, containing the resolved configuration (see
+// ResolvedEnv), using the options' environment variable names as keys. Secret
+// options (see RedactArgs) are placed in the Secret, and all others in the
+// ConfigMap. Options without a value are omitted. A matching container "env"
+// stanza is included as a trailing comment. Must be called after Parse().
+func (cli *CLI[T]) KubernetesManifests(out io.Writer, name string) error {
+	if name == "" {
+		name = cli.AppName()
+	}
+
+	cm := &k8sConfigMap{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sMetadata{Name: name},
+		Data:       map[string]string{},
+	}
+
+	secret := &k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sMetadata{Name: name},
+		Type:       "Opaque",
+		StringData: map[string]string{},
+	}
+
+	var env []k8sEnvVar
+
+	for _, v := range cli.ResolvedEnv() {
+		if v.Value == "" {
+			continue
+		}
+
+		ref := &k8sKeyRef{Name: name, Key: v.Name}
+
+		if v.Secret {
+			secret.StringData[v.Name] = v.Value
+			env = append(env, k8sEnvVar{Name: v.Name, ValueFrom: &k8sEnvSource{SecretKeyRef: ref}})
+			continue
+		}
+
+		cm.Data[v.Name] = v.Value
+		env = append(env, k8sEnvVar{Name: v.Name, ValueFrom: &k8sEnvSource{ConfigMapKeyRef: ref}})
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(cm); err != nil {
+		return fmt.Errorf("failed to encode configmap: %w", err)
+	}
+
+	if len(secret.StringData) > 0 {
+		if err := enc.Encode(secret); err != nil {
+			return fmt.Errorf("failed to encode secret: %w", err)
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if len(env) > 0 {
+		var stanza bytes.Buffer
+
+		enc = yaml.NewEncoder(&stanza)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(map[string][]k8sEnvVar{"env": env}); err != nil {
+			return fmt.Errorf("failed to encode container env: %w", err)
+		}
+
+		if err := enc.Close(); err != nil {
+			return err
+		}
+
+		buf.WriteString("# container env stanza:\n#\n")
+		for _, line := range strings.Split(strings.TrimSuffix(stanza.String(), "\n"), "\n") {
+			buf.WriteString("#   " + line + "\n")
+		}
+	}
+
+	_, err := buf.WriteTo(out)
+	return err
+}
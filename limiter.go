@@ -0,0 +1,152 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// LimitConfig are the flags used to throttle concurrent and/or rate-limited
+// work, such that batch tools have consistent throttling controls.
+//
+// Example (where you can set CONCURRENCY as an environment variable, for example):
+//
+//	type Flags struct {
+//		Limits clix.LimitConfig `group:"Limit Options"`
+//	}
+//	[...]
+//	limiter := cli.Flags.Limits.Limiter()
+//	g, ctx := cli.Flags.Limits.Group(ctx)
+//	for _, item := range items {
+//		g.Go(func() error {
+//			if err := limiter.Wait(ctx); err != nil {
+//				return err
+//			}
+//			return process(ctx, item)
+//		})
+//	}
+//	err := g.Wait()
+type LimitConfig struct {
+	// Concurrency is the maximum number of concurrent operations.
+	Concurrency int `env:"CONCURRENCY" long:"concurrency" default:"4" description:"maximum number of concurrent operations (0 is unlimited)"`
+
+	// RateLimit is the maximum number of operations per second.
+	RateLimit float64 `env:"RATE_LIMIT" long:"rate-limit" default:"0" description:"maximum number of operations per second (0 is unlimited)"`
+
+	// RateBurst is the maximum number of operations which can happen at once,
+	// when rate limiting.
+	RateBurst int `env:"RATE_BURST" long:"rate-burst" default:"1" description:"maximum burst of operations when rate limiting"`
+
+	once    sync.Once
+	limiter *Limiter
+}
+
+// Limiter returns the token-bucket limiter generated from the provided flags.
+// The same limiter is returned on subsequent calls.
+func (c *LimitConfig) Limiter() *Limiter {
+	c.once.Do(func() {
+		c.limiter = NewLimiter(c.RateLimit, c.RateBurst)
+	})
+
+	return c.limiter
+}
+
+// Group returns a new errgroup.Group (and associated context), limited to the
+// configured concurrency.
+func (c *LimitConfig) Group(ctx context.Context) (*errgroup.Group, context.Context) {
+	g, ctx := errgroup.WithContext(ctx)
+
+	if c.Concurrency > 0 {
+		g.SetLimit(c.Concurrency)
+	}
+
+	return g, ctx
+}
+
+// Limiter is a token-bucket rate limiter, which is safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a new limiter allowing rate operations per second, with
+// bursts of up to burst operations. A rate of 0 (or less) means unlimited.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// advance refills the bucket based on the time elapsed since the last call.
+// l.mu must be held.
+func (l *Limiter) advance(now time.Time) {
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// Allow reports whether an operation may happen now, consuming a token if so.
+func (l *Limiter) Allow() bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(time.Now())
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Wait blocks until an operation may happen, or the context is cancelled.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	l.advance(time.Now())
+	l.tokens-- // Reserve a token, which may put the bucket into debt.
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved token.
+		l.mu.Lock()
+		l.tokens = math.Min(l.burst, l.tokens+1)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
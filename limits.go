@@ -0,0 +1,189 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+const (
+	// DefaultMaxConfigFileSize is the default maximum size of configuration
+	// files (e.g. .env), in bytes. See WithConfigLimits.
+	DefaultMaxConfigFileSize = 1 << 20 // 1MiB.
+
+	// DefaultMaxEnvValueLength is the default maximum length of environment
+	// variable values used by flags, in bytes. See WithConfigLimits.
+	DefaultMaxEnvValueLength = 64 << 10 // 64KiB.
+)
+
+// ErrConfigLimit is returned (wrapped) when a configuration file, or an
+// environment variable value, exceeds the configured limits. See
+// WithConfigLimits.
+var ErrConfigLimit = errors.New("clix: configuration limit exceeded")
+
+// WithConfigLimits overrides the maximum size of configuration files (e.g.
+// .env, DefaultMaxConfigFileSize), and the maximum length of environment
+// variable values used by flags (DefaultMaxEnvValueLength), in bytes. Zero
+// uses the default, and a negative value disables the limit.
+func WithConfigLimits(maxFileSize, maxValueLength int) Option {
+	return func(s *settings) error {
+		s.maxConfigFileSize = maxFileSize
+		s.maxEnvValueLength = maxValueLength
+		return nil
+	}
+}
+
+// limit returns the provided limit, or def if zero.
+func limit[N int | int64](v, def N) N {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// checkEnvLimits returns an error if any environment variable used by a flag
+// exceeds the maximum value length.
+func (cli *CLI[T]) checkEnvLimits() error {
+	maxLen := limit(cli.settings.maxEnvValueLength, DefaultMaxEnvValueLength)
+	if maxLen < 0 || cli.Parser == nil {
+		return nil
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		key := option.EnvKeyWithNamespace()
+		if option.EnvDefaultKey == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+
+		value, ok := os.LookupEnv(key)
+		if !ok || len(value) <= maxLen {
+			return
+		}
+
+		err := fmt.Errorf("%w: environment variable %s is %d bytes (maximum %d)", ErrConfigLimit, key, len(value), maxLen)
+		errs = append(errs, envSourceError(key, err))
+	})
+
+	return errors.Join(errs...)
+}
+
+// envSourceError wraps the provided error in a *ConfigError, if the provided
+// environment variable was loaded from a .env file.
+func envSourceError(key string, err error) error {
+	file, ok := dotEnvSources.Load(key)
+	if !ok {
+		return err
+	}
+
+	ce := &ConfigError{File: file.(string), Err: err}
+	if data, rerr := os.ReadFile(ce.File); rerr == nil {
+		ce.Line = dotEnvKeyLine(data, key)
+	}
+
+	return ce
+}
+
+// valueError is a type coercion error with an operator-friendly message,
+// which wraps the original go-flags error.
+type valueError struct {
+	msg string
+	err error
+}
+
+func (e *valueError) Error() string { return e.msg }
+
+func (e *valueError) Unwrap() error { return e.err }
+
+// describeValueError replaces the message of type coercion errors (e.g. an
+// invalid integer), with one which describes where the value came from (flag,
+// environment variable, or .env file, including the line number), and what was
+// expected. Other errors are returned as-is.
+func (cli *CLI[T]) describeValueError(err error) error {
+	var ferr *FlagError
+	if !errors.As(err, &ferr) || ferr.Type != flags.ErrMarshal || cli.Parser == nil {
+		return err
+	}
+
+	var option *flags.Option
+	walkOptions(cli.Parser.Command, func(o *flags.Option) {
+		if option == nil && (ferr.Flag == "--"+o.LongNameWithNamespace() || ferr.Flag == "-"+string(o.ShortName)) {
+			option = o
+		}
+	})
+
+	if option == nil {
+		return err
+	}
+
+	expected := expectedValue(reflect.TypeOf(option.Value()))
+	if strings.Contains(ferr.Err.Error(), strconv.ErrRange.Error()) {
+		expected += " (value out of range)"
+	}
+
+	key := option.EnvKeyWithNamespace()
+	value, envSet := os.LookupEnv(key)
+
+	if option.EnvDefaultKey == "" || !envSet || !option.IsSetDefault() {
+		return &FlagError{
+			Flag: ferr.Flag,
+			Type: ferr.Type,
+			Err: &valueError{
+				msg: fmt.Sprintf("invalid value for flag %s: expected %s", ferr.Flag, expected),
+				err: ferr.Err,
+			},
+		}
+	}
+
+	return envSourceError(key, &FlagError{
+		Flag: ferr.Flag,
+		Type: ferr.Type,
+		Err: &valueError{
+			msg: fmt.Sprintf("invalid value %q for environment variable %s (flag %s): expected %s", value, key, ferr.Flag, expected),
+			err: ferr.Err,
+		},
+	})
+}
+
+// expectedValue describes the values accepted by the provided type.
+func expectedValue(t reflect.Type) string {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return "a valid value"
+	}
+
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "a duration (e.g. 30s, 5m, 1h30m)"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Map:
+		return "KEY:VALUE pairs"
+	default:
+		return "a valid " + t.String()
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// listenFDStart is the first file descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START).
+const listenFDStart = 3
+
+// ListenerConfig are the flags used to create one or more listeners, with
+// support for systemd socket activation and file descriptor inheritance.
+//
+// Supported address formats:
+//   - "host:port" or ":port" (tcp).
+//   - "unix:/path/to/socket" (unix socket).
+//   - "fd:N" (inherit already-open file descriptor N).
+//   - "systemd" (all sockets passed through systemd socket activation, or
+//     by a parent process with HandoffListeners).
+//
+// Example (where you can set HTTP_LISTEN as an environment variable, for example):
+//
+//	type Flags struct {
+//		HTTP clix.ListenerConfig `group:"HTTP Options" namespace:"http" env-namespace:"HTTP"`
+//	}
+//	[...]
+//	clix.Run(cli.Flags.HTTP.Serve(srv, cli.Logger))
+type ListenerConfig struct {
+	// Listen are the addresses to listen on.
+	Listen []string `env:"LISTEN" env-delim:"," long:"listen" default:":8080" description:"address(es) to listen on (host:port, unix:<path>, fd:<n>, or systemd)"`
+
+	// ShutdownTimeout is the maximum amount of time to wait for active
+	// connections to finish when shutting down.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" long:"shutdown-timeout" default:"30s" description:"time to wait for active connections to finish when shutting down"`
+
+	// TLS optionally enables TLS on all listeners.
+	TLS TLSConfig `group:"TLS Options" namespace:"tls" env-namespace:"TLS"`
+}
+
+// Listeners returns the listeners for all configured addresses, wrapped with
+// TLS if enabled. If any listener fails to be created, all previously created
+// listeners are closed.
+func (c *ListenerConfig) Listeners() (listeners []net.Listener, err error) {
+	if len(c.Listen) == 0 {
+		return nil, errors.New("no listen addresses provided")
+	}
+
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			listeners = nil
+		}
+	}()
+
+	for _, addr := range c.Listen {
+		var ls []net.Listener
+
+		ls, err = listen(addr)
+		if err != nil {
+			return listeners, fmt.Errorf("failed to listen on %q: %w", addr, err)
+		}
+
+		listeners = append(listeners, ls...)
+	}
+
+	tlsConfig, err := c.TLS.Config()
+	if err != nil {
+		return listeners, err
+	}
+
+	if tlsConfig != nil {
+		for i := range listeners {
+			listeners[i] = tls.NewListener(listeners[i], tlsConfig)
+		}
+	}
+
+	return listeners, nil
+}
+
+// Serve returns a Runner which serves the provided http.Server on all configured
+// listeners, and gracefully shuts down the server when the context is cancelled
+// (e.g. when a termination signal is received through Run). logger may be nil.
+func (c *ListenerConfig) Serve(srv *http.Server, logger log.Interface) Runner {
+	return func(ctx context.Context) error {
+		listeners, err := c.Listeners()
+		if err != nil {
+			return err
+		}
+
+		g, gctx := errgroup.WithContext(ctx)
+
+		for _, l := range listeners {
+			if logger != nil {
+				logger.Infof("listening on %s://%s", l.Addr().Network(), l.Addr().String())
+			}
+
+			g.Go(func() error {
+				if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+				return nil
+			})
+		}
+
+		g.Go(func() error {
+			<-gctx.Done()
+
+			sctx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+			defer cancel()
+
+			return srv.Shutdown(sctx)
+		})
+
+		return g.Wait()
+	}
+}
+
+// listen creates listener(s) for the provided address.
+func listen(addr string) ([]net.Listener, error) {
+	switch {
+	case addr == "systemd":
+		return systemdListeners()
+	case strings.HasPrefix(addr, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(addr, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor %q", addr)
+		}
+
+		l, err := fileListener(fd, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	case strings.HasPrefix(addr, "unix:"):
+		l, err := net.Listen("unix", strings.TrimPrefix(addr, "unix:"))
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	default:
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	}
+}
+
+// systemdListeners returns the listeners passed through systemd socket
+// activation (see sd_listen_fds(3)), or by a parent process with
+// HandoffListeners. The LISTEN_* environment variables are unset once
+// consumed, so they aren't inherited by child processes.
+func systemdListeners() ([]net.Listener, error) {
+	// HandoffListeners sets LISTEN_PID to the parent's pid, as the child's pid
+	// isn't known before it's started.
+	pid, err := strconv.Atoi(getenv("LISTEN_PID"))
+	if err != nil || (pid != os.Getpid() && pid != os.Getppid()) {
+		return nil, errors.New("no sockets passed by systemd (LISTEN_PID unset or mismatched)")
+	}
+
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, errors.New("no sockets passed by systemd (LISTEN_FDS unset or invalid)")
+	}
+
+	names := strings.Split(getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := "systemd"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		l, err := fileListener(listenFDStart+i, name)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	for _, key := range listenEnv {
+		_ = os.Unsetenv(key)
+	}
+
+	return listeners, nil
+}
+
+// listenEnv are the environment variables used to pass listeners to a process
+// (see sd_listen_fds(3)).
+var listenEnv = []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"}
+
+// HandoffListeners prepares cmd (e.g. a new version of the application, for
+// zero-downtime restarts) to inherit the provided listeners, which the child
+// can use with the "systemd" listen address (see ListenerConfig). Listeners
+// are duplicated into cmd.ExtraFiles (which must be empty, so they start at
+// file descriptor 3), and LISTEN_FDS and LISTEN_PID are set in cmd.Env (based
+// on the current environment if nil). The files in cmd.ExtraFiles should be
+// closed once cmd has been started. Listeners must be *net.TCPListener or
+// *net.UnixListener (i.e. not wrapped with TLS). Unix socket listeners remove
+// their socket file when closed, unless SetUnlinkOnClose(false) is used.
+//
+// Example:
+//
+//	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+//	if err := clix.HandoffListeners(cmd, listeners...); err != nil {
+//		return err
+//	}
+//	err := cmd.Start()
+//	for _, f := range cmd.ExtraFiles {
+//		_ = f.Close()
+//	}
+func HandoffListeners(cmd *exec.Cmd, listeners ...net.Listener) error {
+	if len(cmd.ExtraFiles) > 0 {
+		return errors.New("cannot hand off listeners: command already has extra files")
+	}
+
+	files := make([]*os.File, 0, len(listeners))
+
+	for _, l := range listeners {
+		fl, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return fmt.Errorf("cannot hand off listener %s: unsupported listener type %T", l.Addr(), l)
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return fmt.Errorf("cannot hand off listener %s: %w", l.Addr(), err)
+		}
+
+		files = append(files, f)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+
+	cmd.Env = make([]string, 0, len(env)+2)
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(listenEnv, key) {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+
+	cmd.Env = append(
+		cmd.Env,
+		"LISTEN_FDS="+strconv.Itoa(len(files)),
+		"LISTEN_PID="+strconv.Itoa(os.Getpid()),
+	)
+	cmd.ExtraFiles = files
+
+	return nil
+}
+
+// fileListener creates a listener from an inherited file descriptor.
+func fileListener(fd int, name string) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), name)
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor %d", fd)
+	}
+	defer f.Close()
+
+	return net.FileListener(f)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/clix"
+)
+
+// handoffChildEnv is set when the test binary is re-executed as the child of
+// TestHandoffListeners.
+const handoffChildEnv = "CLIX_TEST_HANDOFF_CHILD"
+
+func TestHandoffListeners(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inheriting file descriptors isn't supported on windows")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffListenersChild$")
+	cmd.Env = append(os.Environ(), handoffChildEnv+"=1", "LISTEN_FDNAMES=stale")
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err = clix.HandoffListeners(cmd, l); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cmd.ExtraFiles) != 1 {
+		t.Fatalf("expected 1 extra file, got %d", len(cmd.ExtraFiles))
+	}
+
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range cmd.ExtraFiles {
+		_ = f.Close()
+	}
+
+	// The parent doesn't accept connections, so they're accepted by the child,
+	// through the inherited listener.
+	conn, err := net.DialTimeout("tcp", l.Addr().String(), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = cmd.Wait(); err != nil {
+		t.Fatalf("child failed: %v", err)
+	}
+
+	if string(b) != "ok" {
+		t.Fatalf("unexpected response %q from child", b)
+	}
+}
+
+func TestHandoffListenersChild(t *testing.T) {
+	if os.Getenv(handoffChildEnv) == "" {
+		t.Skip("only run as the child of TestHandoffListeners")
+	}
+
+	c := &clix.ListenerConfig{Listen: []string{"systemd"}}
+
+	listeners, err := c.Listeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listeners[0].Close()
+
+	for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if _, ok := os.LookupEnv(key); ok {
+			t.Fatalf("expected %s to be unset once consumed", key)
+		}
+	}
+
+	_ = listeners[0].(*net.TCPListener).SetDeadline(time.Now().Add(10 * time.Second))
+
+	conn, err := listeners[0].Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = conn.Write([]byte("ok"))
+	_ = conn.Close()
+}
@@ -0,0 +1,16 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build !unix && !windows
+
+package clix
+
+import "os"
+
+// lockFile is a no-op on platforms without file locking, where only
+// in-process locking is provided.
+func lockFile(_ *os.File, _ bool) error { return nil }
+
+// unlockFile is a no-op on platforms without file locking.
+func unlockFile(_ *os.File) error { return nil }
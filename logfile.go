@@ -0,0 +1,150 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// parseFileMode parses the provided file mode in octal, returning def if the
+// mode is empty.
+func parseFileMode(mode string, def fs.FileMode) (fs.FileMode, error) {
+	if mode == "" {
+		return def, nil
+	}
+
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q: %w", mode, err)
+	}
+
+	return fs.FileMode(m).Perm(), nil
+}
+
+// lookupOwner resolves the provided "USER[:GROUP]" owner (names or numeric
+// ids) to a uid and gid. If group is omitted, gid is -1 (unchanged).
+func lookupOwner(owner string) (uid, gid int, err error) {
+	name, group, _ := strings.Cut(owner, ":")
+	uid, gid = -1, -1
+
+	if name != "" {
+		if uid, err = strconv.Atoi(name); err != nil {
+			u, lerr := user.Lookup(name)
+			if lerr != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %w", owner, lerr)
+			}
+
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %w", owner, err)
+			}
+		}
+	}
+
+	if group != "" {
+		if gid, err = strconv.Atoi(group); err != nil {
+			g, lerr := user.LookupGroup(group)
+			if lerr != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %w", owner, lerr)
+			}
+
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return -1, -1, fmt.Errorf("invalid owner %q: %w", owner, err)
+			}
+		}
+	}
+
+	return uid, gid, nil
+}
+
+// openLogFile opens the configured log file for appending, creating it (and
+// its parent directories) with the configured modes and owner.
+func (c *LoggerConfig) openLogFile() (*os.File, error) {
+	mode, err := parseFileMode(c.PathMode, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	dirMode, err := parseFileMode(c.PathDirMode, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	uid, gid := -1, -1
+	if c.PathOwner != "" {
+		if uid, gid, err = lookupOwner(c.PathOwner); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir := filepath.Dir(c.Path); dir != "." {
+		if err = os.MkdirAll(dir, dirMode); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+
+	existing, statErr := os.Stat(c.Path)
+
+	f, err := os.OpenFile(c.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// OpenFile only applies the mode when the file is created (and is subject
+	// to umask), so enforce an explicitly configured mode. Permissions of
+	// existing files are only ever removed, never added.
+	if c.PathMode != "" {
+		if statErr == nil {
+			mode &= existing.Mode().Perm()
+		}
+
+		if err = f.Chmod(mode); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to set log file mode: %w", err)
+		}
+	}
+
+	if c.PathOwner != "" {
+		if err = f.Chown(uid, gid); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to set log file owner: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// lineWriter buffers writes until a full line is available, and writes each
+// batch of complete lines with a single write. Combined with O_APPEND, this
+// keeps entries from multiple processes writing to the same file from being
+// interleaved, as handlers often write a single entry in multiple calls.
+type lineWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+
+	if i := bytes.LastIndexByte(w.buf.Bytes(), '\n'); i >= 0 {
+		if _, err := w.w.Write(w.buf.Next(i + 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
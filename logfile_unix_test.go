@@ -0,0 +1,60 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build unix
+
+package clix
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestOpenLogFileMode(t *testing.T) {
+	old := syscall.Umask(0)
+	defer syscall.Umask(old)
+
+	tests := []struct {
+		name     string
+		existing fs.FileMode // 0 if the file doesn't exist.
+		pathMode string
+		want     fs.FileMode
+	}{
+		{name: "new-default", want: 0o644},
+		{name: "new-explicit", pathMode: "0600", want: 0o600},
+		{name: "existing-default", existing: 0o600, want: 0o600},
+		{name: "existing-looser", existing: 0o600, pathMode: "0644", want: 0o600},
+		{name: "existing-stricter", existing: 0o666, pathMode: "0640", want: 0o640},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, nil, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := (&LoggerConfig{Path: path, PathMode: tt.pathMode}).openLogFile()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = f.Close()
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := info.Mode().Perm(); got != tt.want {
+				t.Fatalf("unexpected mode %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
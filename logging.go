@@ -5,7 +5,11 @@
 package clix
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/apex/log"
 	logcli "github.com/apex/log/handlers/cli"
@@ -29,7 +33,7 @@ import (
 //		Log      *chix.LoggerConfig `group:"Logging Options" namespace:"log" env-namespace:"LOG"`
 //	}
 //	[...]
-//	cli.Log.New(cli.Debug)
+//	logger, err := cli.Log.New(cli.Debug)
 type LoggerConfig struct {
 	// Quiet disables all logging.
 	Quiet bool `env:"QUIET" long:"quiet" description:"disable logging to stdout (also: see levels)"`
@@ -48,53 +52,149 @@ type LoggerConfig struct {
 
 	// Path is the path to the log file.
 	Path string `env:"PATH" long:"path" description:"path to log file (disables stdout logging)"`
+
+	// PathMode is the file mode (in octal) used for the log file (0644 if
+	// empty). If set, it's also enforced on existing log files, though their
+	// mode is never loosened.
+	PathMode string `env:"PATH_MODE" long:"path-mode" description:"file mode (in octal) used for the log file (default: 0644)"`
+
+	// PathDirMode is the file mode (in octal) used when creating the log file's
+	// parent directories.
+	PathDirMode string `env:"PATH_DIR_MODE" long:"path-dir-mode" default:"0755" description:"file mode (in octal) used when creating log file directories"`
+
+	// PathOwner is the optional owner of the log file, as "USER[:GROUP]" (names
+	// or numeric ids). Typically requires running as root, e.g. before
+	// dropping privileges.
+	PathOwner string `env:"PATH_OWNER" long:"path-owner" value-name:"USER[:GROUP]" description:"owner of the log file (typically requires root)"`
+
+	// Components are per-component log level overrides, as "NAME=LEVEL" (see
+	// CLI.ComponentLogger).
+	Components []string `env:"COMPONENTS" env-delim:"," long:"component" value-name:"NAME=LEVEL" description:"per-component log level override (repeatable)"`
+
+	// Handler is an optional apex/log handler to use, instead of one of the
+	// built-in handlers, allowing applications to route logging into their
+	// existing pipeline. Takes precedence over all other output options.
+	Handler log.Handler `no-flag:"true" json:"-"`
+
+	// Writer is an optional writer to use instead of stdout, for the built-in
+	// handlers.
+	Writer io.Writer `no-flag:"true" json:"-"`
 }
 
-// new parses LoggerConfig and creates a new structured logger with the
-// provided configuration.
-func (cli *CLI[T]) newLogger() error {
-	cli.Logger = &log.Logger{}
+// globalLoggerMu guards updates to the global apex/log logger, which may
+// happen concurrently when multiple CLIs are parsed in parallel.
+var globalLoggerMu sync.Mutex
 
-	if cli.LoggerConfig.Level == "" {
-		cli.Logger.Level = log.InfoLevel
-	}
+// New creates a new structured logger with the provided configuration. Unlike
+// the logger initialized by CLI.Parse(), this has no side effects (the global
+// apex/log logger is not updated).
+func (c *LoggerConfig) New(debug bool) (*log.Logger, error) {
+	logger := &log.Logger{}
 
-	if cli.Debug {
-		cli.Logger.Level = log.DebugLevel
-	} else if cli.LoggerConfig.Level == "" {
-		cli.Logger.Level = log.InfoLevel
+	if debug {
+		logger.Level = log.DebugLevel
+	} else if c.Level == "" {
+		logger.Level = log.InfoLevel
 	} else {
-		cli.Logger.Level = log.MustParseLevel(cli.LoggerConfig.Level)
+		level, err := log.ParseLevel(c.Level)
+		if err != nil {
+			return nil, err
+		}
+		logger.Level = level
+	}
+
+	if _, err := c.componentLevels(); err != nil {
+		return nil, err
+	}
+
+	out := c.Writer
+	if out == nil {
+		out = os.Stdout
 	}
 
 	switch {
-	case cli.LoggerConfig.Path != "":
-		f, err := os.OpenFile(cli.LoggerConfig.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	case c.Handler != nil:
+		logger.Handler = c.Handler
+	case c.Path != "":
+		f, err := c.openLogFile()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// We can't really close the file here.
 
-		cli.Logger.Handler = logcli.New(f)
-	case cli.LoggerConfig.Github:
+		logger.Handler = logcli.New(&lineWriter{w: f})
+	case c.Github:
 		// Since debug is by default masked unless debugging is enabled in Actions.
-		cli.Logger.Level = log.DebugLevel
-		cli.Logger.Handler = githubhandler.New(os.Stdout)
-	case cli.LoggerConfig.Quiet:
-		cli.Logger.Handler = discard.New()
-	case cli.LoggerConfig.JSON:
-		cli.Logger.Handler = json.New(os.Stdout)
-	case cli.LoggerConfig.Pretty:
-		cli.Logger.Handler = text.New(os.Stdout)
+		logger.Level = log.DebugLevel
+		logger.Handler = githubhandler.New(out)
+	case c.Quiet:
+		logger.Handler = discard.New()
+	case c.JSON:
+		logger.Handler = json.New(out)
+	case c.Pretty:
+		logger.Handler = text.New(consoleWriter(out))
 	default:
-		cli.Logger.Handler = logfmt.New(os.Stdout)
+		logger.Handler = logfmt.New(out)
+	}
+
+	return logger, nil
+}
+
+// newLogger creates a new structured logger from LoggerConfig, and updates the
+// global apex/log logger (unless disabled).
+func (cli *CLI[T]) newLogger() error {
+	config := cli.LoggerConfig
+
+	// Logs are diagnostic output, which shouldn't be mixed with the command's
+	// output (see WithGuidelines).
+	if cli.settings.guidelines && config.Writer == nil {
+		config.Writer = os.Stderr
 	}
 
-	if cli.options&OptDisableGlobalLogger == 0 {
-		log.SetLevel(cli.Logger.Level)
-		log.SetHandler(cli.Logger.Handler)
+	logger, err := config.New(cli.DebugEnabled(""))
+	if err != nil {
+		return err
+	}
+
+	cli.wrapExitHooks(logger)
+	cli.wrapCommandField(logger)
+
+	cli.mu.Lock()
+	cli.Logger = logger
+	cli.mu.Unlock()
+
+	if !cli.IsSet(OptDisableGlobalLogger) {
+		globalLoggerMu.Lock()
+		log.SetLevel(logger.Level)
+		log.SetHandler(logger.Handler)
+		globalLoggerMu.Unlock()
 	}
 
 	return nil
 }
+
+// componentLevels returns the per-component log level overrides.
+func (c *LoggerConfig) componentLevels() (map[string]log.Level, error) {
+	if len(c.Components) == 0 {
+		return nil, nil
+	}
+
+	levels := make(map[string]log.Level, len(c.Components))
+
+	for _, component := range c.Components {
+		name, value, ok := strings.Cut(component, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid component log level %q (expected NAME=LEVEL)", component)
+		}
+
+		level, err := log.ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid component log level %q: %w", component, err)
+		}
+
+		levels[strings.ToLower(name)] = level
+	}
+
+	return levels, nil
+}
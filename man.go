@@ -0,0 +1,88 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ManPage writes a generated man page (roff) for the CLI to the provided
+// io.Writer, including the EXIT STATUS, ENVIRONMENT, FILES and SEE ALSO
+// sections (see DocsMeta). Environment variables of flags are included in the
+// ENVIRONMENT section automatically.
+func (cli *CLI[T]) ManPage(out io.Writer) {
+	p := cli.Parser
+	if p == nil {
+		p, _ = cli.newParser()
+	}
+
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	p.WriteManPage(w)
+	cli.DocModel().manSections(w)
+}
+
+// manSections writes the man page sections derived from the documentation
+// metadata, and the environment variables of flags.
+func (m *DocModel) manSections(out io.Writer) {
+	meta := m.Meta
+	if meta == nil {
+		meta = &DocsMeta{}
+	}
+
+	if len(meta.ExitCodes) > 0 {
+		fmt.Fprintln(out, ".SH EXIT STATUS")
+		for _, code := range meta.ExitCodes {
+			fmt.Fprintf(out, ".TP\n\\fB%d\\fP\n%s\n", code.Code, roffEscape(code.Description))
+		}
+	}
+
+	options := m.EnvOptions()
+	if len(options) > 0 || len(meta.Environment) > 0 {
+		fmt.Fprintln(out, ".SH ENVIRONMENT")
+		for _, option := range options {
+			fmt.Fprintf(out, ".TP\n\\fB%s\\fP\n%s (see \\fB%s\\fP)\n", roffEscape(option.Env), roffEscape(option.Description), roffEscape(option.Flag))
+		}
+		for _, env := range meta.Environment {
+			fmt.Fprintf(out, ".TP\n\\fB%s\\fP\n%s\n", roffEscape(env.Name), roffEscape(env.Description))
+		}
+	}
+
+	if len(meta.Files) > 0 {
+		fmt.Fprintln(out, ".SH FILES")
+		for _, file := range meta.Files {
+			fmt.Fprintf(out, ".TP\n\\fI%s\\fP\n%s\n", roffEscape(file.Path), roffEscape(file.Description))
+		}
+	}
+
+	if len(meta.SeeAlso) > 0 {
+		fmt.Fprintln(out, ".SH SEE ALSO")
+		refs := make([]string, len(meta.SeeAlso))
+		for i, ref := range meta.SeeAlso {
+			refs[i] = roffEscape(ref)
+		}
+		fmt.Fprintln(out, strings.Join(refs, ", "))
+	}
+}
+
+// roffEscape escapes the provided string for use in roff, including lines
+// which would otherwise be interpreted as requests.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
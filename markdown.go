@@ -5,6 +5,7 @@
 package clix
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"strings"
@@ -16,67 +17,138 @@ const optionHeader = "| Environment vars | Flags | Type | Description |\n| --- |
 
 // Markdown writes generated marakdown to the provided io.Writer.
 func (cli *CLI[T]) Markdown(out io.Writer) {
-	cli.generateRecursive(out)
+	cli.DocModel().Markdown(out)
 }
 
-func (cli *CLI[T]) generateRecursive(out io.Writer, groups ...*flags.Group) {
-	// TODO: commands?
+// MarkdownFromParser writes generated markdown for the provided go-flags parser
+// to the provided io.Writer. This allows projects which use go-flags directly
+// (without CLI[T]) to still generate markdown documentation.
+func MarkdownFromParser(parser *flags.Parser, out io.Writer) {
+	ModelFromParser(parser).Markdown(out)
+}
+
+// Markdown writes generated markdown for the documentation model to the
+// provided io.Writer.
+func (m *DocModel) Markdown(out io.Writer) {
+	// Buffer output, as it's written in many small writes (one or more per
+	// option).
+	w := bufio.NewWriter(out)
+	defer w.Flush()
 
-	parser := cli.newParser()
+	if m.LongDescription != "" {
+		fmt.Fprintf(w, "%s\n", strings.TrimSpace(m.LongDescription))
+	}
 
-	if groups == nil {
-		groups = parser.Groups()
+	markdownGroups(w, m.Groups)
+	markdownCommands(w, m.Commands)
+
+	if m.Meta != nil {
+		markdownMeta(w, m.Meta)
+	}
+}
+
+func markdownMeta(out io.Writer, meta *DocsMeta) {
+	if len(meta.ExitCodes) > 0 {
+		fmt.Fprint(out, "\n#### Exit Codes\n| Code | Description |\n| --- | --- |\n")
+		for _, code := range meta.ExitCodes {
+			fmt.Fprintf(out, "| `%d` | %s |\n", code.Code, markdownCell(code.Description))
+		}
+	}
+
+	if len(meta.Environment) > 0 {
+		fmt.Fprint(out, "\n#### Environment\n| Environment vars | Description |\n| --- | --- |\n")
+		for _, env := range meta.Environment {
+			fmt.Fprintf(out, "| `%s` | %s |\n", env.Name, markdownCell(env.Description))
+		}
+	}
+
+	if len(meta.Files) > 0 {
+		fmt.Fprint(out, "\n#### Files\n| Path | Description |\n| --- | --- |\n")
+		for _, file := range meta.Files {
+			fmt.Fprintf(out, "| `%s` | %s |\n", file.Path, markdownCell(file.Description))
+		}
+	}
+
+	if len(meta.SeeAlso) > 0 {
+		fmt.Fprint(out, "\n#### See Also\n")
+		for _, ref := range meta.SeeAlso {
+			fmt.Fprintf(out, "- %s\n", ref)
+		}
 	}
+}
+
+// markdownCell escapes the provided string for use in a markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
 
+func markdownGroups(out io.Writer, groups []*DocGroup) {
 	for _, group := range groups {
-		if group.LongDescription != "" {
-			fmt.Fprintf(out, "\n#### %s\n%s", group.LongDescription, optionHeader)
-		} else if group.ShortDescription != "" {
-			fmt.Fprintf(out, "\n#### %s\n%s", group.ShortDescription, optionHeader)
+		if group.Name != "" {
+			fmt.Fprintf(out, "\n#### %s\n%s", group.Name, optionHeader)
 		}
 
 		// print the options in this group first, then recursively continue into
 		// each sub-group.
-		options := group.Options()
-		for _, option := range options {
-			if option.Hidden {
-				continue
-			}
+		markdownOptions(out, group.Options)
+		markdownGroups(out, group.Groups)
+	}
+}
 
-			environment := option.EnvKeyWithNamespace()
-			if environment != "" {
-				environment = "`" + environment + "`"
-			} else {
-				environment = "-"
-			}
+func markdownOptions(out io.Writer, options []*DocOption) {
+	for _, option := range options {
+		environment := option.Env
+		if environment != "" {
+			environment = "`" + environment + "`"
+		} else {
+			environment = "-"
+		}
 
-			description := option.Description
+		description := option.Description
 
-			if option.Required {
-				description += " [**required**]"
-			}
+		if option.Required {
+			description += " [**required**]"
+		}
 
-			if option.Default != nil {
-				description += fmt.Sprintf(" [**default: %s**]", strings.Join(option.Default, ", "))
-			}
+		if option.Default != nil {
+			description += fmt.Sprintf(" [**default: %s**]", strings.Join(option.Default, ", "))
+		}
 
-			if option.Choices != nil {
-				description += fmt.Sprintf(" [**choices: %s**]", strings.Join(option.Choices, ", "))
-			}
+		if option.Choices != nil {
+			description += fmt.Sprintf(" [**choices: %s**]", strings.Join(option.Choices, ", "))
+		}
 
-			description = strings.ReplaceAll(description, "|", "\\|")
+		description = strings.ReplaceAll(description, "|", "\\|")
 
-			_type := fmt.Sprintf("%T", option.Value())
-			if strings.Contains(strings.ToLower(_type), "func") {
-				_type = "-"
-			}
+		_type := option.Type
+		if _type == "" {
+			_type = "-"
+		}
+
+		fmt.Fprintf(out, "| %s | `%s` | %s | %s |\n", environment, option.Flag, _type, description)
+	}
+}
 
-			fmt.Fprintf(out, "| %s | `%s` | %s | %s |\n", environment, option.String(), _type, description)
+func markdownCommands(out io.Writer, commands []*DocCommand) {
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "\n### `%s`\n", cmd.Path)
+
+		if cmd.LongDescription != "" {
+			fmt.Fprintf(out, "\n%s\n", cmd.LongDescription)
+		} else if cmd.ShortDescription != "" {
+			fmt.Fprintf(out, "\n%s\n", cmd.ShortDescription)
+		}
+
+		if len(cmd.Aliases) > 0 {
+			fmt.Fprintf(out, "\nAliases: `%s`\n", strings.Join(cmd.Aliases, "`, `"))
 		}
 
-		groups := group.Groups()
-		if len(groups) > 0 {
-			cli.generateRecursive(out, groups...)
+		if len(cmd.Options) > 0 {
+			fmt.Fprintf(out, "\n%s", optionHeader)
+			markdownOptions(out, cmd.Options)
 		}
+
+		markdownGroups(out, cmd.Groups)
+		markdownCommands(out, cmd.Commands)
 	}
 }
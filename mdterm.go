@@ -0,0 +1,161 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"strings"
+)
+
+// renderMarkdown renders the provided markdown for display in a terminal (e.g.
+// help output), using color and styles if enabled for f (see ColorEnabled).
+// This supports the subset of markdown typically used in descriptions:
+// headings, paragraphs, lists, block quotes, code blocks, code spans,
+// emphasis, and links. Paragraphs are joined into single lines, as help
+// output is wrapped to the terminal width by go-flags, which also trims
+// indentation (so code blocks and quotes are prefixed with "|").
+func renderMarkdown(f *os.File, md string) string {
+	r := &mdRenderer{color: ColorEnabled(f)}
+
+	var out, para []string
+
+	flush := func() {
+		if len(para) > 0 {
+			out = append(out, r.inline(strings.Join(para, " "), true))
+			para = nil
+		}
+	}
+
+	var fenced bool
+
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fenced = !fenced
+			continue
+		}
+
+		if fenced {
+			out = append(out, "|  "+r.style("green", line))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+			if len(out) > 0 && out[len(out)-1] != "" {
+				out = append(out, "")
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			out = append(out, r.style("bold", r.inline(strings.TrimSpace(strings.TrimLeft(trimmed, "#")), false)))
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flush()
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			out = append(out, "| "+r.inline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")), true))
+		case isListItem(trimmed):
+			flush()
+			marker, item, _ := strings.Cut(trimmed, " ")
+			if !strings.HasSuffix(marker, ".") {
+				marker = bullet()
+			}
+			out = append(out, marker+" "+r.inline(strings.TrimSpace(item), true))
+		default:
+			para = append(para, trimmed)
+		}
+	}
+
+	flush()
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// isListItem returns true if the provided (trimmed) line is a list item.
+func isListItem(line string) bool {
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") {
+		return true
+	}
+
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+
+	return i > 0 && strings.HasPrefix(line[i:], ". ")
+}
+
+type mdRenderer struct {
+	color bool
+}
+
+// style wraps s in the ANSI codes of the provided color tag (see colorTags),
+// if color is enabled.
+func (r *mdRenderer) style(tag, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return "\x1b[" + colorTags[tag] + "m" + s + "\x1b[0m"
+}
+
+// inline renders inline markdown (code spans, emphasis, and links). If styled
+// is false, markers are removed without applying styles.
+func (r *mdRenderer) inline(s string, styled bool) string {
+	style := func(tag, s string) string {
+		if !styled {
+			return s
+		}
+		return r.style(tag, s)
+	}
+
+	var b strings.Builder
+
+	for len(s) > 0 {
+		switch {
+		case s[0] == '`':
+			if end := strings.IndexByte(s[1:], '`'); end >= 0 {
+				b.WriteString(style("cyan", s[1:end+1]))
+				s = s[end+2:]
+				continue
+			}
+		case strings.HasPrefix(s, "**") || strings.HasPrefix(s, "__"):
+			if end := strings.Index(s[2:], s[:2]); end > 0 {
+				b.WriteString(style("bold", s[2:end+2]))
+				s = s[end+4:]
+				continue
+			}
+		case s[0] == '*':
+			if end := strings.IndexByte(s[1:], '*'); end > 0 {
+				b.WriteString(style("italic", s[1:end+1]))
+				s = s[end+2:]
+				continue
+			}
+		case s[0] == '[':
+			if mid := strings.Index(s, "]("); mid > 0 {
+				if end := strings.IndexByte(s[mid:], ')'); end > 0 {
+					text, url := s[1:mid], s[mid+2:mid+end]
+					if text == url {
+						b.WriteString(style("magenta", url))
+					} else {
+						b.WriteString(text + " (" + style("magenta", url) + ")")
+					}
+					s = s[mid+end+1:]
+					continue
+				}
+			}
+		}
+
+		b.WriteByte(s[0])
+		s = s[1:]
+	}
+
+	return b.String()
+}
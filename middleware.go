@@ -0,0 +1,74 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// RunFunc executes a command with the remaining (positional) arguments. See
+// Middleware.
+type RunFunc func(ctx context.Context, args []string) error
+
+// Middleware wraps the execution of commands, e.g. for auth checks, timing
+// metrics, tracing spans, or license validation. Middleware can inspect the
+// command being executed with CommandPath, and must call next to continue
+// execution (unless it decides to abort, by returning an error).
+type Middleware func(next RunFunc) RunFunc
+
+// ContextCommander is an optional interface which commands can implement (in
+// addition to flags.Commander), to receive the context passed through the
+// middleware chain (see UseMiddleware), rather than having Execute invoked.
+type ContextCommander interface {
+	ExecuteContext(ctx context.Context, args []string) error
+}
+
+// UseMiddleware registers middleware, which wraps the execution of all
+// commands. Middleware is invoked in order of registration (i.e. the first
+// registered middleware is the outermost). Must be called before Parse().
+//
+// Example:
+//
+//	cli.UseMiddleware(func(next clix.RunFunc) clix.RunFunc {
+//		return func(ctx context.Context, args []string) error {
+//			started := time.Now()
+//			defer func() {
+//				cli.CommandLogger().WithDuration(time.Since(started)).Info("command finished")
+//			}()
+//			return next(ctx, args)
+//		}
+//	})
+func (cli *CLI[T]) UseMiddleware(mw ...Middleware) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.middleware = append(cli.middleware, mw...)
+}
+
+// runCommand executes the provided command through the middleware chain.
+func (cli *CLI[T]) runCommand(ctx context.Context, command flags.Commander, args []string) error {
+	run := func(ctx context.Context, args []string) error {
+		if err := cli.authorize(ctx, command); err != nil {
+			return err
+		}
+
+		if cc, ok := command.(ContextCommander); ok {
+			return cc.ExecuteContext(ctx, args)
+		}
+		return command.Execute(args)
+	}
+
+	cli.mu.Lock()
+	middleware := cli.middleware
+	cli.mu.Unlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		run = middleware[i](run)
+	}
+
+	return run(ctx, args)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// MountFunc is invoked with all arguments provided after the mounted command
+// name, which are passed through as-is (not parsed by clix).
+type MountFunc func(ctx context.Context, args []string) error
+
+// mount is an external command tree mounted under the CLI.
+type mount struct {
+	name        string
+	description string
+	fn          MountFunc
+	args        []string
+}
+
+// Execute implements flags.Commander.
+func (m *mount) Execute(_ []string) error {
+	return m.fn(context.Background(), m.args)
+}
+
+// ExecuteContext implements ContextCommander, so the mounted command receives
+// the context passed through the middleware chain (see UseMiddleware).
+func (m *mount) ExecuteContext(ctx context.Context, _ []string) error {
+	return m.fn(ctx, m.args)
+}
+
+// Mount mounts an external command tree (e.g. from cobra or urfave/cli) under
+// the provided command name. All arguments after the command name are passed
+// through to the mounted command without being parsed by clix, while global
+// flags before the command name (logging, debug, version, etc) are still
+// handled by clix. This allows incrementally adopting clix in applications
+// with large existing command trees. Must be called before Parse().
+//
+// Example:
+//
+//	cli.Mount("legacy", "legacy commands", clix.FromCobra(rootCmd))
+func (cli *CLI[T]) Mount(name, description string, fn MountFunc) {
+	cli.mounts = append(cli.mounts, &mount{
+		name:        name,
+		description: description,
+		fn:          fn,
+	})
+}
+
+// splitMountArgs splits the provided arguments after the mounted command name
+// (if any), storing the passthrough arguments on the mount. The command name
+// is only matched at the command position, i.e. the first positional argument
+// after global flags (and their values), so flag values and arguments of
+// other commands which happen to match a mounted command name are left as-is.
+func (cli *CLI[T]) splitMountArgs(p *flags.Parser, args []string) []string {
+	if len(cli.mounts) == 0 {
+		return args
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			return args
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if option := p.FindOptionByLongName(name); option != nil && !hasValue && takesValue(option) {
+				i++ // Skip the value.
+			}
+			continue
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j, r := range arg[1:] {
+				option := p.FindOptionByShortName(r)
+				if option == nil || !takesValue(option) {
+					continue
+				}
+
+				// The remainder (e.g. "-ovalue") or the next argument is the
+				// value.
+				if j+utf8.RuneLen(r) == len(arg)-1 {
+					i++
+				}
+				break
+			}
+			continue
+		}
+
+		for _, m := range cli.mounts {
+			if arg == m.name {
+				m.args = append([]string(nil), args[i+1:]...)
+				return args[:i+1]
+			}
+		}
+
+		// The first positional argument isn't a mounted command.
+		return args
+	}
+
+	return args
+}
+
+// CobraCommand is the subset of *cobra.Command used by FromCobra.
+type CobraCommand interface {
+	SetArgs(args []string)
+	ExecuteContext(ctx context.Context) error
+}
+
+// FromCobra adapts a cobra command tree (typically the root *cobra.Command) to
+// be mounted with CLI.Mount.
+func FromCobra(cmd CobraCommand) MountFunc {
+	return func(ctx context.Context, args []string) error {
+		cmd.SetArgs(args)
+		return cmd.ExecuteContext(ctx)
+	}
+}
+
+// URFaveApp is the subset of *cli.App (urfave/cli v2) used by FromURFave.
+type URFaveApp interface {
+	RunContext(ctx context.Context, arguments []string) error
+}
+
+// FromURFave adapts a urfave/cli (v2) application to be mounted with CLI.Mount.
+func FromURFave(app URFaveApp) MountFunc {
+	return func(ctx context.Context, args []string) error {
+		return app.RunContext(ctx, append([]string{filepath.Base(os.Args[0])}, args...))
+	}
+}
+
+// URFaveCommand is the subset of *cli.Command (urfave/cli v3) used by
+// FromURFaveV3.
+type URFaveCommand interface {
+	Run(ctx context.Context, args []string) error
+}
+
+// FromURFaveV3 adapts a urfave/cli (v3) root command to be mounted with
+// CLI.Mount.
+func FromURFaveV3(cmd URFaveCommand) MountFunc {
+	return func(ctx context.Context, args []string) error {
+		return cmd.Run(ctx, append([]string{filepath.Base(os.Args[0])}, args...))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+type mountFlags struct {
+	Target  string `long:"target" short:"t" description:"target"`
+	Verbose bool   `long:"verbose" short:"V" description:"verbose"`
+}
+
+func TestMountArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		mount  string
+		want   []string
+		target string
+	}{
+		{name: "direct", args: []string{"kubectl", "get", "pods"}, mount: "kubectl", want: []string{"get", "pods"}},
+		{name: "no-args", args: []string{"helm"}, mount: "helm", want: []string{}},
+		{name: "passthrough-flags", args: []string{"helm", "--target", "x", "-t"}, mount: "helm", want: []string{"--target", "x", "-t"}},
+		{name: "bool-flag-before", args: []string{"-V", "helm", "list"}, mount: "helm", want: []string{"list"}},
+		{name: "long-value", args: []string{"--target", "kubectl", "helm", "list"}, mount: "helm", want: []string{"list"}, target: "kubectl"},
+		{name: "long-equals", args: []string{"--target=kubectl", "helm"}, mount: "helm", want: []string{}, target: "kubectl"},
+		{name: "short-value", args: []string{"-t", "kubectl", "helm", "a"}, mount: "helm", want: []string{"a"}, target: "kubectl"},
+		{name: "short-attached", args: []string{"-tkubectl", "helm", "a"}, mount: "helm", want: []string{"a"}, target: "kubectl"},
+		{name: "combined-short", args: []string{"-Vt", "kubectl", "helm"}, mount: "helm", want: []string{}, target: "kubectl"},
+		{name: "mount-name-as-arg", args: []string{"helm", "kubectl", "get"}, mount: "helm", want: []string{"kubectl", "get"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string][]string{}
+
+			cli := &clix.CLI[mountFlags]{}
+			for _, name := range []string{"kubectl", "helm"} {
+				cli.Mount(name, name, func(ctx context.Context, args []string) error {
+					if clix.FlagsFrom[mountFlags](ctx) == nil {
+						return errors.New("mounted command didn't receive the CLI context")
+					}
+
+					got[name] = args
+					return nil
+				})
+			}
+
+			res := clixtest.Run(t, cli, tt.args, nil)
+			if res.Err != nil {
+				t.Fatalf("unexpected error: %v", res.Err)
+			}
+
+			if len(got) != 1 || !slices.Equal(got[tt.mount], tt.want) {
+				t.Fatalf("unexpected mount invocations %q, want %s: %q", got, tt.mount, tt.want)
+			}
+
+			if cli.Flags.Target != tt.target {
+				t.Fatalf("unexpected target %q, want %q", cli.Flags.Target, tt.target)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// ErrUnknownApplet is returned by MultiCall when no applet matches the name
+// the binary was invoked as (or the first argument).
+var ErrUnknownApplet = errors.New("unknown applet")
+
+// Applet is a single program within a multi-call (busybox-style) binary.
+type Applet struct {
+	// Name is the name of the applet, matched against the name the binary was
+	// invoked as (e.g. the name of a symlink to the binary).
+	Name string
+
+	// Aliases are alternative names for the applet.
+	Aliases []string
+
+	// Description is a short description of the applet, used when listing
+	// applets.
+	Description string
+
+	// Main is invoked when the applet is selected. os.Args is adjusted so that
+	// os.Args[0] is the applet name, so each applet can define and Parse() its
+	// own CLI[T], with its own flags, commands, and version/docs output. The
+	// applet name is used as the application name (see CLI.AppName), unless
+	// CLI.VersionInfo provides one.
+	Main func() error
+}
+
+// invokedApplet is the applet selected by MultiCall, if any.
+var invokedApplet atomic.Pointer[Applet]
+
+// appletName returns the name of the applet selected by MultiCall, or an empty
+// string if MultiCall isn't used.
+func appletName() string {
+	if applet := invokedApplet.Load(); applet != nil {
+		return applet.Name
+	}
+	return ""
+}
+
+// run records the applet as the invoked applet, and invokes it.
+func (a *Applet) run() error {
+	invokedApplet.Store(a)
+	return a.Main()
+}
+
+// matches returns true if the applet is known by the provided name.
+func (a *Applet) matches(name string) bool {
+	if a.Name == name {
+		return true
+	}
+
+	for _, alias := range a.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MultiCall dispatches to one of the provided applets based on the name the
+// binary was invoked as (os.Args[0], e.g. through a symlink), busybox-style.
+// If no applet matches (e.g. the binary was invoked by its own name), the first
+// argument is used as the applet name instead, so "tool ls -l" is equivalent
+// to "ls -l", where ls is a symlink to tool. If neither match, the available
+// applets are printed to stderr, and ErrUnknownApplet is returned.
+//
+// Example:
+//
+//	func main() {
+//		err := clix.MultiCall(
+//			&clix.Applet{Name: "ls", Description: "list files", Main: lsMain},
+//			&clix.Applet{Name: "cat", Description: "print files", Main: catMain},
+//		)
+//		if err != nil {
+//			os.Exit(1)
+//		}
+//	}
+func MultiCall(applets ...*Applet) error {
+	if applet := findApplet(applets, os.Args[0]); applet != nil {
+		return applet.run()
+	}
+
+	if len(os.Args) > 1 {
+		if applet := findApplet(applets, os.Args[1]); applet != nil {
+			os.Args = os.Args[1:]
+			return applet.run()
+		}
+	}
+
+	var name string
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		name = os.Args[1]
+		fmt.Fprintf(os.Stderr, "%s: %s\n\n", ErrUnknownApplet, name)
+	}
+
+	listApplets(os.Stderr, filepath.Base(os.Args[0]), applets)
+
+	if name == "" {
+		return ErrUnknownApplet
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownApplet, name)
+}
+
+// findApplet returns the applet matching the base name of the provided path
+// (without a ".exe" suffix), or nil if none match.
+func findApplet(applets []*Applet, path string) *Applet {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+
+	for _, applet := range applets {
+		if applet.matches(name) {
+			return applet
+		}
+	}
+
+	return nil
+}
+
+// listApplets writes the list of available applets to out.
+func listApplets(out io.Writer, bin string, applets []*Applet) {
+	fmt.Fprintf(out, "Usage:\n  %s <applet> [arguments]\n\nAvailable applets:\n", bin)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, applet := range applets {
+		name := applet.Name
+		if len(applet.Aliases) > 0 {
+			name += " (" + strings.Join(applet.Aliases, ", ") + ")"
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\n", name, applet.Description)
+	}
+	w.Flush()
+}
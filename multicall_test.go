@@ -0,0 +1,45 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMultiCallAppletName(t *testing.T) {
+	origArgs := os.Args
+	t.Cleanup(func() {
+		os.Args = origArgs
+		invokedApplet.Store(nil)
+	})
+
+	for _, args := range [][]string{{"/usr/bin/tool", "ls"}, {"/usr/bin/ls"}, {"/usr/bin/ll"}} {
+		os.Args = args
+
+		var name, parserName string
+
+		err := MultiCall(&Applet{Name: "ls", Aliases: []string{"ll"}, Main: func() error {
+			cli := &CLI[struct{}]{Flags: &struct{}{}}
+			name = cli.GetVersionInfo().Name
+
+			cli.VersionInfo = cli.GetVersionInfo()
+			p, err := cli.newParser()
+			if err != nil {
+				return err
+			}
+			parserName = p.Name
+
+			return nil
+		}})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", args, err)
+		}
+
+		if name != "ls" || parserName != "ls" {
+			t.Fatalf("%q: unexpected names %q (version) and %q (parser), want %q", args, name, parserName, "ls")
+		}
+	}
+}
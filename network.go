@@ -0,0 +1,263 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// networkGroup is the name of the built-in network flag group.
+const networkGroup = "Network Options"
+
+// NetworkConfig are the proxy, CA bundle and DNS override flags, shared by all
+// clix-provided clients (e.g. HTTPClientConfig and grpcclient), so proxy and
+// certificate configuration is consistent across them. These are built-in
+// flags of the CLI, which are hidden unless OptEnableNetwork is set. Use
+// DefaultNetwork to access the configuration from clients.
+//
+// Proxies are resolved from (in order of precedence) --proxy/--no-proxy, the
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables, their lowercase
+// variants, and finally ALL_PROXY/all_proxy, so the various conventions used
+// by other tools (e.g. curl) behave the same. CA bundles are added to the
+// system certificate pool, which includes certificates from the OS store.
+//
+// DNS overrides (--resolve) use the same format as curl (HOST:PORT:ADDR, where
+// PORT can be "*" to match any port), and allow targeting specific endpoints
+// (e.g. staging servers) without editing /etc/hosts. TLS verification still
+// uses the original host name.
+type NetworkConfig struct {
+	// Proxy is the proxy URL used for all requests, overriding the environment.
+	Proxy string `long:"proxy" value-name:"URL" description:"proxy URL used for all requests, overriding HTTP(S)_PROXY"`
+
+	// NoProxy are the hosts which shouldn't use the proxy, overriding the
+	// environment.
+	NoProxy string `long:"no-proxy" value-name:"HOSTS" description:"comma-separated hosts, domains and CIDRs which bypass the proxy, overriding NO_PROXY"`
+
+	// CABundles are paths to additional PEM-encoded CA certificates, which are
+	// trusted in addition to the system certificate pool.
+	CABundles []string `long:"ca-bundle" env:"CA_BUNDLE" env-delim:"," value-name:"PATH" description:"additional CA certificates (PEM) to trust (repeatable)"`
+
+	// Resolve are DNS overrides, in the format HOST:PORT:ADDR.
+	Resolve []string `long:"resolve" env:"RESOLVE" env-delim:"," value-name:"HOST:PORT:ADDR" description:"connect to ADDR instead of resolving HOST:PORT (repeatable, PORT can be *)"`
+
+	once      sync.Once
+	tlsConfig *tls.Config
+	tlsErr    error
+
+	resolveOnce sync.Once
+	resolve     map[string]string
+	resolveErr  error
+}
+
+var (
+	defaultNetworkMu sync.RWMutex
+	defaultNetwork   = &NetworkConfig{}
+)
+
+// DefaultNetwork returns the network configuration of the most recently
+// parsed CLI, used by all clix-provided clients. Before a CLI is parsed, the
+// configuration is resolved from the environment only.
+func DefaultNetwork() *NetworkConfig {
+	defaultNetworkMu.RLock()
+	defer defaultNetworkMu.RUnlock()
+
+	return defaultNetwork
+}
+
+// setDefaultNetwork sets the configuration returned by DefaultNetwork.
+func setDefaultNetwork(c *NetworkConfig) {
+	defaultNetworkMu.Lock()
+	defaultNetwork = c
+	defaultNetworkMu.Unlock()
+}
+
+// proxyConfig returns the reconciled proxy configuration.
+func (c *NetworkConfig) proxyConfig() *httpproxy.Config {
+	all := firstEnv("ALL_PROXY", "all_proxy")
+
+	return &httpproxy.Config{
+		HTTPProxy:  firstNonEmpty(c.Proxy, firstEnv("HTTP_PROXY", "http_proxy"), all),
+		HTTPSProxy: firstNonEmpty(c.Proxy, firstEnv("HTTPS_PROXY", "https_proxy"), all),
+		NoProxy:    firstNonEmpty(c.NoProxy, firstEnv("NO_PROXY", "no_proxy")),
+		CGI:        getenv("REQUEST_METHOD") != "",
+	}
+}
+
+// ProxyFunc returns the proxy function used by clix-provided HTTP clients,
+// suitable for http.Transport.Proxy.
+func (c *NetworkConfig) ProxyFunc() func(req *http.Request) (*url.URL, error) {
+	fn := c.proxyConfig().ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}
+}
+
+// ProxyURL returns the proxy used for the provided URL, or nil if no proxy is
+// used.
+func (c *NetworkConfig) ProxyURL(u *url.URL) (*url.URL, error) {
+	return c.proxyConfig().ProxyFunc()(u)
+}
+
+// RootCAs returns the system certificate pool, with any configured CA bundles
+// added.
+func (c *NetworkConfig) RootCAs() (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, fn := range c.CABundles {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in ca bundle %q", fn)
+		}
+	}
+
+	return pool, nil
+}
+
+// TLSConfig returns the TLS configuration used by clix-provided clients. The
+// same configuration is returned on subsequent calls, and must not be
+// modified (use Clone).
+func (c *NetworkConfig) TLSConfig() (*tls.Config, error) {
+	c.once.Do(func() {
+		cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		// Only override the root CAs if needed, so platform verifiers (e.g. on
+		// macOS and Windows) are used otherwise.
+		if len(c.CABundles) > 0 {
+			cfg.RootCAs, c.tlsErr = c.RootCAs()
+		}
+
+		c.tlsConfig = cfg
+	})
+
+	return c.tlsConfig, c.tlsErr
+}
+
+// Transport returns a new HTTP transport (based on http.DefaultTransport),
+// using the configured proxies and CA bundles.
+func (c *NetworkConfig) Transport() (*http.Transport, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := c.resolveOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = c.ProxyFunc()
+	transport.TLSClientConfig = tlsConfig.Clone()
+
+	if len(overrides) > 0 {
+		transport.DialContext = c.DialContext
+	}
+
+	return transport, nil
+}
+
+// resolveOverrides returns the parsed DNS overrides, keyed by "host:port" (or
+// "host:*").
+func (c *NetworkConfig) resolveOverrides() (map[string]string, error) {
+	c.resolveOnce.Do(func() {
+		c.resolve = make(map[string]string, len(c.Resolve))
+
+		for _, entry := range c.Resolve {
+			host, rest, _ := strings.Cut(entry, ":")
+			port, addr, ok := strings.Cut(rest, ":")
+
+			addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+
+			if !ok || host == "" || port == "" || net.ParseIP(addr) == nil {
+				c.resolveErr = fmt.Errorf("invalid resolve entry %q: expected HOST:PORT:ADDR (e.g. example.com:443:127.0.0.1)", entry)
+				return
+			}
+
+			c.resolve[strings.ToLower(host)+":"+port] = addr
+		}
+	})
+
+	return c.resolve, c.resolveErr
+}
+
+// ResolveAddr returns the address to connect to for the provided "host:port"
+// address, and true if it was overridden by a DNS override (see Resolve).
+func (c *NetworkConfig) ResolveAddr(addr string) (string, bool, error) {
+	overrides, err := c.resolveOverrides()
+	if err != nil || len(overrides) == 0 {
+		return addr, false, err
+	}
+
+	// Addresses which aren't host:port are left as-is.
+	host, port, serr := net.SplitHostPort(addr)
+	if serr != nil {
+		return addr, false, nil
+	}
+
+	ip, ok := overrides[strings.ToLower(host)+":"+port]
+	if !ok {
+		ip, ok = overrides[strings.ToLower(host)+":*"]
+	}
+
+	if !ok {
+		return addr, false, nil
+	}
+
+	return net.JoinHostPort(ip, port), true, nil
+}
+
+// DialContext dials the provided address, applying DNS overrides (see
+// Resolve). Suitable for http.Transport.DialContext and similar.
+func (c *NetworkConfig) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	addr, _, err := c.ResolveAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return d.DialContext(ctx, network, addr)
+}
+
+// firstEnv returns the value of the first non-empty environment variable.
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := getenv(key); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// firstNonEmpty returns the first non-empty value.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OCILabels returns the org.opencontainers.image.* labels (which can also be
+// used as annotations) for the version information, so build pipelines can
+// derive image labels from the same metadata as --version. The source, url,
+// and documentation labels are derived from the LinkSource, LinkWebsite, and
+// LinkDocs links (see GithubLinks), falling back to the module path. Labels without a known value are omitted.
+//
+// Example (using the --version-oci-labels flag):
+//
+//	docker build $(app --version-oci-labels | sed 's/^/--label /') .
+func (v *VersionInfo[T]) OCILabels(description string) map[string]string {
+	labels := map[string]string{
+		"org.opencontainers.image.title":       path.Base(v.Name),
+		"org.opencontainers.image.description": description,
+	}
+
+	if v.Version != "" && v.Version != "(devel)" {
+		labels["org.opencontainers.image.version"] = strings.TrimPrefix(v.Version, "v")
+	}
+
+	if v.Commit != "" && v.Commit != "unknown" {
+		labels["org.opencontainers.image.revision"] = v.Commit
+	}
+
+	if t, err := time.Parse(time.RFC3339, v.Date); err == nil {
+		labels["org.opencontainers.image.created"] = t.UTC().Format(time.RFC3339)
+	}
+
+	if l, ok := FindLink(v.Links, LinkSource); ok {
+		labels["org.opencontainers.image.source"] = l.URL
+	}
+
+	if l, ok := FindLink(v.Links, LinkWebsite); ok {
+		labels["org.opencontainers.image.url"] = l.URL
+	}
+
+	if l, ok := FindLink(v.Links, LinkDocs); ok {
+		labels["org.opencontainers.image.documentation"] = l.URL
+	}
+
+	if labels["org.opencontainers.image.source"] == "" && strings.Contains(v.Name, ".") && strings.Contains(v.Name, "/") {
+		labels["org.opencontainers.image.source"] = "https://" + v.Name
+	}
+
+	if labels["org.opencontainers.image.url"] == "" {
+		labels["org.opencontainers.image.url"] = labels["org.opencontainers.image.source"]
+	}
+
+	for k, val := range labels {
+		if val == "" {
+			delete(labels, k)
+		}
+	}
+
+	return labels
+}
+
+// writeOCILabels writes the provided labels to out, as sorted "key=value"
+// lines.
+func writeOCILabels(out io.Writer, labels map[string]string) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(out, "%s=%s\n", k, labels[k])
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Option is a functional option, which configures a CLI. This is a more
+// expressive alternative to the Options bitmask (which is still supported,
+// and can be provided with WithOptions). See CLI.Apply.
+type Option func(s *settings) error
+
+// settings are the settings configured through functional options.
+type settings struct {
+	options       Options
+	logger        *log.Logger
+	exitFunc      func(code int)
+	parserOptions []func(p *flags.Parser)
+
+	exitHooks       []ExitHook
+	exitHookTimeout time.Duration
+
+	flagOverrides []flagOverride
+
+	strictMode     StrictMode
+	strictPrefixes []string
+
+	maxConfigFileSize int
+	maxEnvValueLength int
+
+	upgradeHooks        []UpgradeHook
+	downgradeProtection bool
+	downgradeRefuse     bool
+	cache               bool
+	maxCacheSize        int64
+	doctorChecks        []DoctorCheck
+	deprecations        []commandDeprecation
+	fipsPolicyEnv       string
+	updateChannels      *updateChannelConfig
+	keyring             Keyring
+	configCommand       bool
+	completionCommand   bool
+	outputCapture       int
+	guidelines          bool
+}
+
+// WithOptions sets the provided Options bits.
+func WithOptions(options ...Options) Option {
+	return func(s *settings) error {
+		for _, o := range options {
+			s.options |= o
+		}
+		return nil
+	}
+}
+
+// WithLogger uses the provided logger, instead of initializing one from
+// LoggerConfig. Conflicts with OptDisableLogging.
+func WithLogger(logger *log.Logger) Option {
+	return func(s *settings) error {
+		if logger == nil {
+			return errors.New("WithLogger: logger is nil")
+		}
+
+		if s.logger != nil {
+			return errors.New("WithLogger: logger already configured")
+		}
+
+		s.logger = logger
+		return nil
+	}
+}
+
+// WithExitFunc uses the provided function to exit the process, instead of
+// os.Exit (e.g. to run cleanup logic, or to capture the exit code in tests).
+// If the function returns, ParseWithInit returns the associated error. Conflicts
+// with OptNoExit.
+func WithExitFunc(fn func(code int)) Option {
+	return func(s *settings) error {
+		if fn == nil {
+			return errors.New("WithExitFunc: function is nil")
+		}
+
+		if s.exitFunc != nil {
+			return errors.New("WithExitFunc: exit function already configured")
+		}
+
+		s.exitFunc = fn
+		return nil
+	}
+}
+
+// WithParserOptions invokes the provided functions with the go-flags parser,
+// after it has been created by clix, and before arguments are parsed. This
+// can be used to configure the parser in ways not directly supported by clix.
+func WithParserOptions(fns ...func(p *flags.Parser)) Option {
+	return func(s *settings) error {
+		for _, fn := range fns {
+			if fn == nil {
+				return errors.New("WithParserOptions: function is nil")
+			}
+		}
+
+		s.parserOptions = append(s.parserOptions, fns...)
+		return nil
+	}
+}
+
+// Apply applies the provided functional options to the CLI, returning an
+// error if any option is invalid, or conflicts with another option. Must be
+// called before Parse().
+//
+// Example:
+//
+//	err := cli.Apply(
+//		clix.WithOptions(clix.OptSubcommandsOptional),
+//		clix.WithLogger(logger),
+//	)
+func (cli *CLI[T]) Apply(opts ...Option) error {
+	cli.mu.Lock()
+	s := cli.settings
+	cli.mu.Unlock()
+
+	for _, opt := range opts {
+		if opt == nil {
+			return errors.New("nil option provided")
+		}
+
+		if err := opt(&s); err != nil {
+			return err
+		}
+	}
+
+	cli.mu.Lock()
+	cli.settings = s
+	cli.options |= s.options
+	cli.mu.Unlock()
+
+	return cli.validateSettings()
+}
+
+// validateSettings checks for conflicts between functional options and the
+// Options bitmask.
+func (cli *CLI[T]) validateSettings() error {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.settings.logger != nil && cli.options&OptDisableLogging != 0 {
+		return fmt.Errorf("option conflict: WithLogger cannot be used with OptDisableLogging")
+	}
+
+	if cli.settings.exitFunc != nil && cli.options&OptNoExit != 0 {
+		return fmt.Errorf("option conflict: WithExitFunc cannot be used with OptNoExit")
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OutputConfig are the flags used to write output to a file or stdout. File
+// writes are atomic (written to a temporary file in the same directory, and
+// renamed into place on Close), and gzip compressed if the file ends in ".gz".
+//
+// Example:
+//
+//	type Flags struct {
+//		Output clix.OutputConfig `group:"Output Options"`
+//	}
+//	[...]
+//	w, err := cli.Flags.Output.Create()
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Abort() // No-op if Close() was successful.
+//	// [write to w]
+//	return w.Close()
+type OutputConfig struct {
+	// OutputFile is the path to the output file, or "-" for stdout.
+	OutputFile string `short:"o" long:"output-file" default:"-" description:"path to output file (use '-' for stdout, .gz extension enables compression)"`
+
+	// OutputMode is the file mode used for the output file, in octal.
+	OutputMode string `long:"output-mode" default:"0644" description:"file mode (in octal) used for the output file"`
+}
+
+// Mode returns the parsed file mode.
+func (c *OutputConfig) Mode() (fs.FileMode, error) {
+	return parseFileMode(c.OutputMode, 0o644)
+}
+
+// Create returns a new OutputWriter for the configured destination. Close must
+// be called to commit the output to disk.
+func (c *OutputConfig) Create() (*OutputWriter, error) {
+	if c.OutputFile == "" || c.OutputFile == "-" {
+		return &OutputWriter{w: os.Stdout}, nil
+	}
+
+	mode, err := c.Mode()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, base := filepath.Split(c.OutputFile)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to set output file mode: %w", err)
+	}
+
+	w := &OutputWriter{
+		w:    f,
+		file: f,
+		path: c.OutputFile,
+	}
+
+	if strings.EqualFold(filepath.Ext(c.OutputFile), ".gz") {
+		w.gz = gzip.NewWriter(f)
+		w.w = w.gz
+	}
+
+	return w, nil
+}
+
+// OutputWriter is an io.WriteCloser which writes to stdout, or atomically to
+// a file.
+type OutputWriter struct {
+	w    io.Writer
+	gz   *gzip.Writer
+	file *os.File
+	path string
+	done bool
+}
+
+// Write implements io.Writer.
+func (w *OutputWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, os.ErrClosed
+	}
+
+	return w.w.Write(p)
+}
+
+// Close flushes any buffered data, and atomically moves the temporary file
+// into place (if writing to a file).
+func (w *OutputWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+
+	if w.file == nil {
+		return nil
+	}
+
+	var err error
+
+	if w.gz != nil {
+		err = w.gz.Close()
+	}
+
+	if err == nil {
+		err = w.file.Sync()
+	}
+
+	err = errors.Join(err, w.file.Close())
+	if err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if err = os.Rename(w.file.Name(), w.path); err != nil {
+		_ = os.Remove(w.file.Name())
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return nil
+}
+
+// Abort discards any output written to the temporary file. It is a no-op if
+// the writer was already closed, which makes it safe to defer.
+func (w *OutputWriter) Abort() {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	if w.file != nil {
+		_ = w.file.Close()
+		_ = os.Remove(w.file.Name())
+	}
+}
+
+// writeFileAtomic atomically writes data to the provided path, keeping the
+// permissions of an existing file (or using mode for new files).
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	out := &OutputConfig{OutputFile: path, OutputMode: strconv.FormatUint(uint64(mode), 8)}
+
+	w, err := out.Create()
+	if err != nil {
+		return err
+	}
+	defer w.Abort()
+
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,366 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultPackagePlatforms are the platforms included in generated package
+// manifests, when PackageOptions.Platforms isn't provided.
+var DefaultPackagePlatforms = []string{
+	"darwin/amd64", "darwin/arm64",
+	"linux/amd64", "linux/arm64",
+	"windows/amd64", "windows/arm64",
+}
+
+// packageChecksumPlaceholder is used for checksums which aren't provided.
+const packageChecksumPlaceholder = "REPLACE_WITH_SHA256"
+
+// PackageOptions configures the generated Homebrew formula and Scoop manifest
+// (see CLI.HomebrewFormula and CLI.ScoopManifest). All fields are optional.
+type PackageOptions struct {
+	// Name is the package (and binary) name. Defaults to AppName.
+	Name string
+
+	// Description is a short description of the application. Defaults to the
+	// first paragraph of CLI.Description.
+	Description string
+
+	// Homepage defaults to the website link, or the source link (see Links).
+	Homepage string
+
+	// License is the SPDX license identifier (e.g. "MIT").
+	License string
+
+	// Version defaults to the build version (without a "v" prefix).
+	Version string
+
+	// URL is the template of release archive URLs, with "{name}", "{version}",
+	// "{os}", "{arch}" and "{ext}" ("zip" on Windows, otherwise "tar.gz")
+	// placeholders. Defaults to GoReleaser's naming, within the releases link
+	// (see Links), e.g.:
+	//
+	//	https://github.com/org/repo/releases/download/v{version}/{name}_{version}_{os}_{arch}.{ext}
+	URL string
+
+	// Platforms are the "os/arch" pairs to include. Defaults to
+	// DefaultPackagePlatforms.
+	Platforms []string
+
+	// Checksums are the SHA256 checksums of release archives, keyed by
+	// "os/arch". Missing checksums are replaced with a placeholder.
+	Checksums map[string]string
+}
+
+// resolvedPackage is PackageOptions, with defaults applied.
+type resolvedPackage struct {
+	PackageOptions
+	source string // Source repository URL, if known.
+}
+
+// packageOptions returns PackageOptions, with defaults applied.
+func (cli *CLI[T]) packageOptions() *resolvedPackage {
+	if cli.VersionInfo == nil {
+		cli.VersionInfo = cli.GetVersionInfo()
+	}
+
+	p := &resolvedPackage{PackageOptions: cli.PackageOptions}
+
+	if p.Name == "" {
+		p.Name = cli.AppName()
+	}
+
+	if p.Description == "" && cli.Description != "" {
+		p.Description = packageDescription(cli.Description)
+	}
+
+	if l, ok := FindLink(cli.VersionInfo.Links, LinkSource); ok {
+		p.source = l.URL
+	}
+
+	if p.Homepage == "" {
+		if l, ok := FindLink(cli.VersionInfo.Links, LinkWebsite); ok {
+			p.Homepage = l.URL
+		} else {
+			p.Homepage = p.source
+		}
+	}
+
+	if p.Version == "" {
+		p.Version = strings.TrimPrefix(cli.VersionInfo.Version, "v")
+	}
+
+	if p.URL == "" {
+		base := "https://example.com/releases"
+		if l, ok := FindLink(cli.VersionInfo.Links, LinkReleases); ok {
+			base = strings.TrimSuffix(l.URL, "/")
+		}
+		p.URL = base + "/download/v{version}/{name}_{version}_{os}_{arch}.{ext}"
+	}
+
+	if len(p.Platforms) == 0 {
+		p.Platforms = DefaultPackagePlatforms
+	}
+
+	return p
+}
+
+// url returns the release archive URL for the provided platform, using the
+// provided version (e.g. Scoop's "$version" placeholder).
+func (p *resolvedPackage) url(goos, goarch, version string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+
+	return strings.NewReplacer(
+		"{name}", p.Name,
+		"{version}", version,
+		"{os}", goos,
+		"{arch}", goarch,
+		"{ext}", ext,
+	).Replace(p.URL)
+}
+
+// checksum returns the checksum for the provided platform, or a placeholder.
+func (p *resolvedPackage) checksum(platform string) string {
+	if sum := p.Checksums[platform]; sum != "" {
+		return sum
+	}
+
+	return packageChecksumPlaceholder
+}
+
+// platforms returns the architectures of the provided OS.
+func (p *resolvedPackage) platforms(goos string) []string {
+	var arches []string
+
+	for _, platform := range p.Platforms {
+		if platformOS, arch, ok := strings.Cut(platform, "/"); ok && platformOS == goos {
+			arches = append(arches, arch)
+		}
+	}
+
+	return arches
+}
+
+// packageDescription returns the first paragraph of the provided markdown, as
+// a single line.
+func packageDescription(md string) string {
+	var lines []string
+
+	scanner := bufio.NewScanner(strings.NewReader(md))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSuffix(strings.Join(lines, " "), ".")
+}
+
+// homebrewClass returns the Homebrew formula class name of the provided name
+// (e.g. "FooBar" for "foo-bar").
+func homebrewClass(name string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '@':
+			b.WriteString("AT")
+			upper = true
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// rubyQuote returns s as a double-quoted Ruby string literal.
+func rubyQuote(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "#{", `\#{`)
+}
+
+// HomebrewFormula writes a Homebrew formula skeleton to out, using
+// PackageOptions (and defaults derived from the version information and
+// links), including release archives for macOS and Linux, and installation of
+// shell completions and the man page, generated with --generate-shell and
+// --generate-man. Must be called after Parse().
+func (cli *CLI[T]) HomebrewFormula(out io.Writer) error {
+	p := cli.packageOptions()
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "class %s < Formula\n", homebrewClass(p.Name))
+
+	if p.Description != "" {
+		fmt.Fprintf(&buf, "  desc %s\n", rubyQuote(p.Description))
+	}
+
+	if p.Homepage != "" {
+		fmt.Fprintf(&buf, "  homepage %s\n", rubyQuote(p.Homepage))
+	}
+
+	fmt.Fprintf(&buf, "  version %s\n", rubyQuote(p.Version))
+
+	if p.License != "" {
+		fmt.Fprintf(&buf, "  license %s\n", rubyQuote(p.License))
+	}
+
+	for _, goos := range []string{"darwin", "linux"} {
+		arches := p.platforms(goos)
+		if len(arches) == 0 {
+			continue
+		}
+
+		block := map[string]string{"darwin": "on_macos", "linux": "on_linux"}[goos]
+		fmt.Fprintf(&buf, "\n  %s do\n", block)
+
+		for _, arch := range arches {
+			switch arch {
+			case "arm64":
+				fmt.Fprintf(&buf, "    on_arm do\n")
+			case "amd64":
+				fmt.Fprintf(&buf, "    on_intel do\n")
+			default:
+				// Homebrew only supports ARM and Intel.
+				continue
+			}
+
+			fmt.Fprintf(&buf, "      url %s\n", rubyQuote(p.url(goos, arch, p.Version)))
+			fmt.Fprintf(&buf, "      sha256 %s\n", rubyQuote(p.checksum(goos+"/"+arch)))
+			fmt.Fprintf(&buf, "    end\n")
+		}
+
+		fmt.Fprintf(&buf, "  end\n")
+	}
+
+	bin := rubyQuote(p.Name)
+
+	fmt.Fprintf(&buf, "\n  def install\n")
+	fmt.Fprintf(&buf, "    bin.install %s\n\n", bin)
+	fmt.Fprintf(&buf, "    (bash_completion/%s).write Utils.safe_popen_read(bin/%s, \"--generate-shell=bash-completion\")\n", bin, bin)
+	fmt.Fprintf(&buf, "    (zsh_completion/%s).write Utils.safe_popen_read(bin/%s, \"--generate-shell=zsh-completion\")\n", rubyQuote("_"+p.Name), bin)
+	fmt.Fprintf(&buf, "    (fish_completion/%s).write Utils.safe_popen_read(bin/%s, \"--generate-shell=fish-completion\")\n", rubyQuote(p.Name+".fish"), bin)
+	fmt.Fprintf(&buf, "    (man1/%s).write Utils.safe_popen_read(bin/%s, \"--generate-man\")\n", rubyQuote(p.Name+".1"), bin)
+	fmt.Fprintf(&buf, "  end\n")
+
+	testFlag := "--version"
+	if cli.IsSet(OptDisableVersion) {
+		testFlag = "--help"
+	}
+
+	fmt.Fprintf(&buf, "\n  test do\n")
+	fmt.Fprintf(&buf, "    system bin/%s, %s\n", bin, rubyQuote(testFlag))
+	fmt.Fprintf(&buf, "  end\n")
+	fmt.Fprintf(&buf, "end\n")
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+type scoopArch struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash,omitempty"`
+}
+
+type scoopArchitectures struct {
+	AMD64 *scoopArch `json:"64bit,omitempty"`
+	ARM64 *scoopArch `json:"arm64,omitempty"`
+	I386  *scoopArch `json:"32bit,omitempty"`
+}
+
+type scoopCheckver struct {
+	GitHub string `json:"github"`
+}
+
+type scoopAutoupdate struct {
+	Architecture scoopArchitectures `json:"architecture"`
+}
+
+type scoopManifest struct {
+	Version      string             `json:"version"`
+	Description  string             `json:"description,omitempty"`
+	Homepage     string             `json:"homepage,omitempty"`
+	License      string             `json:"license,omitempty"`
+	Architecture scoopArchitectures `json:"architecture"`
+	Bin          string             `json:"bin"`
+	Checkver     *scoopCheckver     `json:"checkver,omitempty"`
+	Autoupdate   *scoopAutoupdate   `json:"autoupdate,omitempty"`
+}
+
+// ScoopManifest writes a Scoop manifest skeleton to out, using PackageOptions
+// (and defaults derived from the version information and links), including
+// release archives for Windows, and an autoupdate stanza (with a GitHub
+// checkver, if the source is hosted on GitHub). Must be called after Parse().
+func (cli *CLI[T]) ScoopManifest(out io.Writer) error {
+	p := cli.packageOptions()
+
+	m := &scoopManifest{
+		Version:     p.Version,
+		Description: p.Description,
+		Homepage:    p.Homepage,
+		License:     p.License,
+		Bin:         p.Name + ".exe",
+		Autoupdate:  &scoopAutoupdate{},
+	}
+
+	for _, arch := range p.platforms("windows") {
+		var current, next **scoopArch
+
+		switch arch {
+		case "amd64":
+			current, next = &m.Architecture.AMD64, &m.Autoupdate.Architecture.AMD64
+		case "arm64":
+			current, next = &m.Architecture.ARM64, &m.Autoupdate.Architecture.ARM64
+		case "386":
+			current, next = &m.Architecture.I386, &m.Autoupdate.Architecture.I386
+		default:
+			continue
+		}
+
+		*current = &scoopArch{URL: p.url("windows", arch, p.Version), Hash: p.checksum("windows/" + arch)}
+		*next = &scoopArch{URL: p.url("windows", arch, "$version")}
+	}
+
+	if strings.HasPrefix(p.source, "https://github.com/") {
+		m.Checkver = &scoopCheckver{GitHub: p.source}
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("failed to encode scoop manifest: %w", err)
+	}
+
+	_, err := buf.WriteTo(out)
+	return err
+}
@@ -0,0 +1,90 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+)
+
+// AppName returns the name used for application-specific directories, which
+// is the last element of the version name (e.g. "clix" for
+// "github.com/lrstanley/clix").
+func (cli *CLI[T]) AppName() string {
+	if cli.VersionInfo == nil {
+		cli.VersionInfo = cli.GetVersionInfo()
+	}
+
+	return path.Base(cli.VersionInfo.Name)
+}
+
+// StateDir returns the application-specific state directory, creating it if
+// needed. This is $XDG_STATE_HOME/<app> (defaulting to ~/.local/state/<app>)
+// on unix-like systems, and %LocalAppData%\<app>\state on Windows.
+func (cli *CLI[T]) StateDir() (string, error) {
+	var base string
+
+	switch {
+	case getenv("XDG_STATE_HOME") != "":
+		base = getenv("XDG_STATE_HOME")
+	case runtime.GOOS == "windows":
+		base = getenv("LocalAppData")
+		if base == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+
+		return mkdir(filepath.Join(base, cli.AppName(), "state"))
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		base = filepath.Join(home, ".local", "state")
+	}
+
+	return mkdir(filepath.Join(base, cli.AppName()))
+}
+
+// CacheDir returns the application-specific cache directory, creating it if
+// needed. This is $XDG_CACHE_HOME/<app> (defaulting to ~/.cache/<app>) on
+// unix-like systems, and %LocalAppData%\<app>\cache on Windows.
+func (cli *CLI[T]) CacheDir() (string, error) {
+	var base string
+
+	switch {
+	case getenv("XDG_CACHE_HOME") != "":
+		base = getenv("XDG_CACHE_HOME")
+	case runtime.GOOS == "windows":
+		base = getenv("LocalAppData")
+		if base == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+
+		return mkdir(filepath.Join(base, cli.AppName(), "cache"))
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		base = filepath.Join(home, ".cache")
+	}
+
+	return mkdir(filepath.Join(base, cli.AppName()))
+}
+
+// mkdir creates the provided directory (and any parents) if it doesn't exist,
+// returning the directory.
+func mkdir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,383 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ErrProvenanceMismatch is returned when the binary doesn't match the provided
+// provenance document (see VersionInfo.VerifyProvenance).
+var ErrProvenanceMismatch = errors.New("clix: binary does not match provenance")
+
+// ProvenanceCheck is the result of comparing a single claim of a provenance
+// document against the binary.
+type ProvenanceCheck struct {
+	Name     string `json:"name"`               // e.g. "binary digest", "vcs revision", or "module <path>".
+	Expected string `json:"expected,omitempty"` // Value claimed by the provenance document.
+	Actual   string `json:"actual,omitempty"`   // Value of the binary.
+	Skipped  bool   `json:"skipped,omitempty"`  // The claim couldn't be checked (e.g. the binary has no VCS information).
+	Error    string `json:"error,omitempty"`    // Why the check failed, if it did.
+}
+
+// Passed returns true if the check passed.
+func (c *ProvenanceCheck) Passed() bool {
+	return !c.Skipped && c.Error == ""
+}
+
+// ProvenanceReport is the result of verifying the binary against a provenance
+// document. See VersionInfo.VerifyProvenance.
+type ProvenanceReport struct {
+	PredicateType string            `json:"predicate_type"`
+	BuilderID     string            `json:"builder_id,omitempty"`
+	Checks        []ProvenanceCheck `json:"checks"`
+}
+
+// Err returns an error matching ErrProvenanceMismatch if any check failed, or
+// if none could be performed.
+func (r *ProvenanceReport) Err() error {
+	var passed, failed int
+
+	for i := range r.Checks {
+		switch {
+		case r.Checks[i].Passed():
+			passed++
+		case !r.Checks[i].Skipped:
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d checks failed", ErrProvenanceMismatch, failed, len(r.Checks))
+	}
+
+	if passed == 0 {
+		return fmt.Errorf("%w: no claims of the provenance document could be verified", ErrProvenanceMismatch)
+	}
+
+	return nil
+}
+
+// inTotoStatement is an in-toto attestation statement, with a SLSA provenance
+// predicate (v0.1, v0.2, and v1 are supported).
+type inTotoStatement struct {
+	Type          string `json:"_type"`
+	PredicateType string `json:"predicateType"`
+	Subject       []struct {
+		Name   string            `json:"name"`
+		Digest map[string]string `json:"digest"`
+	} `json:"subject"`
+	Predicate struct {
+		// v0.1/v0.2.
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Invocation struct {
+			ConfigSource provenanceResource `json:"configSource"`
+		} `json:"invocation"`
+		Materials []provenanceResource `json:"materials"`
+
+		// v1.
+		BuildDefinition struct {
+			ResolvedDependencies []provenanceResource `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// provenanceResource is a SLSA material (v0.x), or resource descriptor (v1).
+type provenanceResource struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// dsseEnvelope is a DSSE envelope, which wraps in-toto statements (e.g. in
+// .intoto.jsonl files). Signatures aren't verified.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+}
+
+// parseProvenance returns the first in-toto statement with a SLSA provenance
+// predicate in the provided document, which can be a statement, a DSSE
+// envelope, or a stream of either (e.g. JSON lines).
+func parseProvenance(doc []byte) (*inTotoStatement, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("no SLSA provenance statement found")
+			}
+			return nil, fmt.Errorf("invalid provenance document: %w", err)
+		}
+
+		var env dsseEnvelope
+		if err := json.Unmarshal(raw, &env); err == nil && env.Payload != "" {
+			payload, err := base64.StdEncoding.DecodeString(env.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DSSE envelope payload: %w", err)
+			}
+			raw = payload
+		}
+
+		stmt := &inTotoStatement{}
+		if err := json.Unmarshal(raw, stmt); err != nil {
+			return nil, fmt.Errorf("invalid in-toto statement: %w", err)
+		}
+
+		if strings.HasPrefix(stmt.PredicateType, "https://slsa.dev/provenance/") {
+			return stmt, nil
+		}
+	}
+}
+
+// VerifyProvenance compares the binary against the provided SLSA provenance
+// document (an in-toto statement, optionally wrapped in a DSSE envelope), as
+// produced by e.g. the SLSA GitHub generator. It checks that the SHA256 digest
+// of the running executable matches a subject, that the embedded VCS revision
+// matches the source revision, and that the versions and sums of Go module
+// materials ("pkg:golang/...") match the embedded dependencies. Claims which
+// can't be checked are skipped. Signatures of the document aren't verified,
+// which should be done separately (e.g. with cosign or slsa-verifier).
+//
+// The returned error is only non-nil if the document is invalid. Use
+// ProvenanceReport.Err to check the result.
+func (v *VersionInfo[T]) VerifyProvenance(doc []byte) (*ProvenanceReport, error) {
+	stmt, err := parseProvenance(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProvenanceReport{
+		PredicateType: stmt.PredicateType,
+		BuilderID:     stmt.Predicate.Builder.ID,
+	}
+
+	if report.BuilderID == "" {
+		report.BuilderID = stmt.Predicate.RunDetails.Builder.ID
+	}
+
+	// Binary digest.
+	check := ProvenanceCheck{Name: "binary digest"}
+
+	var digests []string
+	for _, s := range stmt.Subject {
+		if d := s.Digest["sha256"]; d != "" {
+			digests = append(digests, strings.ToLower(d))
+		}
+	}
+
+	switch digest, err := executableDigest(); {
+	case len(digests) == 0:
+		check.Skipped = true
+	case err != nil:
+		check.Error = err.Error()
+	default:
+		check.Actual = digest
+		check.Expected = strings.Join(digests, ", ")
+		if !slices.Contains(digests, digest) {
+			check.Error = "no subject matches the executable"
+		}
+	}
+
+	report.Checks = append(report.Checks, check)
+
+	resources := append([]provenanceResource{stmt.Predicate.Invocation.ConfigSource}, stmt.Predicate.Materials...)
+	resources = append(resources, stmt.Predicate.BuildDefinition.ResolvedDependencies...)
+
+	// VCS revision.
+	check = ProvenanceCheck{Name: "vcs revision"}
+
+	var revisions []string
+	for _, r := range resources {
+		if !strings.HasPrefix(r.URI, "git+") {
+			continue
+		}
+
+		for _, key := range []string{"gitCommit", "sha1"} {
+			if rev := strings.ToLower(r.Digest[key]); rev != "" && !slices.Contains(revisions, rev) {
+				revisions = append(revisions, rev)
+			}
+		}
+	}
+
+	switch revision := v.GetSetting("vcs.revision", ""); {
+	case len(revisions) == 0 || revision == "":
+		check.Skipped = true
+	default:
+		check.Actual = revision
+		check.Expected = strings.Join(revisions, ", ")
+
+		switch {
+		case !slices.Contains(revisions, strings.ToLower(revision)):
+			check.Error = "revision doesn't match the source revision"
+		case v.GetSetting("vcs.modified", "") == "true":
+			check.Error = "binary was built from a modified working tree"
+		}
+	}
+
+	report.Checks = append(report.Checks, check)
+
+	// Module versions and sums.
+	v.Collect()
+
+	for _, r := range resources {
+		path, version, ok := golangPURL(r.URI)
+		if !ok {
+			continue
+		}
+
+		check = ProvenanceCheck{Name: "module " + path, Expected: version}
+
+		i := slices.IndexFunc(v.Dependencies, func(m Module) bool { return m.Path == path })
+		if i < 0 {
+			// Materials can include modules which aren't linked into the binary
+			// (e.g. tools and test dependencies).
+			check.Skipped = true
+			report.Checks = append(report.Checks, check)
+			continue
+		}
+
+		m := v.Dependencies[i]
+		if m.Replace != nil {
+			m = *m.Replace
+		}
+
+		check.Actual = m.Version
+
+		if m.Version != version {
+			check.Error = "version doesn't match"
+		} else if sum := moduleDigestSum(r.Digest); sum != "" && m.Sum != "" && sum != m.Sum {
+			check.Expected += " " + sum
+			check.Actual += " " + m.Sum
+			check.Error = "sum doesn't match"
+		}
+
+		report.Checks = append(report.Checks, check)
+	}
+
+	return report, nil
+}
+
+// executableDigest returns the hex-encoded SHA256 digest of the running
+// executable.
+func executableDigest() (string, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// golangPURL returns the module path and version of a Go package URL (e.g.
+// "pkg:golang/github.com/foo/bar@v1.2.3").
+func golangPURL(uri string) (path, version string, ok bool) {
+	rest, ok := strings.CutPrefix(uri, "pkg:golang/")
+	if !ok {
+		return "", "", false
+	}
+
+	// Qualifiers and subpaths aren't relevant.
+	rest, _, _ = strings.Cut(rest, "?")
+	rest, _, _ = strings.Cut(rest, "#")
+
+	path, version, ok = strings.Cut(rest, "@")
+	if !ok {
+		return "", "", false
+	}
+
+	if p, err := url.PathUnescape(path); err == nil {
+		path = p
+	}
+
+	if v, err := url.PathUnescape(version); err == nil {
+		version = v
+	}
+
+	return path, version, true
+}
+
+// moduleDigestSum returns the Go module sum (e.g. "h1:...") of the provided
+// digest, which is either provided directly ("h1"), or as the hex-encoded
+// SHA256 hash the sum is derived from.
+func moduleDigestSum(digest map[string]string) string {
+	if h1 := digest["h1"]; h1 != "" {
+		if !strings.HasPrefix(h1, "h1:") {
+			h1 = "h1:" + h1
+		}
+		return h1
+	}
+
+	if b, err := hex.DecodeString(digest["sha256"]); err == nil && len(b) == sha256.Size {
+		return "h1:" + base64.StdEncoding.EncodeToString(b)
+	}
+
+	return ""
+}
+
+// verifyProvenance verifies the binary against the provenance document at the
+// provided path (see --verify-provenance), and writes the report to out.
+func (cli *CLI[T]) verifyProvenance(out *os.File, path string) error {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read provenance: %w", err)
+	}
+
+	report, err := cli.VersionInfo.VerifyProvenance(doc)
+	if err != nil {
+		return fmt.Errorf("failed to verify provenance: %w", err)
+	}
+
+	printf := func(format string, args ...any) {
+		fmt.Fprint(out, colorize(out, fmt.Sprintf(format, args...)))
+	}
+
+	printf("<cyan>%s</> :: <yellow>%s</>\n", report.PredicateType, report.BuilderID)
+
+	for _, c := range report.Checks {
+		switch {
+		case c.Skipped:
+			printf("<gray>SKIP  %s</>\n", c.Name)
+		case c.Passed():
+			printf("<greenB>PASS</>  %s <gray>(%s)</>\n", c.Name, c.Actual)
+		default:
+			printf("<redB>FAIL</>  %s: %s\n", c.Name, c.Error)
+			if c.Expected != "" || c.Actual != "" {
+				printf("      <gray>expected: %s</>\n      <gray>actual:   %s</>\n", c.Expected, c.Actual)
+			}
+		}
+	}
+
+	return report.Err()
+}
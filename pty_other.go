@@ -0,0 +1,24 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build !linux
+
+package clix
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// openPTY is unsupported on this platform.
+func openPTY() (ptm, pts *os.File, err error) {
+	return nil, nil, errors.New("pty allocation is not supported on this platform")
+}
+
+// setControllingTTY is a no-op on this platform.
+func setControllingTTY(_ *exec.Cmd) {}
+
+// copyWinsize is a no-op on this platform.
+func copyWinsize(_, _ *os.File) {}
@@ -0,0 +1,277 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+	"golang.org/x/term"
+)
+
+// MaxRecordedStdin is the maximum amount of stdin captured by --record, in
+// bytes. Input beyond this is still provided to the application, but isn't
+// recorded (see Recording.StdinTruncated).
+const MaxRecordedStdin = 10 << 20 // 10MiB.
+
+// recordEnvKeys are environment variables which affect clix itself, which are
+// recorded in addition to those of flags.
+var recordEnvKeys = []string{
+	"ACCESSIBLE", "CLICOLOR_FORCE", "COLUMNS", "FORCE_COLOR", "LANG", "LC_ALL",
+	"NO_COLOR", "TERM", "TZ",
+}
+
+// Recording is an invocation recorded with --record, which can be replayed
+// with --replay (e.g. to reproduce user-reported bugs). Values of secret flags
+// (see RedactArgs) are redacted, and the environment is limited to variables
+// of flags, and those which affect clix output (e.g. NO_COLOR and TERM).
+type Recording struct {
+	RecordedAt     time.Time         `json:"recorded_at"`
+	Command        string            `json:"command"`
+	Args           []string          `json:"args"`
+	Env            map[string]string `json:"env,omitempty"`
+	Dir            string            `json:"dir,omitempty"`
+	Stdin          []byte            `json:"stdin,omitempty"`
+	StdinTruncated bool              `json:"stdin_truncated,omitempty"`
+	Version        string            `json:"version"`
+	OS             string            `json:"os"`
+	Arch           string            `json:"arch"`
+	ExitCode       int               `json:"exit_code"`
+	Error          string            `json:"error,omitempty"`
+	DurationMS     int64             `json:"duration_ms"`
+}
+
+// ReadRecording reads a recording written with --record.
+func ReadRecording(path string) (*Recording, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Recording{}
+	if err = json.Unmarshal(b, rec); err != nil {
+		return nil, fmt.Errorf("invalid recording %q: %w", path, err)
+	}
+
+	return rec, nil
+}
+
+// recorder captures the current invocation, for --record.
+type recorder struct {
+	path string
+
+	mu        sync.Mutex
+	stdin     bytes.Buffer
+	truncated bool
+}
+
+// Write implements io.Writer, capturing stdin up to MaxRecordedStdin.
+func (r *recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n := MaxRecordedStdin - r.stdin.Len(); n < len(p) {
+		r.stdin.Write(p[:max(n, 0)])
+		r.truncated = true
+		return len(p), nil
+	}
+
+	return r.stdin.Write(p)
+}
+
+// recordArgs returns the values of --record and --replay (before any "--"),
+// and the arguments without them.
+func recordArgs(args []string) (record, replay string, rest []string) {
+	rest = make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		for _, name := range []string{"--record", "--replay"} {
+			value, ok := strings.CutPrefix(arg, name+"=")
+			if !ok && arg == name && i+1 < len(args) {
+				value, ok = args[i+1], true
+				i++
+			}
+
+			if !ok {
+				continue
+			}
+
+			if name == "--record" {
+				record = value
+			} else {
+				replay = value
+			}
+
+			arg = ""
+			break
+		}
+
+		if arg != "" {
+			rest = append(rest, arg)
+		}
+	}
+
+	return record, replay, rest
+}
+
+// setupRecording handles --replay (replacing the arguments, environment and
+// stdin with those of the recording), and --record (capturing stdin, for
+// writeRecording). This is done before parsing, as the arguments may change.
+func (cli *CLI[T]) setupRecording() error {
+	record, replay, args := recordArgs(os.Args[1:])
+
+	if replay != "" {
+		rec, err := ReadRecording(replay)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "replaying %s invocation recorded at %s (version %s, %s/%s)\n",
+			rec.Command, rec.RecordedAt.Format(time.RFC3339), rec.Version, rec.OS, rec.Arch)
+
+		cli.Replay = replay
+		args = rec.Args
+
+		for k, v := range rec.Env {
+			// Secrets must be provided through the current environment.
+			if v == Redacted {
+				continue
+			}
+
+			if err = os.Setenv(k, v); err != nil {
+				return err
+			}
+		}
+
+		stdin, err := readerFile(bytes.NewReader(rec.Stdin))
+		if err != nil {
+			return err
+		}
+		os.Stdin = stdin
+	}
+
+	if record != "" {
+		cli.Record = record
+		cli.recorder = &recorder{path: record}
+
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			stdin, err := readerFile(io.TeeReader(os.Stdin, cli.recorder))
+			if err != nil {
+				return err
+			}
+			os.Stdin = stdin
+		}
+	}
+
+	if record != "" || replay != "" {
+		// The remaining arguments are parsed (and e.g. recorded in the command
+		// history) as if they were provided directly.
+		os.Args = append([]string{os.Args[0]}, args...)
+	}
+
+	return nil
+}
+
+// readerFile returns a file which provides the contents of r, so it can be
+// used in place of os.Stdin.
+func readerFile(r io.Reader) (*os.File, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		_, _ = io.Copy(pw, r)
+		_ = pw.Close()
+	}()
+
+	return pr, nil
+}
+
+// writeRecording writes the recording of the current invocation (see
+// --record).
+func (cli *CLI[T]) writeRecording(runErr error) error {
+	cli.mu.Lock()
+	started := cli.started
+	cli.mu.Unlock()
+
+	r := cli.recorder
+
+	rec := &Recording{
+		RecordedAt: started,
+		Command:    filepath.Base(os.Args[0]),
+		Args:       cli.RedactArgs(os.Args[1:]),
+		Env:        make(map[string]string),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		ExitCode:   ExitCode(runErr),
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+
+	rec.Dir, _ = os.Getwd()
+
+	if cli.VersionInfo != nil {
+		rec.Version = cli.VersionInfo.Version
+	}
+
+	for _, key := range recordEnvKeys {
+		if v, ok := os.LookupEnv(key); ok {
+			rec.Env[key] = v
+		}
+	}
+
+	if cli.Parser != nil {
+		walkOptions(cli.Parser.Command, func(option *flags.Option) {
+			key := option.EnvKeyWithNamespace()
+			if key == "" {
+				return
+			}
+
+			if v, ok := os.LookupEnv(key); ok {
+				if isSecretOption(option) {
+					v = Redacted
+				}
+				rec.Env[key] = v
+			}
+		})
+	}
+
+	r.mu.Lock()
+	rec.Stdin = bytes.Clone(r.stdin.Bytes())
+	rec.StdinTruncated = r.truncated
+	r.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(rec); err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, buf.Bytes(), 0o600)
+}
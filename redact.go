@@ -0,0 +1,145 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Redacted is the replacement for redacted values.
+const Redacted = "<redacted>"
+
+// secretNameRegex matches flag names which likely contain secrets.
+var secretNameRegex = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api[-_.]?key|private[-_.]?key|credential|bearer)`)
+
+// isSecretOption returns true if the provided option likely contains a secret,
+// either because it has a `secret:"true"` struct tag, or its name matches
+// common secret names.
+func isSecretOption(option *flags.Option) bool {
+	if option.Field().Tag.Get("secret") == "true" {
+		return true
+	}
+
+	return secretNameRegex.MatchString(option.LongName)
+}
+
+// RedactArgs returns a copy of the provided arguments with the values of any
+// secret flags replaced with Redacted. Flags are secret if they have a
+// `secret:"true"` struct tag, or their name looks like a secret (e.g.
+// contains "password" or "token"). Must be called after Parse().
+func (cli *CLI[T]) RedactArgs(args []string) []string {
+	long := make(map[string]bool)
+	short := make(map[string]bool)
+
+	if cli.Parser != nil {
+		collectSecretOptions(cli.Parser.Command, long, short)
+	}
+
+	out := make([]string, len(args))
+	copy(out, args)
+
+	for i := 0; i < len(out); i++ {
+		arg := out[i]
+
+		if arg == "--" {
+			break
+		}
+
+		var name string
+		var secrets map[string]bool
+
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, secrets = arg[2:], long
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			name, secrets = arg[1:], short
+			if len(arg) > 2 && arg[2] != '=' {
+				// -tVALUE
+				if secrets[arg[1:2]] {
+					out[i] = arg[:2] + Redacted
+				}
+				continue
+			}
+		default:
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(name, "=")
+		if !secrets[name] {
+			continue
+		}
+
+		if hasValue {
+			out[i] = arg[:strings.Index(arg, "=")+1] + Redacted
+		} else if i+1 < len(out) {
+			out[i+1] = Redacted
+			i++
+		}
+	}
+
+	return out
+}
+
+// collectSecretOptions collects the long and short names of all secret
+// options of the provided command (including its groups and sub-commands).
+func collectSecretOptions(cmd *flags.Command, long, short map[string]bool) {
+	walkOptions(cmd, func(option *flags.Option) {
+		if !isSecretOption(option) || option.Field().Type.Kind() == reflect.Bool {
+			return
+		}
+
+		if option.LongName != "" {
+			long[option.LongNameWithNamespace()] = true
+		}
+
+		if option.ShortName != 0 {
+			short[string(option.ShortName)] = true
+		}
+	})
+}
+
+// minRedactLength is the minimum length of secret values replaced by
+// RedactText, so short values (e.g. "1") don't redact unrelated text.
+const minRedactLength = 4
+
+// RedactText returns the provided text with the current values of any secret
+// flags (see RedactArgs) replaced with Redacted. Values shorter than 4
+// characters are left as-is. Must be called after Parse().
+func (cli *CLI[T]) RedactText(s string) string {
+	if cli.Parser == nil || s == "" {
+		return s
+	}
+
+	var values []string
+
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		if !isSecretOption(option) {
+			return
+		}
+
+		switch v := option.Value().(type) {
+		case string:
+			values = append(values, v)
+		case []string:
+			values = append(values, v...)
+		}
+	})
+
+	// Longer values first, so values containing others are fully redacted.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, v := range values {
+		if len(v) >= minRedactLength {
+			s = strings.ReplaceAll(s, v, Redacted)
+		}
+	}
+
+	return s
+}
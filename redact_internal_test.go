@@ -0,0 +1,63 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"testing"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+func TestIsSecretOption(t *testing.T) {
+	var opts struct {
+		Name         string `long:"name"`
+		Password     string `long:"password"`
+		Passwd       string `long:"db-passwd"`
+		Secret       string `long:"client-secret"`
+		Token        string `long:"token"`
+		APIKey       string `long:"api-key"`
+		APIKeyDotted string `long:"api.key"`
+		PrivateKey   string `long:"private_key"`
+		Credentials  string `long:"credentials-file"`
+		Bearer       string `long:"bearer"`
+		Upper        string `long:"PASSWORD"`
+		Tagged       string `long:"passphrase" secret:"true"`
+		NotTagged    string `long:"keyboard" secret:"false"`
+		Tokenizer    string `long:"tokenizer"`
+	}
+
+	p := flags.NewParser(&opts, flags.None)
+
+	tests := map[string]bool{
+		"name":             false,
+		"password":         true,
+		"db-passwd":        true,
+		"client-secret":    true,
+		"token":            true,
+		"api-key":          true,
+		"api.key":          true,
+		"private_key":      true,
+		"credentials-file": true,
+		"bearer":           true,
+		"PASSWORD":         true,
+		"passphrase":       true,
+		"keyboard":         false,
+		// Names are matched anywhere, so this is (conservatively) a secret.
+		"tokenizer": true,
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			option := p.FindOptionByLongName(name)
+			if option == nil {
+				t.Fatalf("option %q not found", name)
+			}
+
+			if got := isSecretOption(option); got != want {
+				t.Fatalf("isSecretOption(%q) = %v, want %v", name, got, want)
+			}
+		})
+	}
+}
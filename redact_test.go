@@ -0,0 +1,67 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lrstanley/clix"
+	"github.com/lrstanley/clix/clixtest"
+)
+
+type redactFlags struct {
+	Name       string   `long:"name" short:"n" description:"name"`
+	Password   string   `long:"password" short:"p" description:"password"`
+	APIKey     string   `long:"api-key" description:"api key"`
+	Passphrase string   `long:"passphrase" secret:"true" description:"tagged as secret"`
+	Tokens     []string `long:"token" description:"repeatable token"`
+	NoToken    bool     `long:"no-token" description:"boolean secret-like name"`
+
+	DB struct {
+		Secret string `long:"secret" description:"namespaced secret"`
+	} `group:"DB" namespace:"db"`
+}
+
+func TestRedactArgs(t *testing.T) {
+	cli := &clix.CLI[redactFlags]{}
+	clixtest.Run(t, cli, nil, nil)
+
+	r := clix.Redacted
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "not-secret", args: []string{"--name", "foo", "-n", "bar"}, want: []string{"--name", "foo", "-n", "bar"}},
+		{name: "separate-value", args: []string{"--password", "hunter2"}, want: []string{"--password", r}},
+		{name: "equals-value", args: []string{"--password=hunter2"}, want: []string{"--password=" + r}},
+		{name: "short", args: []string{"-p", "hunter2"}, want: []string{"-p", r}},
+		{name: "short-attached", args: []string{"-phunter2"}, want: []string{"-p" + r}},
+		{name: "short-equals", args: []string{"-p=hunter2"}, want: []string{"-p=" + r}},
+		{name: "name-match", args: []string{"--api-key", "abc"}, want: []string{"--api-key", r}},
+		{name: "secret-tag", args: []string{"--passphrase=abc"}, want: []string{"--passphrase=" + r}},
+		{name: "repeatable", args: []string{"--token", "a", "--token", "b"}, want: []string{"--token", r, "--token", r}},
+		{name: "namespaced", args: []string{"--db.secret", "abc"}, want: []string{"--db.secret", r}},
+		{name: "bool-ignored", args: []string{"--no-token", "positional"}, want: []string{"--no-token", "positional"}},
+		{name: "after-terminator", args: []string{"--", "--password", "x"}, want: []string{"--", "--password", "x"}},
+		{name: "trailing-flag", args: []string{"--password"}, want: []string{"--password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := slices.Clone(tt.args)
+
+			if got := cli.RedactArgs(tt.args); !slices.Equal(got, tt.want) {
+				t.Fatalf("RedactArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+
+			if !slices.Equal(in, tt.args) {
+				t.Fatalf("RedactArgs modified its input: %q", tt.args)
+			}
+		})
+	}
+}
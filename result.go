@@ -0,0 +1,210 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result is the machine-readable result envelope, written when --result-json
+// is provided.
+type Result struct {
+	OK           bool      `json:"ok"`
+	ExitCode     int       `json:"exit_code"`
+	Error        string    `json:"error,omitempty"`
+	InvocationID string    `json:"invocation_id"`
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Commit       string    `json:"commit"`
+	Command      []string  `json:"command"`
+	StartedAt    time.Time `json:"started_at"`
+	Duration     string    `json:"duration"`
+	DurationMS   int64     `json:"duration_ms"`
+	Warnings     []Warning `json:"warnings,omitempty"`
+	Payload      any       `json:"payload,omitempty"`
+
+	// Processes are the results of processes invoked with Exec.
+	Processes []ProcessResult `json:"processes,omitempty"`
+
+	// Output is the tail of the output, if captured (see WithOutputCapture).
+	Output *CapturedOutput `json:"output,omitempty"`
+}
+
+// SetResult sets the app-supplied payload included in the result envelope
+// (see --result-json). The payload must be JSON-serializable.
+func (cli *CLI[T]) SetResult(payload any) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.result = payload
+}
+
+// recordProcess records the result of a process invoked with Exec, for the
+// result envelope.
+func (cli *CLI[T]) recordProcess(result ProcessResult) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	cli.processes = append(cli.processes, result)
+}
+
+// ExitCode returns the exit code for the provided error. nil errors return 0,
+// errors which implement "ExitCode() int" (e.g. *exec.ExitError) return their
+// own exit code, and all other errors return 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	return 1
+}
+
+// Finish runs all end-of-run tasks: flushing warnings (see FlushWarnings),
+// writing the result envelope (see --result-json), printing statistics (see
+// --stats), recording command history (see OptEnableHistory), writing the
+// recording of the invocation (see --record), and flushing captured output
+// (see WithOutputCapture). It returns the provided error, or any error which
+// occurred while finishing. This is invoked automatically after sub-commands
+// are executed, otherwise it should be called at the end of main with the
+// final error (if any).
+//
+// Example:
+//
+//	func main() {
+//		cli.Parse()
+//		if err := cli.Finish(run()); err != nil {
+//			os.Exit(clix.ExitCode(err))
+//		}
+//	}
+func (cli *CLI[T]) Finish(err error) error {
+	cli.emit(EventShutdown, 0, err)
+
+	warnings := cli.Warnings()
+
+	if ferr := cli.FlushWarnings(); err == nil {
+		err = ferr
+	}
+
+	if cli.ResultJSON != "" {
+		if rerr := cli.writeResult(err, warnings); err == nil {
+			err = rerr
+		}
+	}
+
+	if cli.Stats != "" {
+		if serr := cli.writeStats(os.Stderr, cli.Stats); err == nil {
+			err = serr
+		}
+	}
+
+	// Failing to record history shouldn't fail the run.
+	if cli.IsSet(OptEnableHistory) {
+		if herr := cli.recordHistory(err); herr != nil && cli.Logger != nil {
+			cli.Logger.WithError(herr).Warn("failed to record command history")
+		}
+	}
+
+	if cli.recorder != nil {
+		if rerr := cli.writeRecording(err); err == nil {
+			err = rerr
+		}
+	}
+
+	cli.flushOutputCapture()
+
+	return err
+}
+
+// writeResult writes the result envelope to the --result-json target.
+func (cli *CLI[T]) writeResult(err error, warnings []Warning) error {
+	cli.mu.Lock()
+	payload := cli.result
+	started := cli.started
+	processes := cli.processes
+	id := cli.invocationID
+	cli.mu.Unlock()
+
+	duration := time.Since(started)
+
+	result := &Result{
+		InvocationID: id,
+		OK:           err == nil,
+		ExitCode:     ExitCode(err),
+		Command:      append([]string{os.Args[0]}, cli.RedactArgs(os.Args[1:])...),
+		StartedAt:    started,
+		Duration:     duration.String(),
+		DurationMS:   duration.Milliseconds(),
+		Warnings:     warnings,
+		Payload:      payload,
+		Processes:    processes,
+		Output:       cli.CapturedOutput(),
+	}
+
+	if err != nil {
+		result.Error = cli.RedactText(err.Error())
+	}
+
+	if cli.VersionInfo != nil {
+		result.Name = cli.VersionInfo.Name
+		result.Version = cli.VersionInfo.Version
+		result.Commit = cli.VersionInfo.Commit
+	}
+
+	out, closer, oerr := openResultTarget(cli.ResultJSON)
+	if oerr != nil {
+		return fmt.Errorf("failed to open result target: %w", oerr)
+	}
+	defer closer()
+
+	if oerr = json.NewEncoder(out).Encode(result); oerr != nil {
+		return fmt.Errorf("failed to write result: %w", oerr)
+	}
+
+	return nil
+}
+
+// openResultTarget opens the --result-json target, which is either "-" (or
+// "stdout"), "stderr", "fd:N", or a file path.
+func openResultTarget(target string) (out io.Writer, closer func() error, err error) {
+	nop := func() error { return nil }
+
+	switch {
+	case target == "-" || target == "stdout":
+		return os.Stdout, nop, nil
+	case target == "stderr":
+		return os.Stderr, nop, nil
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, nil, fmt.Errorf("invalid file descriptor %q", target)
+		}
+
+		f := os.NewFile(uintptr(fd), target)
+		if f == nil {
+			return nil, nil, fmt.Errorf("invalid file descriptor %q", target)
+		}
+
+		return f, nop, nil
+	default:
+		f, err := os.Create(target)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return f, f.Close, nil
+	}
+}
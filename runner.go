@@ -6,6 +6,7 @@ package clix
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -36,7 +37,7 @@ func Run(runners ...Runner) error {
 // to externally cancel all runners.
 func RunCtx(ctx context.Context, runners ...Runner) error {
 	if len(runners) == 0 {
-		panic("no runners provided")
+		return errors.New("no runners provided")
 	}
 
 	var g *errgroup.Group
@@ -0,0 +1,148 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Overlap policies, used when a scheduled run is due while the previous run is
+// still in progress. See ScheduleConfig.
+const (
+	OverlapSkip       = "skip"       // Skip the run.
+	OverlapQueue      = "queue"      // Run once the previous run finishes (at most one run is queued).
+	OverlapConcurrent = "concurrent" // Run concurrently with the previous run.
+)
+
+// ScheduleConfig are the flags used to invoke a Runner periodically, so
+// applications can run on a cadence without being wrapped in cron. If no
+// schedule is provided, the runner is invoked once.
+//
+// Example (where you can set SCHEDULE as an environment variable, for example):
+//
+//	type Flags struct {
+//		Schedule clix.ScheduleConfig `group:"Schedule Options"`
+//	}
+//	[...]
+//	err := clix.RunCtx(cli.Context(context.Background()), cli.Flags.Schedule.Runner(sync))
+type ScheduleConfig struct {
+	// Schedule is the cron expression or interval to run on. See ParseSchedule.
+	Schedule string `env:"SCHEDULE" long:"schedule" description:"run periodically, on a cron expression (e.g. \"*/5 * * * *\") or interval (e.g. \"5m\")"`
+
+	// Overlap is the policy used when a run is due while the previous run is
+	// still in progress.
+	Overlap string `env:"SCHEDULE_OVERLAP" long:"schedule-overlap" default:"skip" choice:"skip" choice:"queue" choice:"concurrent" description:"what to do when a run is due while the previous run is in progress"`
+
+	// Jitter is the maximum random delay added to each run, to spread load
+	// across multiple instances.
+	Jitter time.Duration `env:"SCHEDULE_JITTER" long:"schedule-jitter" default:"0s" description:"maximum random delay added to each run"`
+}
+
+// Runner returns a Runner which invokes fn on the configured schedule, until
+// the context is cancelled (e.g. when a termination signal is received through
+// Run). Each run is logged through the logger of the context (see LoggerFrom),
+// and failed runs don't stop the schedule. If no schedule is configured, fn is
+// invoked once, and its error returned.
+func (c *ScheduleConfig) Runner(fn Runner) Runner {
+	return func(ctx context.Context) error {
+		if c.Schedule == "" {
+			return fn(ctx)
+		}
+
+		sched, err := ParseSchedule(c.Schedule)
+		if err != nil {
+			return err
+		}
+
+		logger := LoggerFrom(ctx).WithField("schedule", c.Schedule)
+
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		var runs int
+		invoke := func(run int) {
+			entry := logger.WithField("run", run)
+			entry.Info("scheduled run started")
+
+			started := time.Now()
+			err := fn(ctx)
+
+			entry = entry.WithField("duration", time.Since(started).Round(time.Millisecond))
+			if err != nil {
+				entry.WithError(err).Error("scheduled run failed")
+				return
+			}
+
+			entry.Info("scheduled run finished")
+		}
+
+		// Runs are handed to a single worker for the skip and queue policies,
+		// where an unbuffered channel only accepts a run if the worker is idle,
+		// and a buffered channel accepts a single queued run.
+		var due chan int
+
+		switch c.Overlap {
+		case OverlapQueue:
+			due = make(chan int, 1)
+		case OverlapConcurrent:
+		default:
+			due = make(chan int)
+		}
+
+		if due != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case run := <-due:
+						invoke(run)
+					}
+				}
+			}()
+		}
+
+		for {
+			next := sched.Next(time.Now())
+			if c.Jitter > 0 {
+				next = next.Add(rand.N(c.Jitter))
+			}
+
+			logger.WithField("next", next.Format(time.RFC3339)).Debug("waiting for next scheduled run")
+
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+
+			runs++
+
+			if due == nil {
+				wg.Add(1)
+				go func(run int) {
+					defer wg.Done()
+					invoke(run)
+				}(runs)
+				continue
+			}
+
+			select {
+			case due <- runs:
+			default:
+				logger.WithField("run", runs).WithField("policy", c.Overlap).Warn("previous run still in progress, skipping")
+			}
+		}
+	}
+}
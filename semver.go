@@ -0,0 +1,114 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two semantic versions (e.g. "v1.2.3" or
+// "v0.0.0-20240101000000-abcdef123456"), returning -1, 0, or 1, and false if
+// either version isn't a semantic version (e.g. "(devel)"). Build metadata is
+// ignored.
+func compareVersions(a, b string) (int, bool) {
+	va, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+
+	vb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := range 3 {
+		if va.core[i] != vb.core[i] {
+			if va.core[i] < vb.core[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+
+	return comparePrerelease(va.pre, vb.pre), true
+}
+
+type semver struct {
+	core [3]int
+	pre  string
+}
+
+// parseVersion parses the provided semantic version, with an optional "v"
+// prefix.
+func parseVersion(v string) (semver, bool) {
+	var out semver
+
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, out.pre, _ = strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out.core[i] = n
+	}
+
+	return out, true
+}
+
+// comparePrerelease compares pre-release versions, as per the semver spec
+// (versions without a pre-release are greater than those with one).
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1 // Numeric identifiers have lower precedence.
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(pa) < len(pb):
+		return -1
+	case len(pa) > len(pb):
+		return 1
+	}
+
+	return 0
+}
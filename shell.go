@@ -0,0 +1,191 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shell integration kinds, supported by DocModel.Shell and --generate-shell.
+const (
+	ShellAliases     = "aliases"      // POSIX (bash/zsh) aliases for each command.
+	ShellFishAliases = "fish-aliases" // fish aliases for each command.
+	ShellEnv         = "env"          // POSIX (bash/zsh) exports of option defaults.
+	ShellFishEnv     = "fish-env"     // fish exports of option defaults.
+	ShellDirenv      = "direnv"       // direnv (.envrc) template of all options.
+
+	ShellBashCompletion = "bash-completion" // bash completion script.
+	ShellZshCompletion  = "zsh-completion"  // zsh completion script (using bashcompinit).
+	ShellFishCompletion = "fish-completion" // fish completion script.
+)
+
+// ShellQuote quotes the provided string for use in POSIX shells (and fish),
+// using single quotes.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes the provided string for use in fish, using single quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// ShellIntegration writes the provided kind of shell integration (see the
+// Shell* constants) for the CLI to out.
+func (cli *CLI[T]) ShellIntegration(out io.Writer, kind string) error {
+	return cli.DocModel().Shell(out, kind)
+}
+
+// Shell writes the provided kind of shell integration (see the Shell*
+// constants) for the documentation model to out. Examples:
+//
+//	# aliases for each command (e.g. "app-serve" for "app serve").
+//	source <(app --generate-shell=aliases)
+//	# export option defaults as environment variables.
+//	source <(app --generate-shell=env)
+//	# generate a direnv template for per-project configuration.
+//	app --generate-shell=direnv > .envrc
+//	# completion of commands, flags and flag values (see RegisterCompletion).
+//	source <(app --generate-shell=bash-completion)
+func (m *DocModel) Shell(out io.Writer, kind string) error {
+	switch kind {
+	case ShellBashCompletion, ShellZshCompletion:
+		fmt.Fprintf(out, "# %s completion for %s, generated with --generate-shell=%s.\n", strings.TrimSuffix(kind, "-completion"), m.Name, kind)
+		if kind == ShellZshCompletion {
+			fmt.Fprint(out, "autoload -U +X bashcompinit && bashcompinit\n")
+		}
+		fmt.Fprint(out, strings.NewReplacer("{name}", m.Name, "{fn}", shellFuncName(m.Name), "{cmd}", completeCommand).Replace(bashCompletion))
+	case ShellFishCompletion:
+		fmt.Fprintf(out, "# fish completion for %s, generated with --generate-shell=%s.\n", m.Name, kind)
+		fmt.Fprint(out, strings.NewReplacer("{name}", m.Name, "{fn}", shellFuncName(m.Name), "{cmd}", completeCommand).Replace(fishCompletion))
+	case ShellAliases, ShellFishAliases:
+		m.shellAliases(out, m.Commands, kind == ShellFishAliases)
+	case ShellEnv, ShellFishEnv:
+		for _, option := range m.EnvOptions() {
+			if len(option.Default) == 0 {
+				continue
+			}
+
+			if kind == ShellFishEnv {
+				fmt.Fprintf(out, "set -gx %s %s\n", option.Env, fishQuote(option.EnvDefault()))
+			} else {
+				fmt.Fprintf(out, "export %s=%s\n", option.Env, ShellQuote(option.EnvDefault()))
+			}
+		}
+	case ShellDirenv:
+		fmt.Fprintf(out, "# direnv configuration for %s, generated with --generate-shell=direnv.\n", m.Name)
+		fmt.Fprint(out, "# uncomment and update the options as needed.\n")
+
+		for _, option := range m.EnvOptions() {
+			fmt.Fprintf(out, "\n# %s (%s)", option.Description, option.Flag)
+			if len(option.Choices) > 0 {
+				fmt.Fprintf(out, " [choices: %s]", strings.Join(option.Choices, ", "))
+			}
+			fmt.Fprintf(out, "\n# export %s=%s\n", option.Env, ShellQuote(option.EnvDefault()))
+		}
+	default:
+		return fmt.Errorf("unknown shell integration %q", kind)
+	}
+
+	return nil
+}
+
+func (m *DocModel) shellAliases(out io.Writer, commands []*DocCommand, fish bool) {
+	for _, cmd := range commands {
+		alias := m.Name + "-" + strings.ReplaceAll(cmd.Path, " ", "-")
+		command := m.Name + " " + cmd.Path
+
+		if fish {
+			fmt.Fprintf(out, "alias %s %s\n", alias, fishQuote(command))
+		} else {
+			fmt.Fprintf(out, "alias %s=%s\n", alias, ShellQuote(command))
+		}
+
+		m.shellAliases(out, cmd.Commands, fish)
+	}
+}
+
+// EnvOptions returns all options (including those of groups and commands)
+// which can be configured with an environment variable, de-duplicated by
+// environment variable name, in the order they were defined.
+func (m *DocModel) EnvOptions() []*DocOption {
+	var options []*DocOption
+	seen := make(map[string]bool)
+
+	add := func(opts []*DocOption) {
+		for _, option := range opts {
+			if option.Env == "" || seen[option.Env] {
+				continue
+			}
+
+			seen[option.Env] = true
+			options = append(options, option)
+		}
+	}
+
+	var walkGroups func(groups []*DocGroup)
+	walkGroups = func(groups []*DocGroup) {
+		for _, group := range groups {
+			add(group.Options)
+			walkGroups(group.Groups)
+		}
+	}
+
+	var walkCommands func(commands []*DocCommand)
+	walkCommands = func(commands []*DocCommand) {
+		for _, cmd := range commands {
+			add(cmd.Options)
+			walkGroups(cmd.Groups)
+			walkCommands(cmd.Commands)
+		}
+	}
+
+	walkGroups(m.Groups)
+	walkCommands(m.Commands)
+
+	return options
+}
+
+// bashCompletion is the bash (and zsh, through bashcompinit) completion
+// script. The command line is split manually, as COMP_WORDS splits words on
+// "=", and descriptions are stripped from completions.
+const bashCompletion = `_{fn}_complete() {
+	local line="${COMP_LINE:0:COMP_POINT}"
+	local -a words
+	read -ra words <<< "$line"
+	[[ "$line" == *" " ]] && words+=("")
+
+	local -a lines
+	mapfile -t lines < <({name} {cmd} "${words[@]:1}" 2>/dev/null)
+	COMPREPLY=("${lines[@]%%$'\t'*}")
+}
+complete -o default -F _{fn}_complete {name}
+`
+
+// fishCompletion is the fish completion script. fish replaces the whole token,
+// so the "--flag=" prefix is added back to flag value completions.
+const fishCompletion = `function __{fn}_complete
+	set -l tokens (commandline -opc)
+	set -l current (commandline -ct)
+	set -l prefix (string match -r -- '^--[^=]*=' $current)
+	for c in ({name} {cmd} $tokens[2..-1] $current 2>/dev/null)
+		echo $prefix$c
+	end
+end
+complete -c {name} -f -a '(__{fn}_complete)'
+`
+
+// shellFuncName returns a shell function name for the provided program name.
+func shellFuncName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
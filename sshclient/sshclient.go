@@ -0,0 +1,393 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package sshclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/lrstanley/clix"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key verification policies. See Config.HostKeyPolicy.
+const (
+	// PolicyStrict only accepts hosts with a matching key in the known_hosts
+	// files.
+	PolicyStrict = "strict"
+
+	// PolicyAcceptNew accepts (and records) the keys of unknown hosts, but
+	// rejects hosts whose key has changed (ssh's StrictHostKeyChecking=accept-new).
+	PolicyAcceptNew = "accept-new"
+
+	// PolicyInsecure accepts any host key. This should only be used for
+	// testing.
+	PolicyInsecure = "insecure"
+)
+
+// Config are the flags used to connect to an SSH server. Embed it in your flags
+// struct, and then call Config.Dial() to get a ready-to-use client.
+//
+// Example (where you can set SSH_HOST as an environment variable, for example):
+//
+//	type Flags struct {
+//		SSH sshclient.Config `group:"SSH Options" namespace:"ssh" env-namespace:"SSH"`
+//	}
+//	[...]
+//	client, err := cli.Flags.SSH.Dial(ctx, cli.Logger)
+type Config struct {
+	// Host is the server to connect to, in the format [user@]host[:port].
+	Host string `env:"HOST" long:"host" required:"true" description:"server to connect to ([user@]host[:port])"`
+
+	// User is the user to authenticate as, if not provided through Host.
+	// Defaults to the current user.
+	User string `env:"USER" long:"user" description:"user to authenticate as (defaults to the current user)"`
+
+	// IdentityFiles are private keys used for authentication. Defaults to the
+	// standard keys in ~/.ssh, if none are provided.
+	IdentityFiles []string `env:"IDENTITY" env-delim:"," long:"identity" value-name:"PATH" description:"private key used for authentication (repeatable, defaults to ~/.ssh/id_*)"`
+
+	// KeyPassphrase is the passphrase of encrypted private keys.
+	KeyPassphrase string `env:"KEY_PASSPHRASE" long:"key-passphrase" description:"passphrase of encrypted private keys"`
+
+	// NoAgent disables authentication through the ssh-agent (SSH_AUTH_SOCK).
+	NoAgent bool `env:"NO_AGENT" long:"no-agent" description:"disable authentication through ssh-agent"`
+
+	// KnownHosts are the known_hosts files used to verify host keys.
+	KnownHosts []string `env:"KNOWN_HOSTS" env-delim:"," long:"known-hosts" value-name:"PATH" description:"known_hosts files used to verify host keys (repeatable, defaults to ~/.ssh/known_hosts)"`
+
+	// HostKeyPolicy is the host key verification policy.
+	HostKeyPolicy string `env:"HOST_KEY_POLICY" long:"host-key-policy" default:"strict" choice:"strict" choice:"accept-new" choice:"insecure" description:"host key verification policy"`
+
+	// JumpHost is an optional host to connect through (like ssh's ProxyJump),
+	// in the format [user@]host[:port]. The same authentication is used.
+	JumpHost string `env:"JUMP_HOST" long:"jump-host" description:"host to connect through ([user@]host[:port])"`
+
+	// Timeout is the maximum time to wait for each connection to be
+	// established.
+	Timeout time.Duration `env:"TIMEOUT" long:"timeout" default:"10s" description:"time to wait for each connection to be established"`
+}
+
+// Dial connects to the configured host (through the jump host, if provided),
+// and returns the client. Connection metadata is logged to the provided
+// logger, if not nil.
+func (c *Config) Dial(ctx context.Context, logger log.Interface) (*ssh.Client, error) {
+	if logger == nil {
+		logger = &log.Logger{Handler: log.HandlerFunc(func(*log.Entry) error { return nil })}
+	}
+
+	auth, err := c.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeys, err := c.hostKeyCallback(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	username, addr, err := c.target(c.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	logger = logger.WithFields(log.Fields{
+		"host":   addr,
+		"user":   username,
+		"policy": c.HostKeyPolicy,
+	})
+
+	config := &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         c.Timeout,
+	}
+
+	var conn net.Conn
+	var jump *ssh.Client
+
+	if c.JumpHost != "" {
+		jumpUser, jumpAddr, err := c.target(c.JumpHost)
+		if err != nil {
+			return nil, err
+		}
+
+		logger = logger.WithField("jump_host", jumpAddr)
+		logger.Debug("connecting to jump host")
+
+		jumpConfig := *config
+		jumpConfig.User = jumpUser
+
+		jump, err = dial(ctx, jumpAddr, &jumpConfig, c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to jump host %q: %w", jumpAddr, err)
+		}
+
+		dialAddr, _, err := clix.DefaultNetwork().ResolveAddr(addr)
+		if err != nil {
+			_ = jump.Close()
+			return nil, err
+		}
+
+		conn, err = jump.DialContext(ctx, "tcp", dialAddr)
+		if err != nil {
+			_ = jump.Close()
+			return nil, fmt.Errorf("failed to connect to %q through jump host: %w", addr, err)
+		}
+	} else {
+		conn, err = dialTCP(ctx, addr, c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %q: %w", addr, err)
+		}
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		if jump != nil {
+			_ = jump.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake with %q failed: %w", addr, err)
+	}
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	if jump != nil {
+		// Close the jump host connection once the client is closed.
+		go func() {
+			_ = client.Wait()
+			_ = jump.Close()
+		}()
+	}
+
+	logger.WithField("server_version", string(sshConn.ServerVersion())).Debug("ssh client connected")
+	return client, nil
+}
+
+// dialTCP connects to the provided address, applying DNS overrides (see
+// clix.NetworkConfig).
+func dialTCP(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error) {
+	addr, _, err := clix.DefaultNetwork().ResolveAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &net.Dialer{Timeout: timeout}
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+// dial connects to the provided address, and returns the client.
+func dial(ctx context.Context, addr string, config *ssh.ClientConfig, timeout time.Duration) (*ssh.Client, error) {
+	conn, err := dialTCP(ctx, addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// target returns the user and address (host:port) of the provided
+// [user@]host[:port] destination.
+func (c *Config) target(dest string) (username, addr string, err error) {
+	username, host, ok := strings.Cut(dest, "@")
+	if !ok {
+		host = username
+		username = c.User
+	}
+
+	if host == "" {
+		return "", "", fmt.Errorf("invalid ssh destination %q", dest)
+	}
+
+	if username == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to determine current user: %w", err)
+		}
+		username = u.Username
+	}
+
+	if _, port, err := net.SplitHostPort(host); err == nil {
+		if _, err = strconv.Atoi(port); err != nil {
+			return "", "", fmt.Errorf("invalid port in ssh destination %q", dest)
+		}
+		return username, host, nil
+	}
+
+	return username, net.JoinHostPort(strings.Trim(host, "[]"), "22"), nil
+}
+
+// authMethods returns the authentication methods, using the ssh-agent (if
+// available) and the configured (or default) identity files.
+func (c *Config) authMethods() ([]ssh.AuthMethod, error) {
+	var signers []ssh.Signer
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" && !c.NoAgent {
+		// The agent connection is kept open, as it's used for signing during
+		// authentication.
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if s, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, s...)
+			}
+		}
+	}
+
+	files := c.IdentityFiles
+	explicit := len(files) > 0
+
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+				files = append(files, filepath.Join(home, ".ssh", name))
+			}
+		}
+	}
+
+	for _, fn := range files {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			if !explicit && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read identity file: %w", err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(b)
+
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			if c.KeyPassphrase == "" {
+				if !explicit {
+					continue
+				}
+				return nil, fmt.Errorf("identity file %q is encrypted, and no passphrase was provided", fn)
+			}
+
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(c.KeyPassphrase))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse identity file %q: %w", fn, err)
+		}
+
+		signers = append(signers, signer)
+	}
+
+	if len(signers) == 0 {
+		return nil, errors.New("no ssh keys available (provide an identity file, or use ssh-agent)")
+	}
+
+	return []ssh.AuthMethod{ssh.PublicKeys(signers...)}, nil
+}
+
+// hostKeyCallback returns the host key callback for the configured policy.
+func (c *Config) hostKeyCallback(logger log.Interface) (ssh.HostKeyCallback, error) {
+	if c.HostKeyPolicy == PolicyInsecure {
+		logger.Warn("ssh host key verification is disabled")
+		return ssh.InsecureIgnoreHostKey(), nil //nolint:gosec
+	}
+
+	files := c.KnownHosts
+	if len(files) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		files = []string{filepath.Join(home, ".ssh", "known_hosts")}
+	}
+
+	var existing []string
+	for _, fn := range files {
+		if _, err := os.Stat(fn); err == nil {
+			existing = append(existing, fn)
+		}
+	}
+
+	if len(existing) == 0 && c.HostKeyPolicy != PolicyAcceptNew {
+		return nil, fmt.Errorf("no known_hosts files found (tried: %s)", strings.Join(files, ", "))
+	}
+
+	verify := func(string, net.Addr, ssh.PublicKey) error {
+		return &knownhosts.KeyError{}
+	}
+
+	if len(existing) > 0 {
+		var err error
+		if verify, err = knownhosts.New(existing...); err != nil {
+			return nil, fmt.Errorf("failed to read known_hosts: %w", err)
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := verify(hostname, remote, key)
+
+		var keyErr *knownhosts.KeyError
+		if err == nil || !errors.As(err, &keyErr) {
+			return err
+		}
+
+		if len(keyErr.Want) > 0 {
+			logger.WithField("fingerprint", ssh.FingerprintSHA256(key)).Error("ssh host key mismatch")
+			return fmt.Errorf("host key for %q has changed (possible man-in-the-middle attack): %w", hostname, err)
+		}
+
+		if c.HostKeyPolicy != PolicyAcceptNew {
+			return fmt.Errorf("host %q is unknown (fingerprint %s), add it to known_hosts or use --host-key-policy=accept-new: %w", hostname, ssh.FingerprintSHA256(key), err)
+		}
+
+		if err = addKnownHost(files[0], hostname, remote, key); err != nil {
+			return err
+		}
+
+		logger.WithFields(log.Fields{
+			"fingerprint": ssh.FingerprintSHA256(key),
+			"known_hosts": files[0],
+		}).Warn("added new ssh host key")
+
+		return nil
+	}, nil
+}
+
+// addKnownHost appends the provided host key to the known_hosts file.
+func addKnownHost(fn, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(fn), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open known_hosts: %w", err)
+	}
+
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil && knownhosts.Normalize(remote.String()) != addresses[0] {
+		addresses = append(addresses, knownhosts.Normalize(remote.String()))
+	}
+
+	if _, err = fmt.Fprintln(f, knownhosts.Line(addresses, key)); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
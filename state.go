@@ -0,0 +1,235 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// State is a small, transactional key/value store, persisted as JSON within
+// the state directory (see StateDir), for tokens, caches, counters, etc.
+// Transactions are serialized using a lock file, so it is safe for use across
+// goroutines and concurrent invocations of the application. It is not meant
+// for large amounts of data, as the whole store is read and written by each
+// transaction.
+type State struct {
+	path string
+	mu   sync.RWMutex
+}
+
+// State returns the application's state store, stored as "state.json" within
+// the state directory (see StateDir). The same store is returned on subsequent
+// calls.
+func (cli *CLI[T]) State() (*State, error) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	if cli.state != nil {
+		return cli.state, nil
+	}
+
+	dir, err := cli.StateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cli.state = OpenState(filepath.Join(dir, "state.json"))
+	return cli.state, nil
+}
+
+// OpenState returns a state store persisted to the provided path. The file
+// (and a "<path>.lock" lock file) is created on the first update.
+func OpenState(path string) *State {
+	return &State{path: path}
+}
+
+// Path returns the path of the file the store is persisted to.
+func (s *State) Path() string {
+	return s.path
+}
+
+// StateTx is a state store transaction. See State.View and State.Update.
+type StateTx struct {
+	data     map[string]json.RawMessage
+	readOnly bool
+	changed  bool
+}
+
+// Get decodes the value of the provided key into v, returning false if the key
+// doesn't exist.
+func (tx *StateTx) Get(key string, v any) (bool, error) {
+	raw, ok := tx.data[key]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("failed to decode state key %q: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Set encodes v as the value of the provided key.
+func (tx *StateTx) Set(key string, v any) error {
+	if tx.readOnly {
+		return errors.New("state transaction is read-only")
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state key %q: %w", key, err)
+	}
+
+	tx.data[key] = raw
+	tx.changed = true
+
+	return nil
+}
+
+// Delete removes the provided key, if it exists.
+func (tx *StateTx) Delete(key string) error {
+	if tx.readOnly {
+		return errors.New("state transaction is read-only")
+	}
+
+	if _, ok := tx.data[key]; ok {
+		delete(tx.data, key)
+		tx.changed = true
+	}
+
+	return nil
+}
+
+// Keys returns all keys in the store, sorted.
+func (tx *StateTx) Keys() []string {
+	keys := make([]string, 0, len(tx.data))
+	for key := range tx.data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// View invokes fn within a read-only transaction.
+func (s *State) View(fn func(tx *StateTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.transaction(false, fn)
+}
+
+// Update invokes fn within a read-write transaction. Changes are only
+// persisted if fn returns no error.
+func (s *State) Update(fn func(tx *StateTx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.transaction(true, fn)
+}
+
+// Get decodes the value of the provided key into v, returning false if the key
+// doesn't exist. Shorthand for a View transaction.
+func (s *State) Get(key string, v any) (ok bool, err error) {
+	err = s.View(func(tx *StateTx) error {
+		ok, err = tx.Get(key, v)
+		return err
+	})
+
+	return ok, err
+}
+
+// Set encodes v as the value of the provided key. Shorthand for an Update
+// transaction.
+func (s *State) Set(key string, v any) error {
+	return s.Update(func(tx *StateTx) error {
+		return tx.Set(key, v)
+	})
+}
+
+// Delete removes the provided key, if it exists. Shorthand for an Update
+// transaction.
+func (s *State) Delete(key string) error {
+	return s.Update(func(tx *StateTx) error {
+		return tx.Delete(key)
+	})
+}
+
+// transaction acquires the lock file, reads the store, invokes fn, and writes
+// the store if it was changed.
+func (s *State) transaction(write bool, fn func(tx *StateTx) error) error {
+	if write {
+		if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+			return err
+		}
+	}
+
+	lock, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		if !write && errors.Is(err, os.ErrNotExist) {
+			// Nothing has been stored yet.
+			return fn(&StateTx{data: map[string]json.RawMessage{}, readOnly: true})
+		}
+		return fmt.Errorf("failed to open state lock: %w", err)
+	}
+	defer lock.Close()
+
+	if err = lockFile(lock, write); err != nil {
+		return fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	tx := &StateTx{data: map[string]json.RawMessage{}, readOnly: !write}
+
+	b, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read state: %w", err)
+	default:
+		if err = json.Unmarshal(b, &tx.data); err != nil {
+			return fmt.Errorf("failed to decode state %q: %w", s.path, err)
+		}
+	}
+
+	if err = fn(tx); err != nil || !tx.changed {
+		return err
+	}
+
+	b, err = json.MarshalIndent(tx.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename, so the store is never partially
+	// written.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state: %w", err)
+	}
+
+	return nil
+}
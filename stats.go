@@ -0,0 +1,120 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Stats is the execution timing and resource usage summary, printed when
+// --stats is provided. Fields which aren't supported on the current platform
+// are left empty.
+type Stats struct {
+	WallTime     time.Duration `json:"wall_time_ns"`
+	UserTime     time.Duration `json:"user_time_ns,omitempty"`
+	SystemTime   time.Duration `json:"system_time_ns,omitempty"`
+	MaxRSS       int64         `json:"max_rss_bytes,omitempty"`
+	BlockInput   int64         `json:"block_input_ops,omitempty"`
+	BlockOutput  int64         `json:"block_output_ops,omitempty"`
+	NumGC        uint32        `json:"num_gc"`
+	GCPauseTotal time.Duration `json:"gc_pause_total_ns"`
+	TotalAlloc   uint64        `json:"total_alloc_bytes"`
+	HeapInUse    uint64        `json:"heap_in_use_bytes"`
+	Goroutines   int           `json:"goroutines"`
+}
+
+// CollectStats returns the current execution timing and resource usage
+// statistics, relative to when the CLI was parsed.
+func (cli *CLI[T]) CollectStats() *Stats {
+	cli.mu.Lock()
+	started := cli.started
+	cli.mu.Unlock()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s := &Stats{
+		WallTime:     time.Since(started),
+		NumGC:        mem.NumGC,
+		GCPauseTotal: time.Duration(mem.PauseTotalNs),
+		TotalAlloc:   mem.TotalAlloc,
+		HeapInUse:    mem.HeapInuse,
+		Goroutines:   runtime.NumGoroutine(),
+	}
+
+	collectUsage(s)
+
+	return s
+}
+
+// String returns the human-readable summary of the statistics, colored if
+// color is enabled for stderr (see ColorEnabled).
+func (s *Stats) String() string {
+	var out string
+
+	row := func(name, value string) {
+		out += fmt.Sprintf("<cyan>%18s</> :: <green>%s</>\n", name, value)
+	}
+
+	row("wall time", s.WallTime.Round(time.Microsecond).String())
+
+	if s.UserTime > 0 || s.SystemTime > 0 {
+		row("cpu time", fmt.Sprintf(
+			"%s (user: %s, system: %s)",
+			(s.UserTime+s.SystemTime).Round(time.Microsecond),
+			s.UserTime.Round(time.Microsecond),
+			s.SystemTime.Round(time.Microsecond),
+		))
+	}
+
+	if s.MaxRSS > 0 {
+		row("max rss", formatBytes(uint64(s.MaxRSS)))
+	}
+
+	if s.BlockInput > 0 || s.BlockOutput > 0 {
+		row("block io", fmt.Sprintf("%d in, %d out", s.BlockInput, s.BlockOutput))
+	}
+
+	row("gc", fmt.Sprintf("%d cycles, %s total pause", s.NumGC, s.GCPauseTotal))
+	row("allocated", fmt.Sprintf("%s total, %s heap in use", formatBytes(s.TotalAlloc), formatBytes(s.HeapInUse)))
+	row("goroutines", fmt.Sprintf("%d", s.Goroutines))
+
+	return colorize(os.Stderr, out)
+}
+
+// writeStats writes the statistics to out, in the provided format (text or
+// json).
+func (cli *CLI[T]) writeStats(out io.Writer, format string) error {
+	s := cli.CollectStats()
+
+	if format == "json" {
+		return json.NewEncoder(out).Encode(s)
+	}
+
+	_, err := fmt.Fprint(out, "\n"+s.String())
+	return err
+}
+
+// formatBytes formats the provided number of bytes in a human-readable format.
+func formatBytes(b uint64) string {
+	const unit = 1024
+
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,10 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build !unix
+
+package clix
+
+// collectUsage is a no-op on platforms without getrusage(2).
+func collectUsage(_ *Stats) {}
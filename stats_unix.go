@@ -0,0 +1,32 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+//go:build unix
+
+package clix
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// collectUsage populates the resource usage statistics, using getrusage(2).
+func collectUsage(s *Stats) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return
+	}
+
+	s.UserTime = time.Duration(ru.Utime.Nano())
+	s.SystemTime = time.Duration(ru.Stime.Nano())
+	s.BlockInput = int64(ru.Inblock)
+	s.BlockOutput = int64(ru.Oublock)
+
+	// Maxrss is in bytes on darwin, and kilobytes on other platforms.
+	s.MaxRSS = int64(ru.Maxrss)
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		s.MaxRSS *= 1024
+	}
+}
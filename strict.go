@@ -0,0 +1,231 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/joho/godotenv"
+)
+
+// ErrUnknownConfig is returned (wrapped) in strict mode, when a .env file
+// contains a key, or an environment variable with a strict prefix is set,
+// which doesn't map to any flag. See WithStrict.
+var ErrUnknownConfig = errors.New("clix: unknown configuration key")
+
+// StrictMode controls how unknown configuration keys are handled. See
+// WithStrict.
+type StrictMode int
+
+const (
+	// StrictOff ignores unknown configuration keys (the default).
+	StrictOff StrictMode = iota
+
+	// StrictWarn reports unknown configuration keys as warnings (see Warn).
+	StrictWarn
+
+	// StrictError fails parsing when unknown configuration keys are found.
+	StrictError
+)
+
+// WithStrict enables strict mode, which reports keys in .env files that don't
+// map to any flag's environment variable, and environment variables starting
+// with any of the provided prefixes (e.g. "MYAPP_") which don't map to any
+// flag, to catch typos which would otherwise be silently ignored. Depending on
+// mode, these are either reported as warnings (StrictWarn), or fail parsing
+// (StrictError) with an error matching ErrUnknownConfig.
+//
+// Example:
+//
+//	err := cli.Apply(clix.WithStrict(clix.StrictError, "MYAPP_"))
+func WithStrict(mode StrictMode, prefixes ...string) Option {
+	return func(s *settings) error {
+		if mode < StrictOff || mode > StrictError {
+			return fmt.Errorf("WithStrict: invalid mode %d", mode)
+		}
+
+		for _, prefix := range prefixes {
+			if prefix == "" {
+				return errors.New("WithStrict: empty prefix")
+			}
+		}
+
+		s.strictMode = mode
+		s.strictPrefixes = prefixes
+		return nil
+	}
+}
+
+// checkStrict reports unknown configuration keys (see WithStrict).
+func (cli *CLI[T]) checkStrict() error {
+	if cli.settings.strictMode == StrictOff || cli.Parser == nil {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		if option.EnvDefaultKey != "" {
+			known[option.EnvKeyWithNamespace()] = true
+		}
+	})
+
+	// Variables read by clix itself (e.g. NO_COLOR) are also known.
+	envUsed.Range(func(key, _ any) bool {
+		known[key.(string)] = true
+		return true
+	})
+
+	var errs []error
+
+	for _, file := range dotEnvFilesRead() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		env, err := godotenv.UnmarshalBytes(data)
+		if err != nil {
+			continue
+		}
+
+		for _, key := range sortedKeys(env) {
+			if known[key] {
+				continue
+			}
+
+			errs = append(errs, &ConfigError{
+				File: file,
+				Line: dotEnvKeyLine(data, key),
+				Err:  unknownKeyError("key", key, known),
+			})
+		}
+	}
+
+	var environ []string
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+
+		for _, prefix := range cli.settings.strictPrefixes {
+			if strings.HasPrefix(key, prefix) && !known[key] {
+				// Skip variables which were loaded from (and already
+				// reported for) a .env file.
+				if _, ok := dotEnvSources.Load(key); !ok {
+					environ = append(environ, key)
+				}
+				break
+			}
+		}
+	}
+	sort.Strings(environ)
+
+	for _, key := range environ {
+		errs = append(errs, unknownKeyError("environment variable", key, known))
+	}
+
+	if cli.settings.strictMode == StrictError {
+		return errors.Join(errs...)
+	}
+
+	for _, err := range errs {
+		cli.Warn(err.Error(), log.Fields{"strict": true})
+	}
+
+	return nil
+}
+
+// unknownKeyError returns an error for an unknown configuration key, which
+// suggests the closest known key, if any are similar.
+func unknownKeyError(kind, key string, known map[string]bool) error {
+	var suggestion string
+	best := 3 // Maximum distance for suggestions.
+
+	for k := range known {
+		if d := levenshtein(key, k); d < best || (d == best && suggestion != "" && k < suggestion) {
+			best, suggestion = d, k
+		}
+	}
+
+	if suggestion != "" {
+		return fmt.Errorf("%w: %s %s (did you mean %s?)", ErrUnknownConfig, kind, key, suggestion)
+	}
+
+	return fmt.Errorf("%w: %s %s", ErrUnknownConfig, kind, key)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// dotEnvFilesRead returns the sorted .env files which were read.
+func dotEnvFilesRead() []string {
+	var files []string
+	dotEnvFiles.Range(func(key, _ any) bool {
+		files = append(files, key.(string))
+		return true
+	})
+	sort.Strings(files)
+	return files
+}
+
+// dotEnvKeyLine returns the line number (1-indexed) where the provided key is
+// defined in the provided dotenv data, or 0 if unknown.
+func dotEnvKeyLine(data []byte, key string) int {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+
+		if name, _, ok := strings.Cut(text, "="); ok && strings.TrimSpace(name) == key {
+			return line
+		}
+
+		if name, _, ok := strings.Cut(text, ":"); ok && strings.TrimSpace(name) == key {
+			return line
+		}
+	}
+
+	return 0
+}
+
+// sortedKeys returns the sorted keys of the provided map.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,243 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig are the flags used to serve TLS. Certificates are automatically
+// reloaded when the certificate or key files change on disk (checked at most
+// once per ReloadInterval, during TLS handshakes), so certificate rotation
+// doesn't require a restart.
+//
+// Example (where you can set TLS_CERT as an environment variable, for example):
+//
+//	type Flags struct {
+//		TLS clix.TLSConfig `group:"TLS Options" namespace:"tls" env-namespace:"TLS"`
+//	}
+//	[...]
+//	tlsConfig, err := cli.Flags.TLS.Config()
+type TLSConfig struct {
+	// Cert and Key are the paths to the certificate and key files.
+	Cert string `env:"CERT" long:"cert" description:"path to certificate file (enables tls)"`
+	Key  string `env:"KEY" long:"key" description:"path to key file"`
+
+	// CA is an optional CA bundle used to verify client certificates.
+	CA string `env:"CA" long:"ca" description:"path to CA bundle used to verify client certificates"`
+
+	// ClientAuth is the client certificate policy, used with CA. If unset, it
+	// defaults to "verify" when CA is provided, and "none" otherwise.
+	ClientAuth string `env:"CLIENT_AUTH" long:"client-auth" choice:"none" choice:"request" choice:"require" choice:"verify" choice:"require-verify" description:"client certificate policy (defaults to verify if --ca is provided, otherwise none)"`
+
+	// MinVersion is the minimum TLS version to accept.
+	MinVersion string `env:"MIN_VERSION" long:"min-version" default:"1.2" choice:"1.0" choice:"1.1" choice:"1.2" choice:"1.3" description:"minimum tls version"`
+
+	// CipherPolicy is the preset of cipher suites to allow.
+	CipherPolicy string `env:"CIPHER_POLICY" long:"cipher-policy" default:"default" choice:"default" choice:"modern" choice:"intermediate" description:"cipher suite policy (modern requires tls 1.3, intermediate restricts tls 1.2 to forward-secret AEAD ciphers)"`
+
+	// ReloadInterval is the minimum interval between checks for updated
+	// certificate files.
+	ReloadInterval time.Duration `env:"RELOAD_INTERVAL" long:"reload-interval" default:"1m" description:"interval to check for updated certificate files (0 disables reloading)"`
+
+	once    sync.Once
+	loadErr error
+	reload  *certReloader
+}
+
+// tlsVersions maps the MinVersion flag values to their tls version.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// intermediateCipherSuites are the TLS 1.2 cipher suites allowed by the
+// "intermediate" cipher policy (forward-secret, AEAD only). TLS 1.3 cipher
+// suites are not configurable.
+var intermediateCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// clientAuthTypes maps the ClientAuth flag values to their tls.ClientAuthType.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":           tls.NoClientCert,
+	"request":        tls.RequestClientCert,
+	"require":        tls.RequireAnyClientCert,
+	"verify":         tls.VerifyClientCertIfGiven,
+	"require-verify": tls.RequireAndVerifyClientCert,
+}
+
+// Enabled returns true if TLS was configured.
+func (c *TLSConfig) Enabled() bool {
+	return c.Cert != "" || c.Key != ""
+}
+
+// Config returns the tls.Config generated from the provided flags, or nil if
+// TLS isn't enabled. The returned config reloads the certificate when it
+// changes on disk. Each call returns a new tls.Config, however they all share
+// the same certificate reloader.
+func (c *TLSConfig) Config() (*tls.Config, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	if c.Cert == "" || c.Key == "" {
+		return nil, errors.New("both tls cert and key must be provided")
+	}
+
+	c.once.Do(func() {
+		c.reload = &certReloader{
+			certFile: c.Cert,
+			keyFile:  c.Key,
+			interval: c.ReloadInterval,
+		}
+		c.loadErr = c.reload.load()
+	})
+
+	if c.loadErr != nil {
+		return nil, c.loadErr
+	}
+
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.reload.GetCertificate,
+	}
+
+	if c.MinVersion != "" {
+		v, ok := tlsVersions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid tls min version %q", c.MinVersion)
+		}
+		cfg.MinVersion = v
+	}
+
+	switch c.CipherPolicy {
+	case "", "default":
+	case "modern":
+		cfg.MinVersion = tls.VersionTLS13
+	case "intermediate":
+		cfg.CipherSuites = intermediateCipherSuites
+	default:
+		return nil, fmt.Errorf("invalid tls cipher policy %q", c.CipherPolicy)
+	}
+
+	if c.CA != "" {
+		b, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls ca bundle: %w", err)
+		}
+
+		cfg.ClientCAs = x509.NewCertPool()
+		if !cfg.ClientCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in tls ca bundle %q", c.CA)
+		}
+
+		if c.ClientAuth == "" {
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	if c.ClientAuth != "" {
+		auth, ok := clientAuthTypes[c.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("invalid tls client auth policy %q", c.ClientAuth)
+		}
+		cfg.ClientAuth = auth
+	}
+
+	return cfg, nil
+}
+
+// certReloader loads a certificate keypair, and reloads it when the files are
+// modified.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// modified returns the latest modification time of the certificate and key.
+func (r *certReloader) modified() (time.Time, error) {
+	var latest time.Time
+
+	for _, fn := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(fn)
+		if err != nil {
+			return latest, err
+		}
+
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// load (re)loads the keypair from disk.
+func (r *certReloader) load() error {
+	modTime, err := r.modified()
+	if err != nil {
+		return fmt.Errorf("failed to load tls keypair: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls keypair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. If the keypair fails
+// to reload (e.g. because it's only partially written), the previously loaded
+// keypair continues to be used.
+func (r *certReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, modTime, lastCheck := r.cert, r.modTime, r.lastCheck
+	r.mu.RUnlock()
+
+	if r.interval <= 0 || time.Since(lastCheck) < r.interval {
+		return cert, nil
+	}
+
+	r.mu.Lock()
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+
+	if latest, err := r.modified(); err == nil && latest.After(modTime) {
+		if err = r.load(); err == nil {
+			r.mu.RLock()
+			cert = r.cert
+			r.mu.RUnlock()
+		}
+	}
+
+	return cert, nil
+}
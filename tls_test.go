@@ -0,0 +1,101 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lrstanley/clix"
+)
+
+// writeTestCert writes a self-signed certificate and key to dir, returning
+// their paths.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSConfigClientAuth(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+
+	tests := []struct {
+		name       string
+		ca         bool
+		clientAuth string
+		want       tls.ClientAuthType
+	}{
+		{name: "default", want: tls.NoClientCert},
+		{name: "default-with-ca", ca: true, want: tls.VerifyClientCertIfGiven},
+		{name: "none-with-ca", ca: true, clientAuth: "none", want: tls.NoClientCert},
+		{name: "require-verify-with-ca", ca: true, clientAuth: "require-verify", want: tls.RequireAndVerifyClientCert},
+		{name: "request", clientAuth: "request", want: tls.RequestClientCert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &clix.TLSConfig{Cert: certFile, Key: keyFile, ClientAuth: tt.clientAuth}
+			if tt.ca {
+				c.CA = certFile
+			}
+
+			cfg, err := c.Config()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cfg.ClientAuth != tt.want {
+				t.Fatalf("unexpected client auth %v, want %v", cfg.ClientAuth, tt.want)
+			}
+		})
+	}
+}
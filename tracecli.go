@@ -0,0 +1,165 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/apex/log"
+	logcli "github.com/apex/log/handlers/cli"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Flag value sources, as reported by FlagResolution.Source.
+const (
+	FlagSourceFlag    = "flag"
+	FlagSourceEnv     = "env"
+	FlagSourceDotEnv  = "dotenv"
+	FlagSourceDefault = "default"
+	FlagSourceUnset   = "unset"
+)
+
+// FlagResolution describes how the value of a single flag was resolved.
+// Sources are consulted in order of precedence: flags, environment variables
+// (including those loaded from .env files), then defaults.
+type FlagResolution struct {
+	Flag    string   `json:"flag"`
+	Value   string   `json:"value"`
+	Source  string   `json:"source"`
+	Env     string   `json:"env,omitempty"`
+	EnvFile string   `json:"env_file,omitempty"`
+	Default []string `json:"default,omitempty"`
+	Secret  bool     `json:"secret,omitempty"`
+}
+
+// Chain returns the resolution chain of the flag, e.g.
+// "flag > env(LOG_LEVEL) > [default(info)]", where the source which provided
+// the value is wrapped in brackets.
+func (r *FlagResolution) Chain() string {
+	chain := []string{FlagSourceFlag}
+
+	if r.Env != "" {
+		env := "env(" + r.Env + ")"
+		if r.EnvFile != "" {
+			env = "dotenv(" + r.EnvFile + ":" + r.Env + ")"
+		}
+		chain = append(chain, env)
+	}
+
+	chain = append(chain, "default("+strings.Join(r.Default, ",")+")")
+
+	switch r.Source {
+	case FlagSourceFlag:
+		chain[0] = "[" + chain[0] + "]"
+	case FlagSourceEnv, FlagSourceDotEnv:
+		chain[1] = "[" + chain[1] + "]"
+	case FlagSourceDefault:
+		chain[len(chain)-1] = "[" + chain[len(chain)-1] + "]"
+	}
+
+	return strings.Join(chain, " > ")
+}
+
+// FlagResolutions returns how the value of each flag (including those of
+// groups and commands, but excluding hidden flags) was resolved, in the order
+// they were defined. Secret values (see RedactArgs) are masked unless reveal
+// is true. Must be called after Parse().
+func (cli *CLI[T]) FlagResolutions(reveal bool) []FlagResolution {
+	var resolutions []FlagResolution
+
+	if cli.Parser == nil {
+		return resolutions
+	}
+
+	walkOptions(cli.Parser.Command, func(option *flags.Option) {
+		if option.Hidden {
+			return
+		}
+
+		r := FlagResolution{
+			Flag:    option.String(),
+			Value:   formatEnvValue(reflect.ValueOf(option.Value()), option.EnvDefaultDelim),
+			Default: option.Default,
+			Secret:  isSecretOption(option),
+		}
+
+		if option.EnvDefaultKey != "" {
+			r.Env = option.EnvKeyWithNamespace()
+			if file, ok := dotEnvSources.Load(r.Env); ok {
+				r.EnvFile = file.(string)
+			}
+		}
+
+		_, envSet := os.LookupEnv(r.Env)
+
+		switch {
+		case option.IsSet() && !option.IsSetDefault():
+			r.Source = FlagSourceFlag
+		case !option.IsSetDefault():
+			r.Source = FlagSourceUnset
+		case r.Env != "" && envSet && r.EnvFile != "":
+			r.Source = FlagSourceDotEnv
+		case r.Env != "" && envSet:
+			r.Source = FlagSourceEnv
+		case len(option.Default) > 0:
+			r.Source = FlagSourceDefault
+		default:
+			r.Source = FlagSourceUnset
+		}
+
+		if r.Secret && !reveal && r.Value != "" {
+			r.Value = Redacted
+		}
+
+		resolutions = append(resolutions, r)
+	})
+
+	return resolutions
+}
+
+// TraceResolution logs which .env files were read, how each flag was resolved
+// (see FlagResolutions), how each feature flag was resolved (see Features),
+// and which environment variables were used (see EnvVarsUsed), at the debug
+// level, regardless of the configured log level.
+// This is invoked automatically when --debug-cli is provided, which is useful
+// when users report configuration being ignored. Must be called after Parse().
+func (cli *CLI[T]) TraceResolution() {
+	logger := &log.Logger{Level: log.DebugLevel, Handler: logcli.New(os.Stderr)}
+	if cli.Logger != nil {
+		logger.Handler = cli.Logger.Handler
+	}
+
+	for _, file := range dotEnvFilesRead() {
+		logger.WithField("file", file).Debug("debug-cli: read .env file")
+	}
+
+	for _, r := range cli.FlagResolutions(cli.Reveal) {
+		fields := log.Fields{
+			"flag":   r.Flag,
+			"value":  r.Value,
+			"source": r.Source,
+			"chain":  r.Chain(),
+		}
+
+		if r.EnvFile != "" {
+			fields["env_file"] = r.EnvFile
+		}
+
+		logger.WithFields(fields).Debug("debug-cli: resolved flag")
+	}
+
+	for _, f := range cli.Features() {
+		logger.WithFields(log.Fields{
+			"feature": f.Name,
+			"enabled": f.Enabled,
+			"default": f.Default,
+			"source":  f.Source,
+		}).Debug("debug-cli: resolved feature")
+	}
+
+	logger.WithField("env", strings.Join(cli.EnvVarsUsed(), ",")).Debug("debug-cli: environment variables used")
+}
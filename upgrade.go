@@ -0,0 +1,122 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// ErrDowngrade is returned when downgrade protection is enabled (see
+// WithDowngradeProtection), and the version is older than the version which
+// last used the state directory.
+var ErrDowngrade = errors.New("clix: version is older than the version which last used the state directory")
+
+// UpgradeHook is invoked once after the application is upgraded (or
+// downgraded), i.e. when the version differs from the last run. See
+// WithUpgradeHook.
+type UpgradeHook func(from, to string) error
+
+// WithUpgradeHook registers a hook to invoke when the version differs from the
+// version recorded by the last run, e.g. to run one-time migrations, or print
+// "what's new". Hooks are invoked in order of registration, after the logger
+// is initialized, and before any commands. Registering a hook enables version
+// tracking (see OptEnableVersionTracking). Errors returned by hooks fail
+// parsing, and the new version isn't recorded, so hooks are retried on the
+// next run.
+func WithUpgradeHook(hook UpgradeHook) Option {
+	return func(s *settings) error {
+		if hook == nil {
+			return errors.New("WithUpgradeHook: hook is nil")
+		}
+
+		s.options |= OptEnableVersionTracking
+		s.upgradeHooks = append(s.upgradeHooks, hook)
+		return nil
+	}
+}
+
+// WithDowngradeProtection enables a guard which detects when the version is
+// older than the version recorded by the last run (see
+// OptEnableVersionTracking), which would otherwise risk silently corrupting
+// state or configuration written by the newer version. If refuse is true,
+// parsing fails with an error matching ErrDowngrade, otherwise a warning is
+// reported (see Warn). Either way, the newer version remains recorded, and
+// upgrade hooks aren't invoked. Versions which aren't semantic versions (e.g.
+// "(devel)") are never considered downgrades. Enables version tracking.
+func WithDowngradeProtection(refuse bool) Option {
+	return func(s *settings) error {
+		s.options |= OptEnableVersionTracking
+		s.downgradeProtection = true
+		s.downgradeRefuse = refuse
+		return nil
+	}
+}
+
+// UpgradedFrom returns the version recorded by the last run, and true, if it
+// differs from the current version (i.e. the application was upgraded or
+// downgraded). It always returns false on the first run, and when version
+// tracking is disabled (see OptEnableVersionTracking). Must be called after
+// Parse().
+func (cli *CLI[T]) UpgradedFrom() (string, bool) {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	return cli.upgradedFrom, cli.upgradedFrom != ""
+}
+
+// trackVersion compares the current version with the version recorded by the
+// last run (in the state directory, see StateDir), invoking upgrade hooks if
+// they differ, and records the current version.
+func (cli *CLI[T]) trackVersion() error {
+	dir, err := cli.StateDir()
+	if err != nil {
+		return err
+	}
+
+	fn := filepath.Join(dir, "last-version")
+	current := cli.VersionInfo.Version
+
+	b, err := os.ReadFile(fn)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	previous := strings.TrimSpace(string(b))
+	if previous == current {
+		return nil
+	}
+
+	if cli.settings.downgradeProtection {
+		if c, ok := compareVersions(current, previous); ok && c < 0 {
+			err = fmt.Errorf("%w (%s < %s, remove %s to override)", ErrDowngrade, current, previous, fn)
+			if cli.settings.downgradeRefuse {
+				return err
+			}
+
+			cli.Warn(err.Error(), log.Fields{"version": current, "last_version": previous})
+			return nil
+		}
+	}
+
+	if previous != "" {
+		cli.mu.Lock()
+		cli.upgradedFrom = previous
+		cli.mu.Unlock()
+
+		for _, hook := range cli.settings.upgradeHooks {
+			if err := hook(previous, current); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.WriteFile(fn, []byte(current+"\n"), 0o600)
+}
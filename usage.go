@@ -0,0 +1,224 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// UsageIssue is an aggregated parse failure, recorded locally when
+// OptEnableUsageReport is set. Flag values are never recorded.
+type UsageIssue struct {
+	// Kind is the kind of failure (e.g. "unknown flag" or "invalid choice").
+	Kind string `json:"kind"`
+
+	// Command is the path of the command being parsed (e.g. "server start"),
+	// or the unknown command provided, for "unknown command" failures.
+	Command string `json:"command,omitempty"`
+
+	// Flag is the flag which failed (e.g. "--name"). Unknown flags are recorded
+	// as provided, without dashes.
+	Flag string `json:"flag,omitempty"`
+
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// key returns the key the issue is aggregated with.
+func (i *UsageIssue) key() string {
+	return i.Kind + "|" + i.Command + "|" + i.Flag
+}
+
+// usageState returns the store parse failures are recorded in, which is
+// "usage.json" within the state directory (see StateDir).
+func (cli *CLI[T]) usageState() (*State, error) {
+	dir, err := cli.StateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenState(filepath.Join(dir, "usage.json")), nil
+}
+
+// usageIssue returns the usage issue of the provided parse error, or nil if
+// it's not a parse failure (e.g. an error returned by a command).
+func (cli *CLI[T]) usageIssue(err error) *UsageIssue {
+	var ferr *flags.Error
+	if !errors.As(err, &ferr) {
+		return nil
+	}
+
+	issue := &UsageIssue{Kind: ferr.Type.String()}
+	if ferr.Type == flags.ErrMarshal {
+		issue.Kind = "invalid value"
+	}
+
+	if cli.Parser != nil {
+		issue.Command = activeCommandPath(cli.Parser)
+	}
+
+	switch ferr.Type {
+	case flags.ErrUnknownCommand:
+		// e.g. "Unknown command `foo'".
+		if _, after, ok := strings.Cut(ferr.Message, "`"); ok {
+			name, _, _ := strings.Cut(after, "'")
+			issue.Command = strings.TrimSpace(issue.Command + " " + name)
+		}
+	case flags.ErrCommandRequired:
+	default:
+		var flagErr *FlagError
+		if !errors.As(err, &flagErr) {
+			return nil
+		}
+		issue.Flag = flagErr.Flag
+	}
+
+	return issue
+}
+
+// recordUsageIssue records the provided parse error, if it's a parse failure.
+func (cli *CLI[T]) recordUsageIssue(err error) error {
+	issue := cli.usageIssue(err)
+	if issue == nil {
+		return nil
+	}
+
+	state, err := cli.usageState()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	return state.Update(func(tx *StateTx) error {
+		existing := &UsageIssue{}
+		if ok, _ := tx.Get(issue.key(), existing); ok && existing.Count > 0 {
+			issue.FirstSeen = existing.FirstSeen
+			issue.Count = existing.Count
+		} else {
+			issue.FirstSeen = now
+		}
+
+		issue.Count++
+		issue.LastSeen = now
+
+		return tx.Set(issue.key(), issue)
+	})
+}
+
+// UsageReport returns the parse failures recorded locally (see
+// OptEnableUsageReport), most frequent first. Must be called after Parse().
+func (cli *CLI[T]) UsageReport() ([]*UsageIssue, error) {
+	state, err := cli.usageState()
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*UsageIssue
+
+	err = state.View(func(tx *StateTx) error {
+		for _, key := range tx.Keys() {
+			issue := &UsageIssue{}
+			if _, err := tx.Get(key, issue); err != nil {
+				continue
+			}
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Count != issues[j].Count {
+			return issues[i].Count > issues[j].Count
+		}
+		return issues[i].LastSeen.After(issues[j].LastSeen)
+	})
+
+	return issues, nil
+}
+
+// usageReportCommand is the "usage-report" command, registered with
+// OptEnableUsageReport.
+type usageReportCommand struct {
+	Clear bool `long:"clear" description:"remove all recorded parse failures"`
+
+	run func(clear bool) error
+}
+
+// Execute implements flags.Commander.
+func (c *usageReportCommand) Execute(_ []string) error {
+	return c.run(c.Clear)
+}
+
+// runUsageReport prints a summary of the recorded parse failures to stdout,
+// or clears them.
+func (cli *CLI[T]) runUsageReport(clear bool) error {
+	if clear {
+		state, err := cli.usageState()
+		if err != nil {
+			return err
+		}
+
+		var removed int
+
+		err = state.Update(func(tx *StateTx) error {
+			for _, key := range tx.Keys() {
+				removed++
+				_ = tx.Delete(key)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to clear usage report: %w", err)
+		}
+
+		fmt.Fprintf(os.Stdout, "removed %d recorded parse failures\n", removed)
+		return nil
+	}
+
+	issues, err := cli.UsageReport()
+	if err != nil {
+		return fmt.Errorf("failed to read usage report: %w", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(os.Stdout, "no parse failures recorded")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tKIND\tCOMMAND\tFLAG\tLAST SEEN")
+
+	for _, issue := range issues {
+		fmt.Fprintf(
+			w, "%d\t%s\t%s\t%s\t%s\n",
+			issue.Count, issue.Kind, emptyDash(issue.Command), emptyDash(issue.Flag),
+			issue.LastSeen.Local().Format(time.DateTime),
+		)
+	}
+
+	return w.Flush()
+}
+
+// emptyDash returns s, or "-" if it's empty.
+func emptyDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
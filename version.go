@@ -5,15 +5,21 @@
 package clix
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 
-	"github.com/gookit/color"
+	"golang.org/x/term"
 )
 
 // Module represents a module.
@@ -59,11 +65,344 @@ type VersionInfo[T any] struct {
 	GoVersion string `json:"go_version"` // Version of Go that produced this binary.
 	OS        string `json:"os"`         // Operating system for this build.
 	Arch      string `json:"arch"`       // CPU Architecture for this build.
+	FIPS      bool   `json:"fips"`       // Built with a FIPS validated crypto module.
 
 	// Items hoisted from the parent CLI. Do not change this.
 	Links []Link `json:"links,omitempty"`
 
-	cli *CLI[T] `json:"-"`
+	cli     *CLI[T]          `json:"-"`
+	build   *debug.BuildInfo `json:"-"`
+	collect sync.Once        `json:"-"`
+}
+
+// VersionOptions allows configuring how version information is collected and
+// rendered.
+type VersionOptions struct {
+	// Lazy defers copying build settings and dependencies out of the embedded
+	// build information until they are first needed (e.g. when --version is
+	// used), which reduces startup overhead for binaries with many dependencies.
+	// When enabled, call VersionInfo.Collect() before directly accessing
+	// VersionInfo.Settings or VersionInfo.Dependencies.
+	Lazy bool
+
+	// SortKey is the key used to sort dependencies and build settings. Defaults
+	// to SortByName.
+	SortKey SortKey
+
+	// MaxWidth is the maximum width of the text version output. When unset,
+	// the terminal width is used (or COLUMNS, if set). Long build settings are
+	// wrapped, and dependencies are rendered across multiple lines when they
+	// don't fit.
+	MaxWidth int
+
+	// DependencyFilter, if provided, is used to filter which dependencies are
+	// included in version output. Return true to include the dependency.
+	DependencyFilter func(m Module) bool
+
+	// DependencyOffset and DependencyLimit can be used to paginate dependencies
+	// in version output (applied after DependencyFilter). A limit of 0 means
+	// no limit.
+	DependencyOffset int
+	DependencyLimit  int
+
+	// BuildInfoJSON is optional build information (in the same format as
+	// --version-json output), which is merged over the build information
+	// embedded by the Go toolchain. This is useful for builds where VCS stamping
+	// isn't available (e.g. vendored tarballs, or Bazel), and is typically
+	// provided through go:embed. Only the fields provided are used, and build
+	// settings are merged by key. Disables Lazy.
+	//
+	// Example:
+	//
+	//	//go:embed buildinfo.json
+	//	var buildInfo []byte
+	//	[...]
+	//	cli.VersionOptions.BuildInfoJSON = buildInfo
+	BuildInfoJSON []byte
+}
+
+// eachDependency invokes fn for each dependency, honoring the configured
+// dependency filter and pagination. If dependencies haven't been collected yet
+// (see VersionOptions.Lazy), and they don't need to be re-sorted, they are
+// read directly from the embedded build information without copying them all.
+func (v *VersionInfo[T]) eachDependency(fn func(m Module) error) error {
+	opts := v.cli.VersionOptions
+
+	var skipped, count int
+	emit := func(m Module) (more bool, err error) {
+		if opts.DependencyFilter != nil && !opts.DependencyFilter(m) {
+			return true, nil
+		}
+
+		if skipped < opts.DependencyOffset {
+			skipped++
+			return true, nil
+		}
+
+		if opts.DependencyLimit > 0 && count >= opts.DependencyLimit {
+			return false, nil
+		}
+
+		count++
+		return true, fn(m)
+	}
+
+	if v.Dependencies == nil && v.build != nil && opts.SortKey != SortByVersion {
+		for _, dep := range v.build.Deps {
+			more, err := emit(Module{Path: dep.Path, Version: dep.Version, Sum: dep.Sum})
+			if err != nil || !more {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	v.Collect()
+
+	for _, m := range v.Dependencies {
+		more, err := emit(m)
+		if err != nil || !more {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EncodeJSON writes the version information as indented JSON to w. Unlike
+// json.Marshal, dependencies are streamed one at a time (honoring the
+// dependency filter and pagination options), so memory usage stays bounded
+// and output starts immediately, even with very large dependency graphs.
+func (v *VersionInfo[T]) EncodeJSON(w io.Writer) error {
+	s := &jsonStream{w: bufio.NewWriter(w)}
+
+	s.open('{')
+	s.field("name", v.Name)
+	s.field("build_version", v.Version)
+	s.field("build_commit", v.Commit)
+	s.field("build_date", v.Date)
+
+	// Build settings are small, so they don't need to be streamed, however we
+	// also don't want to use Collect(), as that would copy all dependencies.
+	settings := v.Settings
+	if settings == nil && v.build != nil {
+		settings = make([]BuildSetting, 0, len(v.build.Settings))
+		for _, setting := range v.build.Settings {
+			settings = append(settings, BuildSetting{Key: setting.Key, Value: setting.Value})
+		}
+		sortVersionInfo(settings, nil, v.cli.VersionOptions.SortKey)
+	}
+
+	if len(settings) > 0 {
+		s.field("build_settings", settings)
+	}
+
+	var started bool
+	err := v.eachDependency(func(m Module) error {
+		if !started {
+			s.key("dependencies")
+			s.open('[')
+			started = true
+		}
+
+		s.elem(m)
+		return s.err
+	})
+	if err != nil {
+		return err
+	}
+
+	if started {
+		s.close(']')
+	}
+
+	s.field("command", v.Command)
+	s.field("go_version", v.GoVersion)
+	s.field("os", v.OS)
+	s.field("arch", v.Arch)
+	s.field("fips", v.FIPS)
+
+	if len(v.Links) > 0 {
+		s.field("links", v.Links)
+	}
+
+	s.close('}')
+	s.w.WriteByte('\n')
+
+	if s.err != nil {
+		return s.err
+	}
+
+	return s.w.Flush()
+}
+
+// jsonStream is a minimal streaming JSON writer, used to write large objects
+// incrementally, with the same indentation as json.MarshalIndent.
+type jsonStream struct {
+	w     *bufio.Writer
+	first []bool // Whether the next element is the first, for each depth.
+	err   error
+}
+
+func (s *jsonStream) newline() {
+	s.w.WriteByte('\n')
+	s.w.WriteString(strings.Repeat("    ", len(s.first)))
+}
+
+func (s *jsonStream) sep() {
+	if !s.first[len(s.first)-1] {
+		s.w.WriteByte(',')
+	}
+
+	s.first[len(s.first)-1] = false
+	s.newline()
+}
+
+func (s *jsonStream) open(c byte) {
+	s.w.WriteByte(c)
+	s.first = append(s.first, true)
+}
+
+func (s *jsonStream) close(c byte) {
+	empty := s.first[len(s.first)-1]
+	s.first = s.first[:len(s.first)-1]
+
+	if !empty {
+		s.newline()
+	}
+
+	s.w.WriteByte(c)
+}
+
+func (s *jsonStream) key(k string) {
+	s.sep()
+	s.value(k)
+	s.w.WriteString(": ")
+}
+
+func (s *jsonStream) value(v any) {
+	if s.err != nil {
+		return
+	}
+
+	b, err := json.MarshalIndent(v, strings.Repeat("    ", len(s.first)), "    ")
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	_, s.err = s.w.Write(b)
+}
+
+func (s *jsonStream) field(k string, v any) {
+	s.key(k)
+	s.value(v)
+}
+
+func (s *jsonStream) elem(v any) {
+	s.sep()
+	s.value(v)
+}
+
+// SortKey is the key used to sort version and documentation output.
+type SortKey string
+
+const (
+	SortByName    SortKey = "name"    // Sort by module path/setting key/command name (default).
+	SortByVersion SortKey = "version" // Sort dependencies by version, then path.
+	SortNone      SortKey = "none"    // Keep the order provided by the build information.
+)
+
+// sortVersionInfo sorts the build settings and dependencies by the provided
+// sort key.
+func sortVersionInfo(settings []BuildSetting, deps []Module, key SortKey) {
+	if key == SortNone {
+		return
+	}
+
+	slices.SortStableFunc(settings, func(a, b BuildSetting) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	slices.SortStableFunc(deps, func(a, b Module) int {
+		if key == SortByVersion && a.Version != b.Version {
+			// Non-semantic versions (e.g. "(devel)") are compared as strings.
+			if c, ok := compareVersions(a.Version, b.Version); ok {
+				if c != 0 {
+					return c
+				}
+			} else {
+				return strings.Compare(a.Version, b.Version)
+			}
+		}
+
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// Collect copies build settings and dependencies from the embedded build
+// information, if not already collected. This is only required when
+// VersionOptions.Lazy is enabled, and is safe to call multiple times.
+func (v *VersionInfo[T]) Collect() {
+	v.collect.Do(func() {
+		if v.build == nil {
+			return
+		}
+
+		if v.Settings == nil {
+			v.Settings = make([]BuildSetting, 0, len(v.build.Settings))
+			for _, setting := range v.build.Settings {
+				v.Settings = append(v.Settings, BuildSetting{
+					Key:   setting.Key,
+					Value: setting.Value,
+				})
+			}
+		}
+
+		if v.Dependencies == nil {
+			v.Dependencies = make([]Module, 0, len(v.build.Deps))
+			for _, dep := range v.build.Deps {
+				v.Dependencies = append(v.Dependencies, Module{
+					Path:    dep.Path,
+					Version: dep.Version,
+					Sum:     dep.Sum,
+				})
+			}
+		}
+
+		sortVersionInfo(v.Settings, v.Dependencies, v.cli.VersionOptions.SortKey)
+	})
+}
+
+// MarshalJSON implements json.Marshaler, collecting build settings and
+// dependencies first if needed.
+func (v *VersionInfo[T]) MarshalJSON() ([]byte, error) {
+	v.Collect()
+
+	return json.Marshal((*versionInfoJSON[T])(v))
+}
+
+// versionInfoJSON is used to marshal VersionInfo without recursing into
+// VersionInfo.MarshalJSON.
+type versionInfoJSON[T any] VersionInfo[T]
+
+// readBuildInfo is debug.ReadBuildInfo, cached, as the build information is
+// re-parsed on every call, and it never changes. The returned build
+// information is shared, and must not be modified. Build settings and
+// dependencies reference its strings directly, rather than copying them.
+var readBuildInfo = sync.OnceValues(debug.ReadBuildInfo)
+
+// buildSetting returns the value of the build setting with the given key,
+// otherwise defaults to defaultValue.
+func buildSetting(build *debug.BuildInfo, key, defaultValue string) string {
+	for _, s := range build.Settings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+
+	return defaultValue
 }
 
 // NonSensitiveVersion represents the version information for the CLI.
@@ -77,6 +416,7 @@ type NonSensitiveVersion struct {
 	GoVersion string `json:"go_version"` // Version of Go that produced this binary.
 	OS        string `json:"os"`         // Operating system for this build.
 	Arch      string `json:"arch"`       // CPU Architecture for this build.
+	FIPS      bool   `json:"fips"`       // Built with a FIPS validated crypto module.
 
 	// Items hoisted from the parent CLI. Do not change this.
 	Links []Link `json:"links,omitempty"`
@@ -94,6 +434,7 @@ func (v *VersionInfo[T]) NonSensitive() *NonSensitiveVersion {
 		GoVersion: v.GoVersion,
 		OS:        v.OS,
 		Arch:      v.Arch,
+		FIPS:      v.FIPS,
 
 		Links: v.Links,
 	}
@@ -102,6 +443,8 @@ func (v *VersionInfo[T]) NonSensitive() *NonSensitiveVersion {
 // GetSetting returns the value of the setting with the given key, otherwise
 // defaults to defaultValue.
 func (v *VersionInfo[T]) GetSetting(key, defaultValue string) string {
+	v.Collect()
+
 	if v.Settings == nil {
 		return defaultValue
 	}
@@ -123,6 +466,11 @@ func (v *VersionInfo[T]) stringBase() string {
 	fmt.Fprintf(w, "|    build date :: <green>%s</>\n", v.Date)
 	fmt.Fprintf(w, "|    go version :: <green>%s %s/%s</>\n", v.GoVersion, v.OS, v.Arch)
 
+	// Only shown when enabled, as this is also used for help output.
+	if v.FIPS {
+		fmt.Fprintf(w, "|          fips :: <green>enabled</>\n")
+	}
+
 	if len(v.Links) > 0 {
 		var longest int
 		for _, l := range v.Links {
@@ -149,6 +497,10 @@ func (v *VersionInfo[T]) String() string {
 
 	w.WriteString(v.stringBase())
 
+	v.Collect()
+
+	width := v.cli.VersionOptions.width()
+
 	if !v.cli.IsSet(OptDisableBuildSettings) {
 		var longest int
 		for _, s := range v.Settings {
@@ -157,19 +509,30 @@ func (v *VersionInfo[T]) String() string {
 			}
 		}
 
+		// "|  " + key + " :: ".
+		indent := longest + 7
+
 		fmt.Fprintf(w, "\n<cyan>build options:</>\n")
 		for _, s := range v.Settings {
+			lines := wrapText(s.Value, width-indent)
+
 			fmt.Fprintf(
 				w, "|  %s%s :: <magenta>%s</>\n",
 				strings.Repeat(" ", longest-len(s.Key)),
-				s.Key, s.Value,
+				s.Key, lines[0],
 			)
+
+			for _, line := range lines[1:] {
+				fmt.Fprintf(w, "|%s<magenta>%s</>\n", strings.Repeat(" ", indent-1), line)
+			}
 		}
 	}
 
 	if !v.cli.IsSet(OptDisableDeps) {
-		fmt.Fprintf(w, "\n<cyan>dependencies:</>\n")
-		for _, m := range v.Dependencies {
+		deps := make([]Module, 0, len(v.Dependencies))
+
+		var sumWidth, pathWidth, lineWidth int
+		_ = v.eachDependency(func(m Module) error {
 			if m.Replace != nil {
 				m = *m.Replace
 			}
@@ -178,14 +541,176 @@ func (v *VersionInfo[T]) String() string {
 				m.Sum = "unknown"
 			}
 
-			fmt.Fprintf(w, "  %47s :: <cyan>%s</> :: <yellow>%s</>\n", m.Sum, m.Path, m.Version)
+			sumWidth = max(sumWidth, len(m.Sum))
+			pathWidth = max(pathWidth, len(m.Path))
+			deps = append(deps, m)
+			return nil
+		})
+
+		for _, m := range deps {
+			// "  " + sum + " :: " + path + " :: " + version.
+			lineWidth = max(lineWidth, 10+sumWidth+pathWidth+len(m.Version))
+		}
+
+		fmt.Fprintf(w, "\n<cyan>dependencies:</>\n")
+		for _, m := range deps {
+			if width > 0 && lineWidth > width {
+				// Not enough room for a single aligned line, so fallback to
+				// putting the checksum on its own line.
+				fmt.Fprintf(w, "  <cyan>%s</> :: <yellow>%s</>\n      %s\n", m.Path, m.Version, m.Sum)
+				continue
+			}
+
+			fmt.Fprintf(
+				w, "  %*s :: <cyan>%-*s</> :: <yellow>%s</>\n",
+				sumWidth, m.Sum, pathWidth, m.Path, m.Version,
+			)
+		}
+	}
+
+	return colorize(os.Stdout, w.String())
+}
+
+// width returns the maximum width to use when rendering, based on the
+// terminal width (if stdout is a terminal), the COLUMNS environment variable,
+// and MaxWidth. Returns 0 if there is no limit.
+func (o VersionOptions) width() (width int) {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	} else if cols, err := strconv.Atoi(getenv("COLUMNS")); err == nil && cols > 0 {
+		width = cols
+	}
+
+	if o.MaxWidth > 0 && (width == 0 || o.MaxWidth < width) {
+		width = o.MaxWidth
+	}
+
+	return width
+}
+
+// wrapText wraps s into lines no longer than width, preferring to break after
+// commas and spaces. If width is too small to be useful, s is returned as-is.
+func wrapText(s string, width int) []string {
+	if width < 20 || len(s) <= width {
+		return []string{s}
+	}
+
+	var lines []string
+	for len(s) > width {
+		idx := strings.LastIndexAny(s[:width], ", ")
+		if idx < width/2 {
+			idx = width - 1
+		}
+
+		lines = append(lines, s[:idx+1])
+		s = s[idx+1:]
+	}
+
+	if s != "" {
+		lines = append(lines, s)
+	}
+
+	return lines
+}
+
+// Build information which can be provided at build time through ldflags, and
+// which is preferred over VCS build settings when present. For example, with
+// GoReleaser:
+//
+//	ldflags:
+//	  - -X github.com/lrstanley/clix.BuildVersion={{.Version}}
+//	  - -X github.com/lrstanley/clix.BuildCommit={{.Commit}}
+//	  - -X github.com/lrstanley/clix.BuildDate={{.Date}}
+//
+// If your project already has its own ldflag variables, use
+// VersionInfoFromLDFlags instead.
+var (
+	BuildVersion string
+	BuildCommit  string
+	BuildDate    string
+)
+
+// VersionInfoFromLDFlags returns version information from values provided at
+// build time through ldflags (e.g. GoReleaser's version, commit and date),
+// which should be assigned to CLI.VersionInfo before calling Parse(). Empty
+// and placeholder values (like "none", "unknown", or "dev") are ignored, so
+// VCS build settings are used instead.
+//
+// Example:
+//
+//	var (
+//		version = "dev"
+//		commit  = "none"
+//		date    = "unknown"
+//
+//		cli = &clix.CLI[Flags]{
+//			VersionInfo: clix.VersionInfoFromLDFlags[Flags](version, commit, date),
+//		}
+//	)
+func VersionInfoFromLDFlags[T any](version, commit, date string) *VersionInfo[T] {
+	return &VersionInfo[T]{
+		Version: ldflagValue(version),
+		Commit:  ldflagValue(commit),
+		Date:    ldflagValue(date),
+	}
+}
+
+// parseEmbeddedBuildInfo parses the provided embedded build information (see
+// VersionOptions.BuildInfoJSON).
+func parseEmbeddedBuildInfo[T any](b []byte) (*VersionInfo[T], error) {
+	embedded := &VersionInfo[T]{}
+
+	if err := json.Unmarshal(b, (*versionInfoJSON[T])(embedded)); err != nil {
+		return nil, fmt.Errorf("invalid embedded build info: %w", err)
+	}
+
+	return embedded, nil
+}
+
+// mergeEmbedded merges the build settings and dependencies from the embedded
+// build information over those from the Go toolchain. Build settings are
+// merged by key, and dependencies are replaced entirely (if any are provided).
+func (v *VersionInfo[T]) mergeEmbedded(embedded *VersionInfo[T]) {
+	for _, es := range embedded.Settings {
+		var found bool
+
+		for i := range v.Settings {
+			if v.Settings[i].Key == es.Key {
+				v.Settings[i].Value = es.Value
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			v.Settings = append(v.Settings, es)
 		}
 	}
 
-	return color.Sprint(w.String())
+	if len(embedded.Dependencies) > 0 {
+		v.Dependencies = embedded.Dependencies
+	}
+
+	sortVersionInfo(v.Settings, v.Dependencies, v.cli.VersionOptions.SortKey)
 }
 
-// GetVersionInfo returns the version information for the CLI.
+// ldflagValue returns the provided ldflag value, or an empty string if it's a
+// common placeholder value.
+func ldflagValue(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none", "unknown", "dev", "devel", "(devel)":
+		return ""
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// GetVersionInfo returns the version information for the CLI. Version, commit,
+// and date are resolved (in order of preference) from CLI.VersionInfo (if
+// provided), the Build* ldflag variables, VersionOptions.BuildInfoJSON, and
+// then the build information embedded by the Go toolchain. Within a multi-call
+// binary, the name is the invoked applet's name (see MultiCall), unless
+// provided by CLI.VersionInfo.
 func (cli *CLI[T]) GetVersionInfo() *VersionInfo[T] {
 	v := VersionInfo[T]{}
 
@@ -196,6 +721,50 @@ func (cli *CLI[T]) GetVersionInfo() *VersionInfo[T] {
 		v.Date = cli.VersionInfo.Date
 	}
 
+	// Prefer ldflags-provided values over VCS build settings.
+	if v.Version == "" {
+		v.Version = ldflagValue(BuildVersion)
+	}
+
+	if v.Commit == "" {
+		v.Commit = ldflagValue(BuildCommit)
+	}
+
+	if v.Date == "" {
+		v.Date = ldflagValue(BuildDate)
+	}
+
+	// Applets of multi-call binaries report their own name, rather than the
+	// binary's (see MultiCall).
+	if v.Name == "" {
+		v.Name = appletName()
+	}
+
+	var embedded *VersionInfo[T]
+
+	if len(cli.VersionOptions.BuildInfoJSON) > 0 {
+		// Invalid build info is ignored here, and surfaced when parsing.
+		embedded, _ = parseEmbeddedBuildInfo[T](cli.VersionOptions.BuildInfoJSON)
+	}
+
+	if embedded != nil {
+		if v.Name == "" {
+			v.Name = embedded.Name
+		}
+
+		if v.Version == "" {
+			v.Version = embedded.Version
+		}
+
+		if v.Commit == "" {
+			v.Commit = embedded.Commit
+		}
+
+		if v.Date == "" {
+			v.Date = embedded.Date
+		}
+	}
+
 	v.cli = cli
 	v.GoVersion = runtime.Version()
 	v.Command = filepath.Base(os.Args[0])
@@ -203,28 +772,9 @@ func (cli *CLI[T]) GetVersionInfo() *VersionInfo[T] {
 	v.Arch = runtime.GOARCH
 	v.Links = cli.Links
 
-	build, ok := debug.ReadBuildInfo()
+	build, ok := readBuildInfo()
 	if ok {
-		if v.Settings == nil {
-			v.Settings = make([]BuildSetting, 0, len(build.Settings))
-			for _, setting := range build.Settings {
-				v.Settings = append(v.Settings, BuildSetting{
-					Key:   setting.Key,
-					Value: setting.Value,
-				})
-			}
-		}
-
-		if v.Dependencies == nil {
-			v.Dependencies = make([]Module, 0, len(build.Deps))
-			for _, dep := range build.Deps {
-				v.Dependencies = append(v.Dependencies, Module{
-					Path:    dep.Path,
-					Version: dep.Version,
-					Sum:     dep.Sum,
-				})
-			}
-		}
+		v.build = build
 
 		if v.Name == "" {
 			v.Name = build.Main.Path
@@ -235,12 +785,26 @@ func (cli *CLI[T]) GetVersionInfo() *VersionInfo[T] {
 		}
 
 		if v.Commit == "" {
-			v.Commit = v.GetSetting("vcs.revision", build.Main.Sum)
+			v.Commit = buildSetting(build, "vcs.revision", build.Main.Sum)
 		}
 
 		if v.Date == "" {
-			v.Date = v.GetSetting("vcs.time", "unknown")
+			v.Date = buildSetting(build, "vcs.time", "unknown")
 		}
+
+		if !cli.VersionOptions.Lazy || embedded != nil {
+			v.Collect()
+		}
+	}
+
+	if embedded != nil {
+		v.mergeEmbedded(embedded)
+	}
+
+	v.FIPS = v.detectFIPS(build) || (embedded != nil && embedded.FIPS)
+
+	if len(v.Links) == 0 && !cli.IsSet(OptDisableAutoLinks) && build != nil {
+		v.Links = moduleLinks(build.Main.Path)
 	}
 
 	if v.Name == "" {
@@ -0,0 +1,48 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func BenchmarkGetVersionInfo(b *testing.B) {
+	for _, lazy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("lazy=%v", lazy), func(b *testing.B) {
+			cli := &CLI[struct{}]{VersionOptions: VersionOptions{Lazy: lazy}}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				cli.GetVersionInfo()
+			}
+		})
+	}
+}
+
+func TestSortVersionInfoByVersion(t *testing.T) {
+	deps := []Module{
+		{Path: "example.com/d", Version: "v1.10.0"},
+		{Path: "example.com/c", Version: "(devel)"},
+		{Path: "example.com/b", Version: "v1.9.0"},
+		{Path: "example.com/a", Version: "v1.10.0-rc.1"},
+		{Path: "example.com/e", Version: "v1.9.0"},
+	}
+
+	sortVersionInfo(nil, deps, SortByVersion)
+
+	var got []string
+	for _, dep := range deps {
+		got = append(got, dep.Path)
+	}
+
+	want := []string{"example.com/c", "example.com/b", "example.com/e", "example.com/a", "example.com/d"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected order %q, want %q", got, want)
+	}
+}
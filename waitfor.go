@@ -0,0 +1,242 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	// DefaultWaitForInterval is the initial delay between attempts of
+	// CLI.WaitFor, which doubles after each failed attempt (up to
+	// DefaultWaitForMaxInterval).
+	DefaultWaitForInterval = 250 * time.Millisecond
+
+	// DefaultWaitForMaxInterval is the maximum delay between attempts of
+	// CLI.WaitFor.
+	DefaultWaitForMaxInterval = 5 * time.Second
+)
+
+// CheckHTTP returns a check that the provided URL responds to a GET request
+// with a 200 status code. Network flags are applied (see NetworkConfig).
+func CheckHTTP(rawURL, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: rawURL + " is healthy",
+		Hint: hint,
+		Run: func(ctx context.Context) error {
+			transport, err := DefaultNetwork().Transport()
+			if err != nil {
+				return err
+			}
+			defer transport.CloseIdleConnections()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+			if err != nil {
+				return err
+			}
+
+			resp, err := (&http.Client{Transport: transport}).Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("unexpected status: %s", resp.Status)
+			}
+
+			return nil
+		},
+	}
+}
+
+// CheckFile returns a check that the provided path exists.
+func CheckFile(path, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: path + " exists",
+		Hint: hint,
+		Run: func(_ context.Context) error {
+			_, err := os.Stat(path)
+			return err
+		},
+	}
+}
+
+// Pinger is implemented by database handles (e.g. *sql.DB), and clients which
+// can check their connection.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// CheckPing returns a check that the provided database (e.g. *sql.DB) responds
+// to a ping.
+func CheckPing(name string, db Pinger, hint string) DoctorCheck {
+	return DoctorCheck{
+		Name: name + " is reachable",
+		Hint: hint,
+		Run:  db.PingContext,
+	}
+}
+
+// ParseWaitTarget parses a --wait-for target into a check:
+//
+//   - "tcp://host:port" or "host:port": the TCP address is reachable (see
+//     CheckPort).
+//   - "unix:///path/to/socket": the unix socket accepts connections.
+//   - "http://..." or "https://...": the URL responds with a 200 status code
+//     (see CheckHTTP).
+//   - "file:///path" or "file:path": the file exists (see CheckFile).
+func ParseWaitTarget(target string) (DoctorCheck, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok {
+		if path, isFile := strings.CutPrefix(target, "file:"); isFile && path != "" {
+			return CheckFile(path, ""), nil
+		}
+		scheme, rest = "tcp", target
+	}
+
+	switch strings.ToLower(scheme) {
+	case "tcp":
+		if _, _, err := net.SplitHostPort(rest); err != nil {
+			return DoctorCheck{}, fmt.Errorf("invalid wait target %q: %w", target, err)
+		}
+		return CheckPort(rest, ""), nil
+	case "unix":
+		return DoctorCheck{
+			Name: rest + " accepts connections",
+			Run: func(ctx context.Context) error {
+				conn, err := DefaultNetwork().DialContext(ctx, "unix", rest)
+				if err != nil {
+					return err
+				}
+				return conn.Close()
+			},
+		}, nil
+	case "http", "https":
+		if _, err := url.Parse(target); err != nil {
+			return DoctorCheck{}, fmt.Errorf("invalid wait target %q: %w", target, err)
+		}
+		return CheckHTTP(target, ""), nil
+	case "file":
+		if rest == "" {
+			return DoctorCheck{}, fmt.Errorf("invalid wait target %q: missing path", target)
+		}
+		return CheckFile(rest, ""), nil
+	default:
+		return DoctorCheck{}, fmt.Errorf("invalid wait target %q: unsupported scheme %q (expected tcp, unix, http, https or file)", target, scheme)
+	}
+}
+
+// WaitFor waits until all of the provided checks pass (e.g. dependencies are
+// reachable), retrying failed checks with exponential backoff (see
+// DefaultWaitForInterval), until ctx is done. Checks are run concurrently, and
+// each attempt is limited by the check's timeout (see DoctorCheck.Timeout).
+// Returns an error listing the checks which didn't pass, if ctx is done first.
+//
+// This is invoked automatically before commands run, with the targets
+// provided through --wait-for (see OptEnableWaitFor and ParseWaitTarget).
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	err := cli.WaitFor(ctx, clix.CheckPort("db:5432", ""), clix.CheckHTTP("http://auth:8080/healthz", ""))
+func (cli *CLI[T]) WaitFor(ctx context.Context, checks ...DoctorCheck) error {
+	var l log.Interface = log.Log
+	if cli.Logger != nil {
+		l = cli.Logger
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []string
+		wg     sync.WaitGroup
+	)
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := waitForCheck(ctx, l, check); err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("%s (%v)", check.Name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("timed out waiting for: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// waitForCheck retries the provided check with backoff, until it passes or ctx
+// is done, returning the last error in the latter case.
+func waitForCheck(ctx context.Context, l log.Interface, check DoctorCheck) error {
+	entry := l.WithField("check", check.Name)
+	interval := DefaultWaitForInterval
+	started := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := runCheck(ctx, check)
+		if err == nil {
+			entry.WithField("attempts", attempt).WithField("duration", time.Since(started).Round(time.Millisecond)).Debug("dependency ready")
+			return nil
+		}
+
+		entry.WithError(err).WithField("attempt", attempt).WithField("retry", interval).Info("waiting for dependency")
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, DefaultWaitForMaxInterval)
+	}
+}
+
+// waitForTargets waits for the targets provided through --wait-for, if
+// enabled (see OptEnableWaitFor), limited by --wait-for-timeout.
+func (cli *CLI[T]) waitForTargets() error {
+	if !cli.IsSet(OptEnableWaitFor) || len(cli.WaitForTargets) == 0 {
+		return nil
+	}
+
+	checks := make([]DoctorCheck, 0, len(cli.WaitForTargets))
+
+	for _, target := range cli.WaitForTargets {
+		check, err := ParseWaitTarget(target)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, check)
+	}
+
+	ctx := cli.Context(context.Background())
+
+	if cli.WaitForTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cli.WaitForTimeout)
+		defer cancel()
+	}
+
+	return cli.WaitFor(ctx, checks...)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// Warning is a non-fatal warning collected with Warn.
+type Warning struct {
+	Time    time.Time  `json:"time"`
+	Message string     `json:"message"`
+	Fields  log.Fields `json:"fields,omitempty"`
+}
+
+// Warn records a non-fatal warning, which is logged immediately (if logging
+// is enabled), and also included in a grouped summary printed by
+// FlushWarnings, so important messages aren't lost in verbose output. Safe for
+// concurrent use.
+func (cli *CLI[T]) Warn(msg string, fields log.Fields) {
+	cli.mu.Lock()
+	cli.warnings = append(cli.warnings, Warning{
+		Time:    time.Now(),
+		Message: msg,
+		Fields:  fields,
+	})
+	logger := cli.Logger
+	cli.mu.Unlock()
+
+	if logger != nil {
+		logger.WithFields(fields).Warn(msg)
+	}
+}
+
+// Warnings returns all warnings collected (and not yet flushed) with Warn.
+func (cli *CLI[T]) Warnings() []Warning {
+	cli.mu.Lock()
+	defer cli.mu.Unlock()
+
+	return append([]Warning(nil), cli.warnings...)
+}
+
+// FlushWarnings prints a grouped summary of all warnings collected with Warn
+// to stderr, and writes them as JSON to the path provided with --warnings-json
+// (if any). Collected warnings are then cleared. This is invoked by Finish.
+func (cli *CLI[T]) FlushWarnings() error {
+	cli.mu.Lock()
+	warnings := cli.warnings
+	cli.warnings = nil
+	cli.mu.Unlock()
+
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	PrintWarnings(os.Stderr, warnings)
+
+	if cli.WarningsJSON == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(warnings, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(cli.WarningsJSON, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write warnings: %w", err)
+	}
+
+	return nil
+}
+
+// PrintWarnings writes a summary of the provided warnings to out, grouped by
+// message (in order of first occurrence), with the number of occurrences and
+// the fields of each occurrence, colored if color is enabled for out (see
+// ColorEnabled).
+func PrintWarnings(out io.Writer, warnings []Warning) {
+	var order []string
+	groups := make(map[string][]Warning)
+	f := streamFor(out)
+
+	for _, w := range warnings {
+		if _, ok := groups[w.Message]; !ok {
+			order = append(order, w.Message)
+		}
+		groups[w.Message] = append(groups[w.Message], w)
+	}
+
+	fmt.Fprint(out, colorize(f, fmt.Sprintf("\n<yellow>%d warning(s):</>\n", len(warnings))))
+
+	for _, msg := range order {
+		group := groups[msg]
+
+		// Messages (and fields) are application-provided, so they're written
+		// as-is, rather than being interpreted as color tags.
+		fmt.Fprint(out, colorize(f, fmt.Sprintf("  <yellow>%s</> ", bullet())), msg)
+
+		if len(group) > 1 {
+			fmt.Fprint(out, colorize(f, fmt.Sprintf(" <gray>(x%d)</>", len(group))))
+		}
+
+		fmt.Fprintln(out)
+
+		for _, w := range group {
+			if len(w.Fields) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(out, "      %s\n", colorText(f, "gray", formatFields(w.Fields)))
+		}
+	}
+}
+
+// formatFields formats fields as sorted key=value pairs.
+func formatFields(fields log.Fields) string {
+	names := fields.Names()
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, fields[name]))
+	}
+
+	return strings.Join(pairs, " ")
+}
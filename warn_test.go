@@ -0,0 +1,31 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/lrstanley/clix"
+)
+
+func TestPrintWarningsVerbatim(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	var buf bytes.Buffer
+
+	clix.PrintWarnings(&buf, []clix.Warning{
+		{Message: "value <red>x</> is <ignored>", Fields: log.Fields{"path": "<cyan>a</>"}},
+		{Message: "value <red>x</> is <ignored>"},
+	})
+
+	for _, want := range []string{"value <red>x</> is <ignored> (x2)\n", "path=<cyan>a</>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) Liam Stanley <liam@liam.sh>. All rights reserved. Use of
+// this source code is governed by the MIT license that can be found in
+// the LICENSE file.
+
+package clix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long Watch waits for changes to settle, before
+// invoking the callback with all changes in that period. This avoids invoking
+// the callback multiple times when an editor (or deployment tool) writes a file
+// in several steps.
+const DefaultWatchDebounce = 250 * time.Millisecond
+
+// WatchEvent is a (debounced) batch of changes, provided by Watch.
+type WatchEvent struct {
+	// Paths are the paths which were created, modified, removed or renamed,
+	// in the order they were first changed.
+	Paths []string
+}
+
+// Watch returns a Runner which watches the provided paths for changes, invoking
+// fn with each (debounced) batch of changes, until the context is cancelled.
+// Paths can be files (which don't need to exist yet), directories (which
+// aren't watched recursively), or glob patterns (e.g. "conf.d/*.yaml", where
+// only the last path element can contain a pattern). Files are watched through
+// their parent directory, so changes are still detected when a file is
+// replaced (e.g. atomically renamed over, like most editors and Kubernetes
+// ConfigMaps do).
+//
+// If fn returns an error, watching stops and the runner returns the error.
+//
+// Example:
+//
+//	err := clix.Run(
+//		cli.Watch([]string{"config.yaml", "conf.d/*.yaml"}, func(event clix.WatchEvent) error {
+//			cli.Logger.WithField("paths", event.Paths).Info("reloading config")
+//			return reloadConfig()
+//		}),
+//		cli.Flags.HTTP.Serve(srv, cli.Logger),
+//	)
+func (cli *CLI[T]) Watch(paths []string, fn func(event WatchEvent) error) Runner {
+	return func(ctx context.Context) error {
+		var logger log.Interface = cli.Logger
+		if cli.Logger == nil {
+			logger = LoggerFrom(ctx)
+		}
+
+		return watch(ctx, logger, paths, DefaultWatchDebounce, fn)
+	}
+}
+
+// watchPattern is a pattern matched against the paths of events within dir.
+type watchPattern struct {
+	dir     string
+	pattern string
+}
+
+// watchPatterns converts the provided paths to the patterns used to match
+// events.
+func watchPatterns(paths []string) ([]watchPattern, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no paths provided to watch")
+	}
+
+	patterns := make([]watchPattern, 0, len(paths))
+
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		dir, base := filepath.Split(path)
+		dir = filepath.Clean(dir)
+
+		if strings.ContainsAny(dir, "*?[") {
+			return nil, fmt.Errorf("invalid watch path %q: only the last path element can contain a pattern", path)
+		}
+
+		if _, err = filepath.Match(base, ""); err != nil {
+			return nil, fmt.Errorf("invalid watch path %q: %w", path, err)
+		}
+
+		if !strings.ContainsAny(base, "*?[") {
+			if info, serr := os.Stat(path); serr == nil && info.IsDir() {
+				dir, base = path, "*"
+			} else {
+				base = escapePattern(base)
+			}
+		}
+
+		patterns = append(patterns, watchPattern{dir: dir, pattern: filepath.Join(escapePattern(dir), base)})
+	}
+
+	return patterns, nil
+}
+
+// escapePattern escapes any pattern characters in the provided path.
+func escapePattern(path string) string {
+	if filepath.Separator == '\\' {
+		// Escaping isn't supported on Windows, though these characters aren't
+		// valid in paths anyway.
+		return path
+	}
+
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`).Replace(path)
+}
+
+// watch watches the provided paths, until the context is cancelled, or fn
+// returns an error.
+func watch(ctx context.Context, logger log.Interface, paths []string, debounce time.Duration, fn func(event WatchEvent) error) error {
+	patterns, err := watchPatterns(paths)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, p := range patterns {
+		if slices.Contains(watcher.WatchList(), p.dir) {
+			continue
+		}
+
+		if err = watcher.Add(p.dir); err != nil {
+			return fmt.Errorf("failed to watch %q: %w", p.dir, err)
+		}
+
+		logger.WithField("path", p.dir).Debug("watching for changes")
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	var pending []string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case werr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			logger.WithError(werr).Warn("error while watching for changes")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// Ignore metadata-only changes (e.g. from backup tools or indexers).
+			if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			if !slices.ContainsFunc(patterns, func(p watchPattern) bool {
+				matched, _ := filepath.Match(p.pattern, event.Name)
+				return matched
+			}) {
+				continue
+			}
+
+			if !slices.Contains(pending, event.Name) {
+				pending = append(pending, event.Name)
+			}
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			timer.Reset(debounce)
+		case <-timer.C:
+			event := WatchEvent{Paths: pending}
+			pending = nil
+
+			logger.WithField("changes", len(event.Paths)).Debug("detected changes")
+
+			if err = fn(event); err != nil {
+				return err
+			}
+		}
+	}
+}